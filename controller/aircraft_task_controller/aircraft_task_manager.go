@@ -2,126 +2,82 @@ package aircraft_task_controller
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"strconv"
-	"uam-power-backend/models/config_models/db_config_model"
+	"time"
 	"uam-power-backend/models/controller_models/aircraft_task_model"
-	"uam-power-backend/service/db_service"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/webhook_service"
 	"uam-power-backend/utils"
 )
 
 type AircraftTaskModel struct {
-	MysqlService       *dbservice.MySQLService
-	RedisService       *dbservice.RedisDict
-	FlightMysqlService *dbservice.MySQLService
-	EventMysqlService  *dbservice.MySQLService
+	Tasks repository_service.TaskRepository
+	// RedisService 进行中的任务，按 AircraftID 保存
+	RedisService repository_service.KVStore
+	// Webhooks 任务开始与结束时通知 webhook 订阅，为 nil 时不通知
+	Webhooks *webhook_service.Dispatcher
 }
 
-func NewAircraftTaskModel(
-	RedisCfg *db_config_model.RedisConfigModel,
-	MySqlCfg *db_config_model.MySqlConfigModel,
-) *AircraftTaskModel {
-	mysqlLink := fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		MySqlCfg.Usr, MySqlCfg.Psw, MySqlCfg.Host, MySqlCfg.Port,
-		MySqlCfg.DB,
-	)
-	MysqlService, err := dbservice.NewMySQLService(mysqlLink)
-	if err != nil {
-		return nil
-	}
-	utils.MsgSuccess("        [AircraftTaskModel]Successfully SystemMysql!")
-	mysqlLink = fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		MySqlCfg.Usr, MySqlCfg.Psw, MySqlCfg.Host, MySqlCfg.Port,
-		MySqlCfg.FlightDB,
-	)
-	FlightMysqlService, FlightErr := dbservice.NewMySQLService(mysqlLink)
-	if FlightErr != nil {
-		return nil
-	}
-	utils.MsgSuccess("        [AircraftTaskModel]Successfully FlightMysql!")
-	mysqlLink = fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		MySqlCfg.Usr, MySqlCfg.Psw, MySqlCfg.Host, MySqlCfg.Port,
-		MySqlCfg.EventDB,
-	)
-	EventMysqlService, EventErr := dbservice.NewMySQLService(mysqlLink)
-	if EventErr != nil {
-		return nil
-	}
-	utils.MsgSuccess("        [AircraftTaskModel]Successfully EventMysql!")
-	RedisInfo := dbservice.NewRedisDict(RedisCfg.Host, RedisCfg.Port, RedisCfg.TaskInfoDBno)
-	utils.MsgSuccess("        [AircraftTaskModel]Successfully Redis!")
+// NewAircraftTaskModel 使用 app 中共享的 MySQL 连接池、Redis 客户端与 webhook 投递服务创建控制器
+func NewAircraftTaskModel(app *app_service.Container) *AircraftTaskModel {
+	RedisInfo := app.Redis.WithPrefix(app.Config.RedisCfg.TaskInfoPrefix)
+	// 进行中的任务在结束前保存在任务 Redis 中，键数即活跃任务数
+	metrics_service.RegisterActiveTasks(func() (int, error) {
+		keys, err := RedisInfo.Keys()
+		return len(keys), err
+	})
 	utils.MsgSuccess("        [AircraftTaskModel]Successfully init!")
-	return &AircraftTaskModel{
-		MysqlService: MysqlService, RedisService: RedisInfo,
-		FlightMysqlService: FlightMysqlService, EventMysqlService: EventMysqlService,
+	return NewAircraftTaskModelFromStores(
+		repository_service.NewMySQLTaskRepository(app.SystemDB, app.FlightDB, app.EventDB), RedisInfo, app.Webhooks,
+	)
+}
+
+// NewAircraftTaskModelFromStores 由任务表、进行中任务的缓存与 webhook 投递服务（可为 nil）创建控制器，测试中可传入内存实现
+func NewAircraftTaskModelFromStores(
+	tasks repository_service.TaskRepository, cache repository_service.KVStore, webhooks *webhook_service.Dispatcher,
+) *AircraftTaskModel {
+	return &AircraftTaskModel{Tasks: tasks, RedisService: cache, Webhooks: webhooks}
+}
+
+// notify 通知 webhook 订阅任务开始或结束。任务已经生效，通知写入失败只记录日志
+func (taskModel *AircraftTaskModel) notify(c *gin.Context, notificationType string, task *aircraft_task_model.MysqlAircraftTask) {
+	id := "task:" + strconv.Itoa(task.TaskID) + ":" + notificationType
+	if err := taskModel.Webhooks.Notify(c.Request.Context(), notificationType, id, task); err != nil {
+		utils.MsgError("        [AircraftTaskModel]Failed to notify webhooks of " + id + " > " + err.Error())
 	}
 }
 
 func (taskModel *AircraftTaskModel) CreateTask(c *gin.Context) {
-	curStr := utils.GetTimeStr()
 	var TaskInfo aircraft_task_model.CreateTaskAircraftInfo
 	if err := c.ShouldBindJSON(&TaskInfo); err != nil {
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		utils.MsgError("        [AircraftTaskModel]CreateTask Invalid Request JSON data")
 		return
 	}
-	FlightTable := fmt.Sprintf("%sFlight_AirID%d_Lane%d", curStr, TaskInfo.AircraftID, TaskInfo.LaneID)
-	EventTable := fmt.Sprintf("%sEvent_AirID%d_Lane%d", curStr, TaskInfo.AircraftID, TaskInfo.LaneID)
-	_, err := taskModel.FlightMysqlService.ExecuteCmd(
-		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (Longitude DOUBLE(15, 12), Latitude DOUBLE(15, 12), Altitude DOUBLE(15, 12), Yaw DOUBLE(15, 12), DataTime DATETIME(6),  UploadTime DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6));",
-			FlightTable,
-		))
-	if err != nil {
-		c.JSON(403, gin.H{"msg": "Create Status Table Failed!"})
-		utils.MsgError("        [AircraftTaskModel]CreateTask Create Table Failed!")
-		return
-	}
-	_, err = taskModel.EventMysqlService.ExecuteCmd(
-		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (DataTime DATETIME(6),  CreateTime DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6), Event char(20) not NULL);",
-			EventTable,
-		))
-	if err != nil {
-		c.JSON(403, gin.H{"msg": "Create Event Table Failed!"})
-		utils.MsgError("        [AircraftTaskModel]CreateTask Create Table Failed!")
-		return
-	}
-	_, err = taskModel.MysqlService.ExecuteCmd(
-		fmt.Sprintf("INSERT INTO systemdb.flight_task_table(AircraftID, LaneID, TrackTable, EventTable, TimeStr) VALUES (%d, %d, '%s', '%s', '%s');",
-			TaskInfo.AircraftID, TaskInfo.LaneID, FlightTable, EventTable, curStr,
-		))
-	if err != nil {
-		c.JSON(403, gin.H{"msg": "Insert failed"})
-		utils.MsgError("        [AircraftTaskModel]CreateTask Create Task Failed!")
-		return
-	}
-	mysqlRe, mysqlErr := taskModel.MysqlService.QueryRow(
-		fmt.Sprintf("Select * from systemdb.flight_task_table where TimeStr = '%s';",
-			curStr))
-	if mysqlErr != nil {
+	task, err := taskModel.Tasks.CreateTask(c.Request.Context(), TaskInfo.AircraftID, TaskInfo.LaneID)
+	if errors.Is(err, repository_service.ErrNotFound) {
 		c.JSON(404, gin.H{"msg": "N.A.!"})
 		utils.MsgError("        [AircraftTaskModel]CreateTask Query sql failed!")
 		return
-	}
-	jsonData, _ := json.Marshal(mysqlRe)
-	var mysqlData aircraft_task_model.MysqlAircraftTask
-	err = json.Unmarshal(jsonData, &mysqlData)
-	if err != nil {
-		c.JSON(403, gin.H{"msg": "Hit redis Failed"})
-		utils.MsgError("        [AircraftTaskModel]CreateTask failed to redis!")
+	} else if err != nil {
+		c.JSON(403, gin.H{"msg": "Create Task Failed!"})
+		utils.MsgError("        [AircraftTaskModel]CreateTask Create Task Failed! >" + err.Error())
 		return
 	}
-	err = taskModel.RedisService.Set(strconv.Itoa(mysqlData.AircraftID), string(jsonData))
+	err = taskModel.RedisService.Set(strconv.Itoa(task.AircraftID), task)
 	if err != nil {
 		c.JSON(403, gin.H{"msg": "Hit redis Failed"})
 		utils.MsgError("        [AircraftTaskModel]CreateTask failed to redis!")
 		return
 	}
+	taskModel.notify(c, webhook_model.TypeTaskStarted, task)
 	utils.MsgSuccess("        [AircraftTaskModel]Successfully create Task!")
-	c.JSON(200, gin.H{"msg": "Successfully CreateTask!", "data": mysqlRe})
+	c.JSON(200, gin.H{"msg": "Successfully CreateTask!", "data": task})
 }
 
 func (taskModel *AircraftTaskModel) EndTask(c *gin.Context) {
@@ -151,11 +107,8 @@ func (taskModel *AircraftTaskModel) EndTask(c *gin.Context) {
 		c.JSON(404, gin.H{"msg": "No such Task!"})
 		return
 	}
-	_, MysqlErr := taskModel.MysqlService.ExecuteCmd(
-		fmt.Sprintf("UPDATE systemdb.flight_task_table SET EndTime = '%s' WHERE TaskID = %d;",
-			utils.GetMySqlTimeStr(), mysqlData.TaskID,
-		),
-	)
+	endTime := time.Now()
+	MysqlErr := taskModel.Tasks.EndTask(c.Request.Context(), mysqlData.TaskID, endTime)
 	if MysqlErr != nil {
 		utils.MsgError("        [AircraftTaskModel]EndTask Set Task ID failed!")
 		c.JSON(403, gin.H{"msg": "Failed to end Task!"})
@@ -167,6 +120,8 @@ func (taskModel *AircraftTaskModel) EndTask(c *gin.Context) {
 		c.JSON(403, gin.H{"msg": "Failed to end set Redis!"})
 		return
 	}
+	mysqlData.EndTime = &endTime
+	taskModel.notify(c, webhook_model.TypeTaskEnded, &mysqlData)
 	utils.MsgSuccess("        [AircraftTaskModel]Successfully EndTask!")
 	c.JSON(200, gin.H{"msg": "Successfully EndTask!"})
 }
@@ -187,23 +142,13 @@ func (taskModel *AircraftTaskModel) CheckTaskInfo(c *gin.Context) {
 		return
 	}
 	if re == nil {
-		row, err := taskModel.MysqlService.QueryRow(
-			fmt.Sprintf("SELECT * FROM systemdb.flight_task_table WHERE TaskID = %d;",
-				aircraftReq.TaskID))
-		if err != nil {
-			utils.MsgError("        [AircraftTaskModel]CheckTaskInfo no such Task!")
-			c.JSON(404, gin.H{"msg": "Not Found"})
-			return
-		}
-		jsonData, _ := json.Marshal(row)
-		var mysqlData aircraft_task_model.MysqlAircraftTask
-		err = json.Unmarshal(jsonData, &mysqlData)
+		task, err := taskModel.Tasks.GetTask(c.Request.Context(), aircraftReq.TaskID)
 		if err != nil {
 			utils.MsgError("        [AircraftTaskModel]CheckTaskInfo no such Task!")
 			c.JSON(404, gin.H{"msg": "Not Found"})
 			return
 		}
-		err = taskModel.RedisService.Set(strconv.Itoa(mysqlData.AircraftID), string(jsonData))
+		err = taskModel.RedisService.Set(strconv.Itoa(task.AircraftID), task)
 		if err != nil {
 			c.JSON(403, gin.H{"msg": "Hit redis Failed"})
 			utils.MsgError("        [AircraftTaskModel]CreateTask failed to redis!")
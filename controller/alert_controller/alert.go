@@ -0,0 +1,140 @@
+package alert_controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// defaultListCount/maxListCount 告警列表默认与最多返回的条数
+const (
+	defaultListCount = 100
+	maxListCount     = 1000
+)
+
+type AlertController struct {
+	Alerts repository_service.AlertRepository
+}
+
+// NewAlertController 使用 app 中共享的系统库连接池创建控制器
+func NewAlertController(app *app_service.Container) *AlertController {
+	utils.MsgSuccess("        [AlertController]init successfully!")
+	return NewAlertControllerFromStores(repository_service.NewMySQLAlertRepository(app.SystemDB))
+}
+
+// NewAlertControllerFromStores 由告警存储创建控制器，测试中可传入内存实现
+func NewAlertControllerFromStores(alerts repository_service.AlertRepository) *AlertController {
+	return &AlertController{Alerts: alerts}
+}
+
+// ListAlerts 按状态、飞行器与严重级别筛选告警，从新到旧返回
+func (a *AlertController) ListAlerts(c *gin.Context) {
+	var request alert_model.ListAlertsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [AlertController]ListAlerts Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	switch request.Status {
+	case "", alert_model.StatusOpen, alert_model.StatusAcknowledged, alert_model.StatusResolved:
+	default:
+		c.JSON(400, gin.H{"msg": "Invalid Status"})
+		return
+	}
+	if request.Severity != "" && !event_catalog_model.IsValidSeverity(request.Severity) {
+		c.JSON(400, gin.H{"msg": "Invalid Severity"})
+		return
+	}
+	if request.Count <= 0 {
+		request.Count = defaultListCount
+	} else if request.Count > maxListCount {
+		request.Count = maxListCount
+	}
+	alerts, err := a.Alerts.ListAlerts(c.Request.Context(), &request)
+	if err != nil {
+		utils.MsgError("        [AlertController]ListAlerts failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read alerts"})
+		return
+	}
+	if alerts == nil {
+		alerts = []alert_model.Alert{}
+	}
+	utils.MsgSuccess("        [AlertController]ListAlerts successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ListAlerts!", "data": alerts})
+}
+
+// AcknowledgeAlert 确认 open 状态的告警，Assignee 为空时由操作人负责
+func (a *AlertController) AcknowledgeAlert(c *gin.Context) {
+	a.transition(c, "AcknowledgeAlert", []string{alert_model.StatusOpen}, alert_model.ActionAcknowledge,
+		alert_model.StatusAcknowledged)
+}
+
+// ResolveAlert 解决 open 或 acknowledged 状态的告警
+func (a *AlertController) ResolveAlert(c *gin.Context) {
+	a.transition(c, "ResolveAlert", []string{alert_model.StatusOpen, alert_model.StatusAcknowledged},
+		alert_model.ActionResolve, alert_model.StatusResolved)
+}
+
+// transition 将告警从 from 中的状态改为 to 并写入审计记录；状态不符时返回 409
+func (a *AlertController) transition(c *gin.Context, handler string, from []string, action, to string) {
+	var request alert_model.AlertActionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [AlertController]" + handler + " Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	if request.Operator == "" {
+		c.JSON(400, gin.H{"msg": "Operator is required"})
+		return
+	}
+	assignee := request.Assignee
+	if assignee == "" && to == alert_model.StatusAcknowledged {
+		assignee = request.Operator
+	}
+	alert, err := a.Alerts.TransitionAlert(c.Request.Context(), request.AlertID, from, &alert_model.AlertChange{
+		Action: action, Status: to, Assignee: assignee, Operator: request.Operator, Note: request.Note,
+	})
+	switch {
+	case errors.Is(err, repository_service.ErrNotFound):
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	case errors.Is(err, repository_service.ErrConflict):
+		utils.MsgError("        [AlertController]" + handler + " conflict > " + err.Error())
+		c.JSON(409, gin.H{"msg": "Alert state does not allow this action", "error": err.Error()})
+		return
+	case err != nil:
+		utils.MsgError("        [AlertController]" + handler + " failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to update alert"})
+		return
+	}
+	metrics_service.AlertTransitions.WithLabelValues(action).Inc()
+	utils.MsgSuccess("        [AlertController]" + handler + " successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully " + handler + "!", "data": alert})
+}
+
+// AlertAudit 返回告警的全部状态变化，按时间先后排列
+func (a *AlertController) AlertAudit(c *gin.Context) {
+	var request alert_model.AlertIDRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [AlertController]AlertAudit Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	if _, err := a.Alerts.GetAlert(c.Request.Context(), request.AlertID); errors.Is(err, repository_service.ErrNotFound) {
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	}
+	audit, err := a.Alerts.ListAlertAudit(c.Request.Context(), request.AlertID)
+	if err != nil {
+		utils.MsgError("        [AlertController]AlertAudit failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read alert audit"})
+		return
+	}
+	utils.MsgSuccess("        [AlertController]AlertAudit successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully AlertAudit!", "data": audit})
+}
@@ -2,23 +2,54 @@ package data_controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"math"
 	"strconv"
+	"time"
 	"uam-power-backend/models/config_models/db_config_model"
 	"uam-power-backend/models/controller_models/data_flow_model"
-	"uam-power-backend/service/db_service"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
 	"uam-power-backend/utils"
 )
 
 type RequestAircraft struct {
-	StatusRedisService *dbservice.RedisDict
-	EventRedisService  *dbservice.RedisDict
+	StatusRedisService repository_service.LatestStore
+	EventRedisService  repository_service.EventStore
+	// DedupRedisService 链路质量统计与各飞行器最近接收状态的时间
+	DedupRedisService repository_service.LinkStore
+	// EventHistorySize/EventGlobalHistorySize 单次查询返回的事件数上限
+	EventHistorySize       int
+	EventGlobalHistorySize int
+	// LinkTimeout 最新状态超过该时长未更新即标记为 stale，为 0 时不标记
+	LinkTimeout time.Duration
+	// legacyZone 查询参数中不带时区的旧格式时间所在的时区，与上传接口相同
+	legacyZone *time.Location
 }
 
-func NewReceiveAircraft(redisConfig *db_config_model.RedisConfigModel) *RequestAircraft {
+// NewReceiveAircraft 使用 app 中共享的 Redis 客户端创建控制器
+func NewReceiveAircraft(app *app_service.Container) *RequestAircraft {
+	redisCfg := &app.Config.RedisCfg
 	utils.MsgSuccess("        [ReceiveAircraft]init successfully!")
-	redisStatusService := dbservice.NewRedisDict(redisConfig.Host, redisConfig.Port, redisConfig.StatusDBno)
-	redisEventService := dbservice.NewRedisDict(redisConfig.Host, redisConfig.Port, redisConfig.EventDBno)
-	return &RequestAircraft{StatusRedisService: redisStatusService, EventRedisService: redisEventService}
+	return NewReceiveAircraftFromStores(
+		app.Redis.WithPrefix(redisCfg.StatusPrefix), app.Redis.WithPrefix(redisCfg.EventPrefix),
+		app.Redis.WithPrefix(redisCfg.DedupPrefix), &app.Config.PipelineCfg,
+	)
+}
+
+// NewReceiveAircraftFromStores 由状态、事件与链路统计缓存创建控制器，测试中可传入内存实现；
+// 旧格式时间按 PipelineCfg.LegacyTimeZone 解释（已在加载配置时校验）
+func NewReceiveAircraftFromStores(
+	status repository_service.LatestStore, event repository_service.EventStore, dedup repository_service.LinkStore,
+	pipelineConfig *db_config_model.PipelineConfigModel,
+) *RequestAircraft {
+	legacyZone, _ := time.LoadLocation(pipelineConfig.LegacyTimeZone)
+	return &RequestAircraft{
+		StatusRedisService: status, EventRedisService: event, DedupRedisService: dedup,
+		EventHistorySize: pipelineConfig.EventHistorySize, EventGlobalHistorySize: pipelineConfig.EventGlobalHistorySize,
+		LinkTimeout: time.Duration(pipelineConfig.LinkTimeoutSec) * time.Second, legacyZone: legacyZone,
+	}
 }
 
 func (receiver *RequestAircraft) RequestAircraftStatus(c *gin.Context) {
@@ -32,18 +63,44 @@ func (receiver *RequestAircraft) RequestAircraftStatus(c *gin.Context) {
 	}
 
 	rec, err := receiver.StatusRedisService.Get(strconv.Itoa(aircraftReq.AircraftID))
+	metrics_service.ObserveRedisLookup("ReceiveAircraft", rec, err)
 	if err != nil || rec == nil {
 		utils.MsgError("        [ReceiveAircraft]RequestAircraftStatus Invalid JSON data!")
 		c.JSON(404, gin.H{"msg": "N.A.!"})
 		return
 	}
-	utils.MsgError("        [ReceiveAircraft]RequestAircraftStatus Invalid JSON data!")
-	c.JSON(200, gin.H{"msg": "Successfully requestData!", "data": rec})
+	response := gin.H{"msg": "Successfully requestData!", "data": rec}
+	if age, ok := receiver.statusAge(aircraftReq.AircraftID, rec); ok {
+		response["ageMs"] = age.Milliseconds()
+		response["stale"] = receiver.LinkTimeout > 0 && age >= receiver.LinkTimeout
+	}
+	utils.MsgSuccess("        [ReceiveAircraft]RequestAircraftStatus successfully!")
+	c.JSON(200, response)
 	return
 }
 
+// statusAge 返回最新状态距今的时长：优先取服务端最近接收该飞行器状态的时间（链路监测开启时记录），
+// 否则取状态自带的 TimeString；均不可用时第二个返回值为 false
+func (receiver *RequestAircraft) statusAge(AircraftID int, rec interface{}) (time.Duration, bool) {
+	seenAt, ok, err := receiver.DedupRedisService.LinkSeenAt(data_flow_model.LinkSeenKey, strconv.Itoa(AircraftID))
+	if err == nil && ok {
+		return time.Since(seenAt), true
+	}
+	status, isMap := rec.(map[string]interface{})
+	if !isMap {
+		return 0, false
+	}
+	timeStr, _ := status["TimeString"].(string)
+	statusTime, err := utils.ParseSqlTimeStr(timeStr)
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(statusTime), true
+}
+
+// RequestAircraftEvent 返回飞行器的最新事件；请求带 Count、Since 或 Severity 时返回最近事件列表（从新到旧）
 func (receiver *RequestAircraft) RequestAircraftEvent(c *gin.Context) {
-	var aircraftReq data_flow_model.RecAircraftStatusRequest
+	var aircraftReq data_flow_model.RecAircraftEventRequest
 
 	// 绑定 JSON 数据到结构体
 	if err := c.ShouldBindJSON(&aircraftReq); err != nil {
@@ -51,8 +108,15 @@ func (receiver *RequestAircraft) RequestAircraftEvent(c *gin.Context) {
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		return
 	}
+	if aircraftReq.Count != 0 || aircraftReq.Since != "" || aircraftReq.Severity != "" {
+		receiver.requestEventHistory(c, "RequestAircraftEvent",
+			data_flow_model.EventHistoryKey(aircraftReq.AircraftID), receiver.EventHistorySize,
+			aircraftReq.Count, aircraftReq.Since, aircraftReq.Severity)
+		return
+	}
 
 	rec, err := receiver.EventRedisService.Get(strconv.Itoa(aircraftReq.AircraftID))
+	metrics_service.ObserveRedisLookup("ReceiveAircraft", rec, err)
 	if err != nil || rec == nil {
 		utils.MsgError("        [ReceiveAircraft]RequestAircraftEvent Invalid JSON data!")
 		c.JSON(404, gin.H{"msg": "N.A.!"})
@@ -62,3 +126,105 @@ func (receiver *RequestAircraft) RequestAircraftEvent(c *gin.Context) {
 	c.JSON(200, gin.H{"msg": "Successfully requestData!", "data": rec})
 	return
 }
+
+// RequestRecentEvents 返回全部飞行器的最近事件（从新到旧），供告警面板使用
+func (receiver *RequestAircraft) RequestRecentEvents(c *gin.Context) {
+	var eventsReq data_flow_model.RecRecentEventsRequest
+
+	// 绑定 JSON 数据到结构体
+	if err := c.ShouldBindJSON(&eventsReq); err != nil {
+		utils.MsgError("        [ReceiveAircraft]RequestRecentEvents Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	receiver.requestEventHistory(c, "RequestRecentEvents",
+		data_flow_model.GlobalEventHistoryKey, receiver.EventGlobalHistorySize, eventsReq.Count, eventsReq.Since,
+		eventsReq.Severity)
+}
+
+// requestEventHistory 读取事件历史 key 中不早于 since 的至多 count 条事件，count 为 0 或超过 limit 时取 limit；
+// severity 不为空时只返回该严重级别的事件，在保留的全部历史中筛选
+func (receiver *RequestAircraft) requestEventHistory(
+	c *gin.Context, handler, key string, limit, count int, since, severity string,
+) {
+	if limit <= 0 {
+		c.JSON(404, gin.H{"msg": "Event history is disabled"})
+		return
+	}
+	if count < 0 {
+		utils.MsgError("        [ReceiveAircraft]" + handler + " Invalid Count!")
+		c.JSON(400, gin.H{"msg": "Invalid Count"})
+		return
+	}
+	if count == 0 || count > limit {
+		count = limit
+	}
+	if severity != "" && !event_catalog_model.IsValidSeverity(severity) {
+		utils.MsgError("        [ReceiveAircraft]" + handler + " Invalid Severity!")
+		c.JSON(400, gin.H{"msg": "Invalid Severity"})
+		return
+	}
+	sinceScore := math.Inf(-1)
+	if since != "" {
+		sinceTime, err := utils.ParseFlexibleTime(since, receiver.legacyZone)
+		if err != nil {
+			utils.MsgError("        [ReceiveAircraft]" + handler + " Invalid Since!")
+			c.JSON(400, gin.H{"msg": "Invalid Since"})
+			return
+		}
+		sinceScore = float64(sinceTime.UnixMilli())
+	}
+	rangeCount := count
+	if severity != "" {
+		rangeCount = limit
+	}
+	events, err := receiver.EventRedisService.RangeHistory(key, sinceScore, rangeCount)
+	if err != nil {
+		utils.MsgError("        [ReceiveAircraft]" + handler + " failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read event history"})
+		return
+	}
+	if severity != "" {
+		events = filterSeverity(events, severity, count)
+	}
+	utils.MsgSuccess("        [ReceiveAircraft]" + handler + " Successfully requestData!")
+	c.JSON(200, gin.H{"msg": "Successfully requestData!", "data": events})
+}
+
+// filterSeverity 保留严重级别为 severity 的前 count 条事件
+func filterSeverity(events []interface{}, severity string, count int) []interface{} {
+	filtered := make([]interface{}, 0, count)
+	for _, event := range events {
+		if len(filtered) == count {
+			break
+		}
+		if record, ok := event.(map[string]interface{}); ok && record["Severity"] == severity {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// RequestLinkQuality 返回飞行器按上传序号统计的收包、丢包与重复情况及时钟偏差
+func (receiver *RequestAircraft) RequestLinkQuality(c *gin.Context) {
+	var aircraftReq data_flow_model.RecAircraftStatusRequest
+
+	// 绑定 JSON 数据到结构体
+	if err := c.ShouldBindJSON(&aircraftReq); err != nil {
+		utils.MsgError("        [ReceiveAircraft]RequestLinkQuality Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	fields, err := receiver.DedupRedisService.GetHash(data_flow_model.LinkStatsKey(aircraftReq.AircraftID))
+	if err != nil {
+		utils.MsgError("        [ReceiveAircraft]RequestLinkQuality failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read link quality"})
+		return
+	}
+	if len(fields) == 0 {
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	}
+	utils.MsgSuccess("        [ReceiveAircraft]RequestLinkQuality successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully requestData!", "data": data_flow_model.NewLinkQuality(aircraftReq.AircraftID, fields)})
+}
@@ -2,96 +2,196 @@ package data_controller
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
-	"uam-power-backend/models/config_models/db_config_model"
+	"strconv"
+	"time"
 	"uam-power-backend/models/controller_models/data_flow_model"
-	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/validation_service"
 	"uam-power-backend/utils"
 )
 
 type UploadAircraftController struct {
-	kafkaStatusService *dbservice.KafkaProducer
-	kafkaEventService  *dbservice.KafkaProducer
+	kafkaStatusService bus_service.Producer
+	kafkaEventService  bus_service.Producer
+	// eventCatalog 校验上传事件的类型与 Payload，并填写严重级别
+	eventCatalog *event_catalog_service.Catalog
+	// validator 上传状态的合理性校验，为 nil 时不校验
+	validator *validation_service.Validator
+	// legacyZone 不带时区的旧格式时间所在的时区，为 nil 时按 UTC
+	legacyZone *time.Location
 }
 
-func NewUploadAircraftController(kafkaConfig *db_config_model.KafkaConfigModel) *UploadAircraftController {
-	kafkaStatusService := dbservice.NewKafkaProducer(kafkaConfig.Addr, kafkaConfig.AircraftDataTopic)
-	kafkaEventService := dbservice.NewKafkaProducer(kafkaConfig.Addr, kafkaConfig.AircraftEventTopic)
+// NewUploadAircraftController 使用 app 中共享的状态与事件生产者、事件目录及校验器创建控制器，生产者随 app 关闭；
+// 旧格式时间按 PipelineCfg.LegacyTimeZone 解释（已在加载配置时校验）
+func NewUploadAircraftController(app *app_service.Container) *UploadAircraftController {
+	legacyZone, err := time.LoadLocation(app.Config.PipelineCfg.LegacyTimeZone)
+	if err != nil {
+		utils.MsgError("        [UploadAircraftController]Invalid LegacyTimeZone, using UTC > " + err.Error())
+	}
 	utils.MsgSuccess("        [UploadAircraftController]init successfully!")
+	return NewUploadAircraftControllerFromProducers(
+		app.StatusProducer, app.EventProducer, app.EventCatalog, app.Validator, legacyZone)
+}
+
+// NewUploadAircraftControllerFromProducers 由状态与事件生产者、事件目录、校验器及旧格式时间的时区创建控制器，
+// 测试中可传入 memory 总线的生产者与基于内存存储的目录和校验器；validator 为 nil 时不做合理性校验
+func NewUploadAircraftControllerFromProducers(
+	status, event bus_service.Producer, catalog *event_catalog_service.Catalog, validator *validation_service.Validator,
+	legacyZone *time.Location,
+) *UploadAircraftController {
 	return &UploadAircraftController{
-		kafkaStatusService: kafkaStatusService,
-		kafkaEventService:  kafkaEventService,
+		kafkaStatusService: status,
+		kafkaEventService:  event,
+		eventCatalog:       catalog,
+		validator:          validator,
+		legacyZone:         legacyZone,
 	}
 }
 
 func (controller *UploadAircraftController) UploadData(c *gin.Context) {
+	receiveTime := time.Now()
 	var aircraftData data_flow_model.AircraftStatus
 	if err := c.ShouldBindJSON(&aircraftData); err != nil {
 		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid JSON data rec >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "invalid_json").Inc()
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		return
 	}
-	if !utils.IsValidSqlTimeFormat(aircraftData.TimeString) {
-		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid time format")
+	if err := controller.normalizeTime(&aircraftData.TimeString, &aircraftData.ReceiveTime, receiveTime); err != nil {
+		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid time format >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "invalid_time").Inc()
 		c.JSON(403, gin.H{"msg": "Invalid time format"})
 		return
 	}
+	if aircraftData.Seq != nil && *aircraftData.Seq < 0 {
+		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid Seq")
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "invalid_seq").Inc()
+		c.JSON(400, gin.H{"msg": "Invalid Seq"})
+		return
+	}
+	if controller.validator != nil && !controller.validate(c, &aircraftData) {
+		return
+	}
 	jStr, err := json.Marshal(aircraftData)
 	if err != nil {
 		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid JSON data tran_str >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "marshal_error").Inc()
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		return
 	}
-	err = controller.kafkaStatusService.SendMessage(string(jStr))
+	err = controller.kafkaStatusService.SendKeyedMessage(c.Request.Context(), strconv.Itoa(aircraftData.AircraftID), string(jStr))
 	if err != nil {
-		utils.MsgInfo(err.Error())
-		utils.MsgError("        [UploadAircraftController]UploadData error-Invalid JSON data >" + err.Error())
-		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		utils.MsgError("        [UploadAircraftController]UploadData error-Failed to send to Kafka >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "kafka_error").Inc()
+		c.JSON(503, gin.H{"msg": "Failed to send to Kafka"})
 		return
 	}
 	utils.MsgSuccess("        [UploadAircraftController]UploadData successfully!")
-	c.JSON(200, gin.H{"msg": "Successfully send to Kafka!"})
+	metrics_service.UploadTotal.WithLabelValues("status", "accepted", "").Inc()
+	respondSent(c, controller.kafkaStatusService)
 }
 
 func (controller *UploadAircraftController) UploadEvent(c *gin.Context) {
+	receiveTime := time.Now()
 	var aircraftEvent data_flow_model.AircraftEvent
 
 	// 绑定 JSON 数据到结构体
 	if err := c.ShouldBindJSON(&aircraftEvent); err != nil {
 		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid JSON data >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("event", "rejected", "invalid_json").Inc()
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		return
 	}
-	if !utils.IsValidSqlTimeFormat(aircraftEvent.TimeString) {
-		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid time format")
+	if err := controller.normalizeTime(&aircraftEvent.TimeString, &aircraftEvent.ReceiveTime, receiveTime); err != nil {
+		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid time format >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("event", "rejected", "invalid_time").Inc()
 		c.JSON(403, gin.H{"msg": "Invalid time format"})
 		return
 	}
+	if aircraftEvent.Seq != nil && *aircraftEvent.Seq < 0 {
+		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid Seq")
+		metrics_service.UploadTotal.WithLabelValues("event", "rejected", "invalid_seq").Inc()
+		c.JSON(400, gin.H{"msg": "Invalid Seq"})
+		return
+	}
+	if err := controller.eventCatalog.Validate(c.Request.Context(), &aircraftEvent); err != nil {
+		utils.MsgError("        [UploadAircraftController]UploadEvent error-Rejected by event catalog >" + err.Error())
+		switch {
+		case errors.Is(err, event_catalog_service.ErrUnknownEventType):
+			metrics_service.UploadTotal.WithLabelValues("event", "rejected", "unknown_event").Inc()
+			c.JSON(400, gin.H{"msg": "Unknown event type", "error": err.Error()})
+		case errors.Is(err, event_catalog_service.ErrInvalidPayload):
+			metrics_service.UploadTotal.WithLabelValues("event", "rejected", "invalid_payload").Inc()
+			c.JSON(400, gin.H{"msg": "Invalid event payload", "error": err.Error()})
+		default:
+			metrics_service.UploadTotal.WithLabelValues("event", "rejected", "catalog_error").Inc()
+			c.JSON(503, gin.H{"msg": "Failed to read event catalog"})
+		}
+		return
+	}
 	jStr, err := json.Marshal(aircraftEvent)
 	if err != nil {
 		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid JSON data >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("event", "rejected", "marshal_error").Inc()
 		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
 		return
 	}
-	err = controller.kafkaEventService.SendMessage(string(jStr))
+	err = controller.kafkaEventService.SendKeyedMessage(c.Request.Context(), strconv.Itoa(aircraftEvent.AircraftID), string(jStr))
 	if err != nil {
-		utils.MsgError("        [UploadAircraftController]UploadEvent error-Invalid JSON data >" + err.Error())
-		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		utils.MsgError("        [UploadAircraftController]UploadEvent error-Failed to send to Kafka >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("event", "rejected", "kafka_error").Inc()
+		c.JSON(503, gin.H{"msg": "Failed to send to Kafka"})
 		return
 	}
 	utils.MsgSuccess("        [UploadAircraftController]UploadEvent successfully!")
-	c.JSON(200, gin.H{"msg": "Successfully send to Kafka!"})
+	metrics_service.UploadTotal.WithLabelValues("event", "accepted", "").Inc()
+	respondSent(c, controller.kafkaEventService)
 }
 
-func (controller *UploadAircraftController) Close() {
-	// 返回响应
-	err := controller.kafkaEventService.Close()
+// normalizeTime 将上传的时间（RFC3339、毫秒时间戳或旧格式）改写为 UTC 的 utils.SqlTimeLayout 格式，
+// 并以同一格式记录服务端接收时间
+func (controller *UploadAircraftController) normalizeTime(timeString, receiveTimeString *string, receiveTime time.Time) error {
+	parsed, err := utils.ParseFlexibleTime(*timeString, controller.legacyZone)
 	if err != nil {
-		return
+		return err
+	}
+	*timeString, *receiveTimeString = utils.FormatSqlTime(parsed), utils.FormatSqlTime(receiveTime)
+	return nil
+}
+
+// validate 对状态做合理性校验，未通过时写入隔离区并返回 422；读写上一个点失败时只记录日志并放行。
+// 返回 false 时已写入响应
+func (controller *UploadAircraftController) validate(c *gin.Context, aircraftData *data_flow_model.AircraftStatus) bool {
+	reasons, err := controller.validator.Validate(aircraftData)
+	if err != nil {
+		utils.MsgError("        [UploadAircraftController]UploadData error-Failed to check previous point >" + err.Error())
+	}
+	if len(reasons) == 0 {
+		return true
 	}
-	err = controller.kafkaStatusService.Close()
+	point, err := controller.validator.Quarantine(c.Request.Context(), aircraftData, reasons)
 	if err != nil {
+		utils.MsgError("        [UploadAircraftController]UploadData error-Failed to quarantine >" + err.Error())
+		metrics_service.UploadTotal.WithLabelValues("status", "rejected", "quarantine_error").Inc()
+		c.JSON(503, gin.H{"msg": "Failed to quarantine implausible data"})
+		return false
+	}
+	utils.MsgError("        [UploadAircraftController]UploadData quarantined-" + reasons[0].Detail)
+	metrics_service.UploadTotal.WithLabelValues("status", "rejected", "quarantined").Inc()
+	c.JSON(422, gin.H{"msg": "Rejected by plausibility checks", "QuarantineID": point.QuarantineID, "reasons": reasons})
+	return false
+}
+
+// respondSent 同步投递时 broker 已确认写入，返回 200；异步投递时消息仅进入发送批次，返回 202
+func respondSent(c *gin.Context, producer bus_service.Producer) {
+	if producer.Sync() {
+		c.JSON(200, gin.H{"msg": "Successfully send to Kafka!"})
 		return
 	}
-	utils.MsgSuccess("        [UploadAircraftController]Close successfully!")
+	c.JSON(202, gin.H{"msg": "Accepted, sending to Kafka asynchronously"})
 }
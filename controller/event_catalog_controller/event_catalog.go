@@ -0,0 +1,86 @@
+package event_catalog_controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+type EventCatalogController struct {
+	Catalog *event_catalog_service.Catalog
+}
+
+// NewEventCatalogController 使用 app 中共享的事件目录创建控制器，修改后上传校验立即生效
+func NewEventCatalogController(app *app_service.Container) *EventCatalogController {
+	utils.MsgSuccess("        [EventCatalogController]init successfully!")
+	return NewEventCatalogControllerFromCatalog(app.EventCatalog)
+}
+
+// NewEventCatalogControllerFromCatalog 由事件目录创建控制器，测试中可传入基于内存存储的目录
+func NewEventCatalogControllerFromCatalog(catalog *event_catalog_service.Catalog) *EventCatalogController {
+	return &EventCatalogController{Catalog: catalog}
+}
+
+// ListEventTypes 返回全部事件类型
+func (e *EventCatalogController) ListEventTypes(c *gin.Context) {
+	eventTypes, err := e.Catalog.List(c.Request.Context())
+	if err != nil {
+		utils.MsgError("        [EventCatalogController]ListEventTypes failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read event catalog"})
+		return
+	}
+	utils.MsgSuccess("        [EventCatalogController]ListEventTypes successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ListEventTypes!", "data": eventTypes})
+}
+
+// UpsertEventType 新增事件类型，Code 已存在时覆盖其严重级别、描述与 PayloadSchema
+func (e *EventCatalogController) UpsertEventType(c *gin.Context) {
+	var eventType event_catalog_model.EventType
+	if err := c.ShouldBindJSON(&eventType); err != nil {
+		utils.MsgError("        [EventCatalogController]UpsertEventType Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	err := e.Catalog.Upsert(c.Request.Context(), &eventType)
+	if errors.Is(err, event_catalog_service.ErrInvalidEventType) {
+		utils.MsgError("        [EventCatalogController]UpsertEventType invalid event type > " + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid event type", "error": err.Error()})
+		return
+	} else if err != nil {
+		utils.MsgError("        [EventCatalogController]UpsertEventType failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to save event type"})
+		return
+	}
+	utils.MsgSuccess("        [EventCatalogController]UpsertEventType successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully UpsertEventType!", "data": eventType})
+}
+
+// DeleteEventType 删除事件类型；服务自身发出的链路事件类型不能删除
+func (e *EventCatalogController) DeleteEventType(c *gin.Context) {
+	var request event_catalog_model.DeleteEventTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [EventCatalogController]DeleteEventType Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	err := e.Catalog.Delete(c.Request.Context(), request.Code)
+	switch {
+	case errors.Is(err, event_catalog_service.ErrSystemEventType):
+		utils.MsgError("        [EventCatalogController]DeleteEventType system event type!")
+		c.JSON(403, gin.H{"msg": "System event type cannot be deleted"})
+		return
+	case errors.Is(err, repository_service.ErrNotFound):
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	case err != nil:
+		utils.MsgError("        [EventCatalogController]DeleteEventType failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to delete event type"})
+		return
+	}
+	utils.MsgSuccess("        [EventCatalogController]DeleteEventType successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully DeleteEventType!"})
+}
@@ -0,0 +1,123 @@
+package export_controller
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/export_model"
+	"uam-power-backend/service/export_service"
+	"uam-power-backend/utils"
+)
+
+func (e *TrackExportController) ExportScene(c *gin.Context) {
+	var sceneReq export_model.SceneExportRequest
+	if err := c.ShouldBindJSON(&sceneReq); err != nil {
+		utils.MsgError("        [TrackExportController]ExportScene Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	start, startErr := utils.ParseFlexibleTime(sceneReq.StartTime, e.legacyZone)
+	end, endErr := utils.ParseFlexibleTime(sceneReq.EndTime, e.legacyZone)
+	if startErr != nil || endErr != nil || !end.After(start) {
+		utils.MsgError("        [TrackExportController]ExportScene Invalid time window")
+		c.JSON(403, gin.H{"msg": "Invalid time window"})
+		return
+	}
+	tasks, err := e.loadOverlappingTasks(start, end, sceneReq.AircraftID, sceneReq.LaneID)
+	if err != nil {
+		utils.MsgError("        [TrackExportController]ExportScene query tasks failed! >" + err.Error())
+		c.JSON(403, gin.H{"msg": "Query tasks failed!"})
+		return
+	}
+
+	tracks := make([]export_model.SceneTrack, 0, len(tasks))
+	for _, task := range tasks {
+		if !tableNamePattern.MatchString(task.TrackTable) || !tableNamePattern.MatchString(task.EventTable) {
+			utils.MsgError(fmt.Sprintf("        [TrackExportController]ExportScene skip Task %d with invalid tables", task.TaskID))
+			continue
+		}
+		track, err := e.loadSceneTrack(task, start, end)
+		if err != nil {
+			utils.MsgError("        [TrackExportController]ExportScene query track failed! >" + err.Error())
+			c.JSON(403, gin.H{"msg": "Query track failed!"})
+			return
+		}
+		tracks = append(tracks, track)
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", `attachment; filename="scene.czml"`)
+	c.Status(200)
+	if err := export_service.WriteCZML(c.Writer, start, end, tracks); err != nil {
+		utils.MsgError("        [TrackExportController]ExportScene write failed! >" + err.Error())
+		return
+	}
+	utils.MsgSuccess(fmt.Sprintf("        [TrackExportController]Successfully ExportScene with %d tasks!", len(tracks)))
+}
+
+// loadOverlappingTasks 查询与时间窗有交集的任务，AircraftID、LaneID 为 0 时不过滤
+func (e *TrackExportController) loadOverlappingTasks(
+	start, end time.Time, aircraftID, laneID int,
+) ([]aircraft_task_model.MysqlAircraftTask, error) {
+	query := "SELECT TaskID, AircraftID, LaneID, CreateTime, EndTime, TrackTable, EventTable, TimeStr " +
+		"FROM flight_task_table WHERE CreateTime <= ? AND (EndTime IS NULL OR EndTime >= ?)"
+	args := []interface{}{end, start}
+	if aircraftID != 0 {
+		query += " AND AircraftID = ?"
+		args = append(args, aircraftID)
+	}
+	if laneID != 0 {
+		query += " AND LaneID = ?"
+		args = append(args, laneID)
+	}
+	query += " ORDER BY TaskID;"
+
+	var tasks []aircraft_task_model.MysqlAircraftTask
+	err := e.MysqlService.QueryEach(query, func(rows *sql.Rows) error {
+		var task aircraft_task_model.MysqlAircraftTask
+		var endTime sql.NullTime
+		if err := rows.Scan(&task.TaskID, &task.AircraftID, &task.LaneID, &task.CreateTime, &endTime,
+			&task.TrackTable, &task.EventTable, &task.TimeStr); err != nil {
+			return err
+		}
+		if endTime.Valid {
+			task.EndTime = &endTime.Time
+		}
+		tasks = append(tasks, task)
+		return nil
+	}, args...)
+	return tasks, err
+}
+
+// loadSceneTrack 读取任务在时间窗内的轨迹点与事件
+func (e *TrackExportController) loadSceneTrack(
+	task aircraft_task_model.MysqlAircraftTask, start, end time.Time,
+) (export_model.SceneTrack, error) {
+	track := export_model.SceneTrack{Task: task}
+	err := e.FlightMysqlService.QueryEach(
+		fmt.Sprintf("SELECT Longitude, Latitude, Altitude, Yaw, DataTime FROM %s WHERE DataTime BETWEEN ? AND ? ORDER BY DataTime;", task.TrackTable),
+		func(rows *sql.Rows) error {
+			point, err := scanTrackPoint(rows)
+			if err != nil {
+				return err
+			}
+			track.Points = append(track.Points, point)
+			return nil
+		}, start, end)
+	if err != nil {
+		return track, err
+	}
+	err = e.EventMysqlService.QueryEach(
+		fmt.Sprintf("SELECT DataTime, Event FROM %s WHERE DataTime BETWEEN ? AND ? ORDER BY DataTime;", task.EventTable),
+		func(rows *sql.Rows) error {
+			var event export_model.TrackEvent
+			if err := rows.Scan(&event.DataTime, &event.Event); err != nil {
+				return err
+			}
+			track.Events = append(track.Events, event)
+			return nil
+		}, start, end)
+	return track, err
+}
@@ -0,0 +1,166 @@
+package export_controller
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"regexp"
+	"strings"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/export_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/export_service"
+	"uam-power-backend/utils"
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type TrackExportController struct {
+	MysqlService       *dbservice.MySQLService
+	FlightMysqlService *dbservice.MySQLService
+	EventMysqlService  *dbservice.MySQLService
+	// legacyZone 请求中不带时区的旧格式时间所在的时区，与上传接口相同
+	legacyZone *time.Location
+}
+
+// NewTrackExportController 使用 app 中共享的 MySQL 连接池创建控制器，旧格式时间按 PipelineCfg.LegacyTimeZone 解释
+func NewTrackExportController(app *app_service.Container) *TrackExportController {
+	legacyZone, _ := time.LoadLocation(app.Config.PipelineCfg.LegacyTimeZone)
+	utils.MsgSuccess("        [TrackExportController]Successfully init!")
+	return &TrackExportController{
+		MysqlService: app.SystemDB, FlightMysqlService: app.FlightDB, EventMysqlService: app.EventDB,
+		legacyZone: legacyZone,
+	}
+}
+
+func (e *TrackExportController) ExportTrack(c *gin.Context) {
+	var exportReq export_model.TrackExportRequest
+	if err := c.ShouldBindJSON(&exportReq); err != nil {
+		utils.MsgError("        [TrackExportController]ExportTrack Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	format := strings.ToLower(exportReq.Format)
+	if format == "" {
+		format = export_service.FormatGeoJSON
+	}
+	if !export_service.IsValidFormat(format) {
+		utils.MsgError("        [TrackExportController]ExportTrack unsupported format " + exportReq.Format)
+		c.JSON(400, gin.H{"msg": "Unsupported format"})
+		return
+	}
+	if exportReq.AltitudeMode != "" && !export_service.IsValidAltitudeMode(exportReq.AltitudeMode) {
+		utils.MsgError("        [TrackExportController]ExportTrack invalid altitude mode " + exportReq.AltitudeMode)
+		c.JSON(400, gin.H{"msg": "Invalid AltitudeMode"})
+		return
+	}
+	task, err := e.loadTask(exportReq.TaskID)
+	if err != nil {
+		utils.MsgError("        [TrackExportController]ExportTrack no such Task!")
+		c.JSON(404, gin.H{"msg": "No such Task!"})
+		return
+	}
+	if !tableNamePattern.MatchString(task.TrackTable) || !tableNamePattern.MatchString(task.EventTable) {
+		utils.MsgError("        [TrackExportController]ExportTrack invalid task tables!")
+		c.JSON(403, gin.H{"msg": "Invalid task tables!"})
+		return
+	}
+	events, err := e.loadEvents(task)
+	if err != nil {
+		utils.MsgError("        [TrackExportController]ExportTrack query events failed! >" + err.Error())
+		c.JSON(403, gin.H{"msg": "Query events failed!"})
+		return
+	}
+	points, release, err := e.trackSource(c.Request.Context(), task.TrackTable)
+	if err != nil {
+		utils.MsgError("        [TrackExportController]ExportTrack query track failed! >" + err.Error())
+		c.JSON(403, gin.H{"msg": "Query track failed!"})
+		return
+	}
+	defer release()
+
+	c.Header("Content-Type", export_service.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="task_%d.%s"`, task.TaskID, format))
+	c.Status(200)
+	err = export_service.WriteTrack(c.Writer, format, task, points, events, exportReq.AltitudeMode)
+	if err != nil {
+		utils.MsgError("        [TrackExportController]ExportTrack stream failed! >" + err.Error())
+		return
+	}
+	utils.MsgSuccess(fmt.Sprintf("        [TrackExportController]Successfully ExportTrack %d as %s!", task.TaskID, format))
+}
+
+func (e *TrackExportController) loadTask(taskID int) (*aircraft_task_model.MysqlAircraftTask, error) {
+	row, err := e.MysqlService.QueryRow("SELECT * FROM flight_task_table WHERE TaskID = ?;", taskID)
+	if err != nil {
+		return nil, err
+	}
+	jsonData, _ := json.Marshal(row)
+	var task aircraft_task_model.MysqlAircraftTask
+	if err := json.Unmarshal(jsonData, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// trackSource 返回按时间顺序流式读取轨迹表的数据源，以及用完后释放它的函数。各次遍历在同一个只读的
+// REPEATABLE READ 事务中执行，读取同一快照，进行中的任务在遍历之间写入的点不会使各次遍历的结果不一致
+func (e *TrackExportController) trackSource(
+	ctx context.Context, table string,
+) (export_service.PointSource, func(), error) {
+	tx, err := e.FlightMysqlService.DB().BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	source := func(handle func(point export_model.TrackPoint) error) error {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+			"SELECT Longitude, Latitude, Altitude, Yaw, DataTime FROM %s WHERE DataTime IS NOT NULL ORDER BY DataTime;", table))
+		if err != nil {
+			return err
+		}
+		defer func(rows *sql.Rows) {
+			_ = rows.Close()
+		}(rows)
+		for rows.Next() {
+			point, err := scanTrackPoint(rows)
+			if err != nil {
+				return err
+			}
+			if err = handle(point); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+	return source, func() { _ = tx.Rollback() }, nil
+}
+
+// loadEvents 按时间顺序读取任务全部事件，事件位置在写出轨迹时定位
+func (e *TrackExportController) loadEvents(task *aircraft_task_model.MysqlAircraftTask) ([]export_model.TrackEvent, error) {
+	var events []export_model.TrackEvent
+	err := e.EventMysqlService.QueryEach(
+		fmt.Sprintf("SELECT DataTime, Event FROM %s WHERE DataTime IS NOT NULL ORDER BY DataTime;", task.EventTable),
+		func(rows *sql.Rows) error {
+			var event export_model.TrackEvent
+			if err := rows.Scan(&event.DataTime, &event.Event); err != nil {
+				return err
+			}
+			events = append(events, event)
+			return nil
+		})
+	return events, err
+}
+
+func scanTrackPoint(rows *sql.Rows) (export_model.TrackPoint, error) {
+	var point export_model.TrackPoint
+	var lon, lat, alt, yaw sql.NullFloat64
+	if err := rows.Scan(&lon, &lat, &alt, &yaw, &point.DataTime); err != nil {
+		return point, err
+	}
+	point.Longitude, point.Latitude, point.Altitude, point.Yaw = lon.Float64, lat.Float64, alt.Float64, yaw.Float64
+	return point, nil
+}
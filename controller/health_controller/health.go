@@ -0,0 +1,54 @@
+package health_controller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/utils"
+)
+
+// checkTimeout 单个依赖检查的超时时间
+const checkTimeout = 2 * time.Second
+
+type HealthController struct {
+	Timeout time.Duration
+}
+
+// NewHealthController 创建健康检查控制器，使用 kafka 总线时登记 Kafka broker 的就绪检查；
+// MySQL/Redis 连接（含 Redis Streams 总线）的检查由 app_service.Container 登记，转发协程的检查由转发服务登记
+func NewHealthController(BusCfg *db_config_model.BusConfigModel, KafkaCfg *db_config_model.KafkaConfigModel) *HealthController {
+	if bus_service.Backend(BusCfg) == "kafka" {
+		health_service.RegisterReadiness("kafka:brokers", func(ctx context.Context) error {
+			return dbservice.PingKafkaBrokers(ctx, KafkaCfg)
+		})
+	}
+	utils.MsgSuccess("        [HealthController]Successfully init!")
+	return &HealthController{Timeout: checkTimeout}
+}
+
+// Live 存活检查：转发协程仍在循环即返回 200，否则返回 503
+func (h *HealthController) Live(c *gin.Context) {
+	h.respond(c, health_service.CheckLiveness(c.Request.Context(), h.Timeout))
+}
+
+// Ready 就绪检查：所有 MySQL/Redis 连接、Kafka broker 与转发协程均可用时返回 200，否则返回 503
+func (h *HealthController) Ready(c *gin.Context) {
+	h.respond(c, health_service.CheckReadiness(c.Request.Context(), h.Timeout))
+}
+
+func (h *HealthController) respond(c *gin.Context, report health_service.Report) {
+	if report.Status != health_service.StatusUp {
+		for name, component := range report.Components {
+			if component.Status != health_service.StatusUp {
+				utils.MsgWarn("        [HealthController]" + name + " is down > " + component.Error)
+			}
+		}
+		c.JSON(503, report)
+		return
+	}
+	c.JSON(200, report)
+}
@@ -0,0 +1,135 @@
+package quarantine_controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// defaultListCount/maxListCount 隔离记录列表默认与最多返回的条数
+const (
+	defaultListCount = 100
+	maxListCount     = 1000
+)
+
+type QuarantineController struct {
+	Quarantines repository_service.QuarantineRepository
+	// kafkaStatusService 放行的点重新投递到状态 topic，与上传接口相同
+	kafkaStatusService bus_service.Producer
+}
+
+// NewQuarantineController 使用 app 中共享的系统库连接池与状态生产者创建控制器
+func NewQuarantineController(app *app_service.Container) *QuarantineController {
+	utils.MsgSuccess("        [QuarantineController]init successfully!")
+	return NewQuarantineControllerFromStores(repository_service.NewMySQLQuarantineRepository(app.SystemDB), app.StatusProducer)
+}
+
+// NewQuarantineControllerFromStores 由隔离区存储与状态生产者创建控制器，测试中可传入内存实现与 memory 总线的生产者
+func NewQuarantineControllerFromStores(
+	quarantines repository_service.QuarantineRepository, status bus_service.Producer,
+) *QuarantineController {
+	return &QuarantineController{Quarantines: quarantines, kafkaStatusService: status}
+}
+
+// ListQuarantine 按状态、飞行器与原因筛选隔离记录，从新到旧返回
+func (q *QuarantineController) ListQuarantine(c *gin.Context) {
+	var request quarantine_model.ListQuarantineRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [QuarantineController]ListQuarantine Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	switch request.Status {
+	case "", quarantine_model.StatusPending, quarantine_model.StatusReleased, quarantine_model.StatusDiscarded:
+	default:
+		c.JSON(400, gin.H{"msg": "Invalid Status"})
+		return
+	}
+	if request.Count <= 0 {
+		request.Count = defaultListCount
+	} else if request.Count > maxListCount {
+		request.Count = maxListCount
+	}
+	points, err := q.Quarantines.ListQuarantine(c.Request.Context(), &request)
+	if err != nil {
+		utils.MsgError("        [QuarantineController]ListQuarantine failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read quarantine"})
+		return
+	}
+	if points == nil {
+		points = []quarantine_model.QuarantinedPoint{}
+	}
+	utils.MsgSuccess("        [QuarantineController]ListQuarantine successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ListQuarantine!", "data": points})
+}
+
+// ReviewQuarantine 审核 pending 的隔离记录：release 将点重新投递到状态 topic（不再做合理性校验），
+// discard 丢弃该点；记录已审核时返回 409，投递失败时恢复为 pending 并返回 503
+func (q *QuarantineController) ReviewQuarantine(c *gin.Context) {
+	var request quarantine_model.ReviewQuarantineRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [QuarantineController]ReviewQuarantine Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	var status string
+	switch request.Action {
+	case quarantine_model.ActionRelease:
+		status = quarantine_model.StatusReleased
+	case quarantine_model.ActionDiscard:
+		status = quarantine_model.StatusDiscarded
+	default:
+		c.JSON(400, gin.H{"msg": "Invalid Action"})
+		return
+	}
+	if request.Reviewer == "" {
+		c.JSON(400, gin.H{"msg": "Reviewer is required"})
+		return
+	}
+	ctx := c.Request.Context()
+	point, err := q.Quarantines.ReviewQuarantine(ctx, request.QuarantineID, quarantine_model.StatusPending,
+		&quarantine_model.Review{Status: status, Reviewer: request.Reviewer, Note: request.Note})
+	switch {
+	case errors.Is(err, repository_service.ErrNotFound):
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	case errors.Is(err, repository_service.ErrConflict):
+		utils.MsgError("        [QuarantineController]ReviewQuarantine conflict > " + err.Error())
+		c.JSON(409, gin.H{"msg": "Quarantined point has already been reviewed", "error": err.Error()})
+		return
+	case err != nil:
+		utils.MsgError("        [QuarantineController]ReviewQuarantine failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to update quarantine"})
+		return
+	}
+	if request.Action == quarantine_model.ActionRelease {
+		if err = q.release(c, point); err != nil {
+			utils.MsgError("        [QuarantineController]ReviewQuarantine error-Failed to send to Kafka >" + err.Error())
+			if _, revertErr := q.Quarantines.ReviewQuarantine(ctx, point.QuarantineID, quarantine_model.StatusReleased,
+				&quarantine_model.Review{Status: quarantine_model.StatusPending}); revertErr != nil {
+				utils.MsgError("        [QuarantineController]ReviewQuarantine failed to revert > " + revertErr.Error())
+			}
+			c.JSON(503, gin.H{"msg": "Failed to send to Kafka"})
+			return
+		}
+	}
+	metrics_service.QuarantineReviews.WithLabelValues(request.Action).Inc()
+	utils.MsgSuccess("        [QuarantineController]ReviewQuarantine successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ReviewQuarantine!", "data": point})
+}
+
+// release 将隔离的点按飞行器分区投递到状态 topic
+func (q *QuarantineController) release(c *gin.Context, point *quarantine_model.QuarantinedPoint) error {
+	jStr, err := json.Marshal(point.Point)
+	if err != nil {
+		return err
+	}
+	return q.kafkaStatusService.SendKeyedMessage(c.Request.Context(), strconv.Itoa(point.AircraftID), string(jStr))
+}
@@ -0,0 +1,72 @@
+package rate_limit_controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"time"
+	"uam-power-backend/models/controller_models/rate_limit_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/rate_limit_service"
+	"uam-power-backend/utils"
+)
+
+// defaultListCount/maxListCount 用量列表默认与最多返回的条数
+const (
+	defaultListCount = 100
+	maxListCount     = 1000
+)
+
+type RateLimitController struct {
+	Limiter *rate_limit_service.Limiter
+}
+
+// NewRateLimitController 使用 app 中共享的限流器创建控制器
+func NewRateLimitController(app *app_service.Container) *RateLimitController {
+	utils.MsgSuccess("        [RateLimitController]init successfully!")
+	return NewRateLimitControllerFromLimiter(app.RateLimiter)
+}
+
+// NewRateLimitControllerFromLimiter 由限流器创建控制器，limiter 为 nil 时用量接口返回 404
+func NewRateLimitControllerFromLimiter(limiter *rate_limit_service.Limiter) *RateLimitController {
+	return &RateLimitController{Limiter: limiter}
+}
+
+// Usage 返回某天各 key 被放行与拒绝的请求数，按请求数从多到少排列
+func (rl *RateLimitController) Usage(c *gin.Context) {
+	if rl.Limiter == nil {
+		c.JSON(404, gin.H{"msg": "Rate limiting is disabled"})
+		return
+	}
+	var request rate_limit_model.UsageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [RateLimitController]Usage Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	if _, err := time.Parse("2006-01-02", request.Date); request.Date != "" && err != nil {
+		c.JSON(400, gin.H{"msg": "Invalid Date, expected 2006-01-02"})
+		return
+	}
+	if request.Scope != "" && rl.Limiter.Rules(request.Scope) == nil {
+		c.JSON(400, gin.H{"msg": "Invalid Scope"})
+		return
+	}
+	switch request.Dimension {
+	case "", rate_limit_model.DimensionAircraft, rate_limit_model.DimensionCredential, rate_limit_model.DimensionIP:
+	default:
+		c.JSON(400, gin.H{"msg": "Invalid Dimension"})
+		return
+	}
+	if request.Count <= 0 {
+		request.Count = defaultListCount
+	} else if request.Count > maxListCount {
+		request.Count = maxListCount
+	}
+	usage, err := rl.Limiter.Usage(&request)
+	if err != nil {
+		utils.MsgError("        [RateLimitController]Usage failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read usage"})
+		return
+	}
+	utils.MsgSuccess("        [RateLimitController]Usage successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully Usage!", "data": usage})
+}
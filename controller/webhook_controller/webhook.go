@@ -0,0 +1,148 @@
+package webhook_controller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/url"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// minSecretLength 订阅秘钥的最短长度
+const minSecretLength = 16
+
+// defaultListCount/maxListCount 投递日志默认与最多返回的条数
+const (
+	defaultListCount = 100
+	maxListCount     = 1000
+)
+
+type WebhookController struct {
+	Webhooks repository_service.WebhookRepository
+}
+
+// NewWebhookController 使用 app 中共享的系统库连接池创建控制器
+func NewWebhookController(app *app_service.Container) *WebhookController {
+	utils.MsgSuccess("        [WebhookController]init successfully!")
+	return NewWebhookControllerFromStores(repository_service.NewMySQLWebhookRepository(app.SystemDB))
+}
+
+// NewWebhookControllerFromStores 由订阅存储创建控制器，测试中可传入内存实现
+func NewWebhookControllerFromStores(webhooks repository_service.WebhookRepository) *WebhookController {
+	return &WebhookController{Webhooks: webhooks}
+}
+
+// validateSubscription 检查订阅的 URL、秘钥与通知类型，返回第一个问题
+func validateSubscription(request *webhook_model.CreateSubscriptionRequest) string {
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if len(request.Secret) < minSecretLength {
+		return "Secret must be at least 16 characters"
+	}
+	for _, eventType := range request.EventTypes {
+		if !event_catalog_model.CodePattern.MatchString(eventType) {
+			return "Invalid EventTypes: " + eventType
+		}
+	}
+	return ""
+}
+
+// CreateSubscription 创建订阅，EventTypes 为空时接收全部通知；返回的订阅不含秘钥
+func (w *WebhookController) CreateSubscription(c *gin.Context) {
+	var request webhook_model.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [WebhookController]CreateSubscription Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	if problem := validateSubscription(&request); problem != "" {
+		c.JSON(400, gin.H{"msg": problem})
+		return
+	}
+	subscription := webhook_model.Subscription{
+		URL: request.URL, Secret: request.Secret, EventTypes: request.EventTypes, Description: request.Description,
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	if err := w.Webhooks.CreateSubscription(c.Request.Context(), &subscription); err != nil {
+		utils.MsgError("        [WebhookController]CreateSubscription failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to create subscription"})
+		return
+	}
+	utils.MsgSuccess("        [WebhookController]CreateSubscription successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully CreateSubscription!", "data": subscription})
+}
+
+// ListSubscriptions 返回全部订阅
+func (w *WebhookController) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := w.Webhooks.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		utils.MsgError("        [WebhookController]ListSubscriptions failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read subscriptions"})
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []webhook_model.Subscription{}
+	}
+	utils.MsgSuccess("        [WebhookController]ListSubscriptions successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ListSubscriptions!", "data": subscriptions})
+}
+
+// DeleteSubscription 删除订阅及其投递记录
+func (w *WebhookController) DeleteSubscription(c *gin.Context) {
+	var request webhook_model.SubscriptionIDRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [WebhookController]DeleteSubscription Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	err := w.Webhooks.DeleteSubscription(c.Request.Context(), request.SubscriptionID)
+	if errors.Is(err, repository_service.ErrNotFound) {
+		c.JSON(404, gin.H{"msg": "N.A.!"})
+		return
+	} else if err != nil {
+		utils.MsgError("        [WebhookController]DeleteSubscription failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to delete subscription"})
+		return
+	}
+	utils.MsgSuccess("        [WebhookController]DeleteSubscription successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully DeleteSubscription!"})
+}
+
+// ListDeliveries 按订阅、状态与通知类型筛选投递日志，从新到旧返回
+func (w *WebhookController) ListDeliveries(c *gin.Context) {
+	var request webhook_model.ListDeliveriesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.MsgError("        [WebhookController]ListDeliveries Invalid JSON data! >" + err.Error())
+		c.JSON(400, gin.H{"msg": "Invalid JSON data"})
+		return
+	}
+	switch request.Status {
+	case "", webhook_model.DeliveryPending, webhook_model.DeliveryDelivered, webhook_model.DeliveryFailed:
+	default:
+		c.JSON(400, gin.H{"msg": "Invalid Status"})
+		return
+	}
+	if request.Count <= 0 {
+		request.Count = defaultListCount
+	} else if request.Count > maxListCount {
+		request.Count = maxListCount
+	}
+	deliveries, err := w.Webhooks.ListDeliveries(c.Request.Context(), &request)
+	if err != nil {
+		utils.MsgError("        [WebhookController]ListDeliveries failed > " + err.Error())
+		c.JSON(500, gin.H{"msg": "Failed to read deliveries"})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook_model.Delivery{}
+	}
+	utils.MsgSuccess("        [WebhookController]ListDeliveries successfully!")
+	c.JSON(200, gin.H{"msg": "Successfully ListDeliveries!", "data": deliveries})
+}
@@ -1,36 +1,193 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"uam-power-backend/middleware"
+	"uam-power-backend/models/config_models/db_config_model"
 	"uam-power-backend/routes"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
 	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/migration_service"
+	"uam-power-backend/service/trace_service"
 	"uam-power-backend/utils"
 )
 
 func main() {
+	defaultPath := utils.DefaultConfigPath
+	if envPath, ok := os.LookupEnv(utils.ConfigPathEnv); ok && envPath != "" {
+		defaultPath = envPath
+	}
+	configPath := flag.String("config", defaultPath, "path of the yaml config file")
+	migrateCmd := flag.String("migrate", "", "run database migrations and exit: up / down / status")
+	migrateSteps := flag.Int("migrate-steps", 1, "number of migrations to roll back with -migrate down")
+	flag.Parse()
+
 	// 初始化日志
 	utils.InitLog()
 
-	cfg, loadCfgErr := utils.LoadDBConfig("config/db_config.yaml")
+	// run 返回后其中的 defer（写出日志缓冲、上报链路）均已执行，此时再以非 0 状态退出
+	if err := run(*configPath, *migrateCmd, *migrateSteps); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run 加载配置并运行服务直到收到退出信号，启动或运行失败时记录日志并返回 error；
+// migrateCmd 不为空时只执行迁移
+func run(configPath, migrateCmd string, migrateSteps int) error {
+	cfg, loadCfgErr := utils.LoadDBConfig(configPath)
 	if loadCfgErr != nil {
-		return
+		utils.MsgError("[main_server]Failed to load config > " + loadCfgErr.Error())
+		return loadCfgErr
+	}
+	if validateErr := utils.ValidateDBConfig(cfg); validateErr != nil {
+		utils.MsgError("[main_server]Invalid config > " + validateErr.Error())
+		return validateErr
+	}
+	if logErr := utils.InitLogWithConfig(&cfg.LogCfg); logErr != nil {
+		utils.MsgError("[main_server]Failed to init log > " + logErr.Error())
+		return logErr
+	}
+	defer utils.CloseLog()
+	shutdownTracing, traceErr := trace_service.InitTracing(&cfg.TraceCfg)
+	if traceErr != nil {
+		utils.MsgError("[main_server]Failed to init tracing > " + traceErr.Error())
+		return traceErr
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
+	utils.MsgSuccess("[main_server]load DB config successfully from " + configPath)
+	utils.MsgInfo("[main_server]effective config:\n" + utils.DumpDBConfig(cfg))
+	if migrateCmd != "" || cfg.MySqlCfg.AutoMigrate {
+		command := migrateCmd
+		if command == "" {
+			command = "up"
+		}
+		if err := runMigrations(&cfg.MySqlCfg, command, migrateSteps); err != nil {
+			utils.MsgError("[main_server]Migration failed > " + err.Error())
+			return err
+		}
+		if migrateCmd != "" {
+			return nil
+		}
+	}
+	if cfg.KafkaCfg.EnsureTopics && bus_service.Backend(&cfg.BusCfg) == "kafka" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := dbservice.EnsureKafkaTopics(ctx, &cfg.KafkaCfg)
+		cancel()
+		if err != nil {
+			utils.MsgError("[main_server]Failed to ensure kafka topics > " + err.Error())
+			return err
+		}
+	}
+	// 建立共享的 MySQL/Redis/总线连接，任一依赖不可用时直接退出
+	app, appErr := app_service.NewContainer(cfg)
+	if appErr != nil {
+		utils.MsgError("[main_server]Failed to connect > " + appErr.Error())
+		return appErr
 	}
-	utils.MsgSuccess("[main_server]load DB config successfully!")
 	// 创建一个新的Gin实例
-	r := gin.Default()
+	gin.SetMode(strings.ToLower(cfg.ServerCfg.Mode))
+	r := gin.New()
+	r.Use(gin.Recovery(), middleware.RequestID(), middleware.Tracing(), middleware.AccessLog(), middleware.Metrics())
 
 	// 配置路由
-	routes.SetupDataFlowRoutes(r, &cfg.KafkaCfg, &cfg.RedisCfg)
-	routes.SetupAircraftTaskRoutes(r, &cfg.RedisCfg, &cfg.MySqlCfg)
-	routes.SetupAircraftIdRoutes(r, &cfg.RedisCfg, &cfg.MySqlCfg)
+	routes.SetupDataFlowRoutes(r, app)
+	routes.SetupAircraftTaskRoutes(r, app)
+	routes.SetupAircraftIdRoutes(r, app)
+	routes.SetupExportRoutes(r, app)
+	routes.SetupEventCatalogRoutes(r, app)
+	routes.SetupAlertRoutes(r, app)
+	routes.SetupWebhookRoutes(r, app)
+	routes.SetupRateLimitRoutes(r, app)
+	routes.SetupQuarantineRoutes(r, app)
+	routes.SetupMetricsRoutes(r)
+	routes.SetupHealthRoutes(r, &cfg.BusCfg, &cfg.KafkaCfg)
 	utils.MsgSuccess("[main_server]init routes successfully!")
-	transferSer := data_transfer_service.NewKafkaToRedis(&cfg.KafkaCfg, &cfg.RedisCfg)
+	transferSer, err := data_transfer_service.NewKafkaToRedis(app)
+	if err != nil {
+		utils.MsgError("[main_server]Failed to init transfer service > " + err.Error())
+		_ = app.Close()
+		return err
+	}
+	transferSerMysql, err := data_transfer_service.NewKafkaToMysql(app)
+	if err != nil {
+		utils.MsgError("[main_server]Failed to init transfer service > " + err.Error())
+		_ = app.Close()
+		return err
+	}
+	transferSerAlert, err := data_transfer_service.NewKafkaToAlert(app)
+	if err != nil {
+		utils.MsgError("[main_server]Failed to init transfer service > " + err.Error())
+		_ = app.Close()
+		return err
+	}
+	transferSerWebhook, err := data_transfer_service.NewKafkaToWebhook(app)
+	if err != nil {
+		utils.MsgError("[main_server]Failed to init transfer service > " + err.Error())
+		_ = app.Close()
+		return err
+	}
 	transferSer.Start()
-	transferSerMysql := data_transfer_service.NewKafkaToMysql(&cfg.KafkaCfg, &cfg.MySqlCfg, &cfg.RedisCfg)
 	transferSerMysql.Start()
+	transferSerAlert.Start()
+	transferSerWebhook.Start()
+	app.Webhooks.Start()
 	utils.MsgSuccess("[main_server]init transfer service successfully!")
-	// 启动服务器
-	if err := r.Run(":26969"); err != nil {
-		utils.MsgError("[main_server]Failed to run the server: %v")
+
+	// 启动服务器，收到 SIGINT/SIGTERM 后依次停止接收请求、停止转发服务、关闭连接
+	server := &http.Server{Addr: fmt.Sprintf(":%d", cfg.ServerCfg.Port), Handler: r}
+	signalCtx, stopSignal := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignal()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	var runErr error
+	select {
+	case runErr = <-serveErr:
+		utils.MsgError("[main_server]Failed to run the server: " + runErr.Error())
+	case <-signalCtx.Done():
+		utils.MsgInfo("[main_server]shutting down...")
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ServerCfg.ShutdownTimeoutSec)*time.Second)
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		utils.MsgError("[main_server]Failed to shutdown the server: " + err.Error())
+	}
+	cancel()
+	transferSer.Stop()
+	transferSerMysql.Stop()
+	transferSerAlert.Stop()
+	transferSerWebhook.Stop()
+	app.Webhooks.Stop()
+	if err = app.Close(); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+	utils.MsgSuccess("[main_server]shutdown complete")
+	return runErr
+}
+
+func runMigrations(MySqlCfg *db_config_model.MySqlConfigModel, command string, steps int) error {
+	migrator, err := migration_service.NewMigrator(MySqlCfg)
+	if err != nil {
+		return err
 	}
+	defer func(migrator *migration_service.Migrator) {
+		_ = migrator.Close()
+	}(migrator)
+	return migrator.RunCommand(command, steps)
 }
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"uam-power-backend/models/controller_models/rate_limit_model"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/rate_limit_service"
+	"uam-power-backend/utils"
+)
+
+// maxPeekBody 限流读取请求体的上限，超出时返回 413
+const maxPeekBody = 64 << 10
+
+// RateLimit 按飞行器（请求体中的 AircraftID）、API 凭证与客户端 IP 对 scope 下的请求限流，
+// 超出限额时返回 429 与 Retry-After（秒），请求体超过 maxPeekBody 时返回 413。limiter 为 nil（未开启限流）时不做任何事
+func RateLimit(limiter *rate_limit_service.Limiter, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		aircraftID, err := peekAircraftID(c)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(413, gin.H{"msg": "Request body too large"})
+			return
+		}
+		subjects := []rate_limit_service.Subject{
+			{Dimension: rate_limit_model.DimensionIP, Key: c.ClientIP()},
+			{Dimension: rate_limit_model.DimensionAircraft, Key: aircraftID},
+		}
+		if credential := c.GetHeader(limiter.Config.CredentialHeader); credential != "" {
+			subjects = append(subjects, rate_limit_service.Subject{
+				Dimension: rate_limit_model.DimensionCredential, Key: rate_limit_service.CredentialKey(credential),
+			})
+		}
+		decision, err := limiter.Allow(scope, subjects)
+		if err != nil {
+			utils.MsgError("        [RateLimit]Failed to check rate limit > " + err.Error())
+			if !limiter.Config.FailOpen {
+				c.AbortWithStatusJSON(503, gin.H{"msg": "Rate limiter unavailable"})
+				return
+			}
+			c.Next()
+			return
+		}
+		if !decision.Allowed {
+			metrics_service.RateLimitRejections.WithLabelValues(scope, decision.Dimension).Inc()
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(decision.RetryAfter.Seconds())))))
+			c.AbortWithStatusJSON(429, gin.H{"msg": "Too Many Requests", "limit": decision.Dimension})
+			return
+		}
+		c.Next()
+	}
+}
+
+// peekAircraftID 读取 JSON 请求体（至多 maxPeekBody 字节）中的 AircraftID 并放回请求体，没有或无法解析时返回空串；
+// 请求体超出上限时返回 *http.MaxBytesError
+func peekAircraftID(c *gin.Context) (string, error) {
+	if c.Request.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPeekBody))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	var request struct {
+		AircraftID *int `json:"AircraftID"`
+	}
+	if json.Unmarshal(body, &request) != nil || request.AircraftID == nil {
+		return "", nil
+	}
+	return strconv.Itoa(*request.AircraftID), nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"time"
+	"uam-power-backend/service/trace_service"
+	"uam-power-backend/utils"
+)
+
+// RequestIDKey 请求 ID 在 gin.Context 中的键
+const RequestIDKey = "RequestID"
+
+// RequestID 为每个请求分配请求 ID（沿用客户端传入的 X-Request-ID），
+// 写入响应头与 request context，供日志和 Kafka 消息头透传
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(utils.RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(utils.RequestIDHeader, requestID)
+		ctx := utils.ContextWithRequestID(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// AccessLog 以结构化日志记录每个请求的方法、路由、状态码与耗时
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		status := c.Writer.Status()
+		logger := utils.LoggerFromContext(c.Request.Context()).With(
+			"component", "http", "method", c.Request.Method, "route", route,
+			"status", status, "latency_ms", time.Since(start).Milliseconds(), "client_ip", c.ClientIP(),
+		)
+		if traceID := trace_service.TraceID(c.Request.Context()); traceID != "" {
+			logger = logger.With("trace_id", traceID)
+		}
+		switch {
+		case status >= 500:
+			logger.Error("request completed")
+		case status >= 400:
+			logger.Warn("request completed")
+		default:
+			logger.Info("request completed")
+		}
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"uam-power-backend/service/trace_service"
+)
+
+// Tracing 为每个请求开启 server span，沿用请求头中的 traceparent，
+// 并将 span 写入 request context 供下游（如 Kafka 生产者）继续传播
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := trace_service.StartSpan(ctx, c.Request.Method+" "+route, trace.SpanKindServer,
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("client.address", c.ClientIP()),
+		)
+		if requestID, ok := c.Get(RequestIDKey); ok {
+			span.SetAttributes(attribute.String("request.id", fmt.Sprint(requestID)))
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		var err error
+		if status >= 500 {
+			err = fmt.Errorf("HTTP %d", status)
+		}
+		trace_service.EndSpan(span, err)
+	}
+}
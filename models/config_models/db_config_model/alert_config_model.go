@@ -0,0 +1,16 @@
+package db_config_model
+
+type AlertConfigModel struct {
+	// Enable 消费事件 topic 生成告警并定期检查升级
+	Enable bool `yaml:"Enable"`
+	// EventTypes 生成告警的事件类型代码
+	EventTypes []string `yaml:"EventTypes"`
+	// MinSeverity 严重级别不低于该级别的事件同样生成告警，为空时只按 EventTypes
+	MinSeverity string `yaml:"MinSeverity"`
+	// EscalateAfterSec 告警每超过该时长仍未确认即升级一次（秒），为 0 时不升级
+	EscalateAfterSec int `yaml:"EscalateAfterSec"`
+	// MaxEscalationLevel 最多升级的次数
+	MaxEscalationLevel int `yaml:"MaxEscalationLevel"`
+	// CheckIntervalSec 升级检查周期（秒）
+	CheckIntervalSec int `yaml:"CheckIntervalSec"`
+}
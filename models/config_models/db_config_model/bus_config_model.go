@@ -0,0 +1,15 @@
+package db_config_model
+
+type BusConfigModel struct {
+	// Backend 消息总线：kafka / redis（Redis Streams）/ memory（进程内，仅用于测试与单进程部署）。
+	// topic 名称沿用 KafkaCfg.AircraftDataTopic/AircraftEventTopic
+	Backend string `yaml:"Backend"`
+	// StreamPrefix Redis Streams 的 key 前缀，连接沿用 RedisCfg
+	StreamPrefix string `yaml:"StreamPrefix"`
+	// StreamMaxLen 每个 stream 近似保留的最大消息数，0 表示不裁剪
+	StreamMaxLen int `yaml:"StreamMaxLen"`
+	// ClaimIdleMs 已读取但超过该时长未确认的消息会被同组消费者接管重投
+	ClaimIdleMs int `yaml:"ClaimIdleMs"`
+	// MemoryBuffer memory 总线每个消费组最多缓存的未读消息数
+	MemoryBuffer int `yaml:"MemoryBuffer"`
+}
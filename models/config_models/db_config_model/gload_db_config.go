@@ -1,7 +1,16 @@
 package db_config_model
 
 type DbConfigModel struct {
-	KafkaCfg KafkaConfigModel `yaml:"KafkaCfg"`
-	RedisCfg RedisConfigModel `yaml:"RedisCfg"`
-	MySqlCfg MySqlConfigModel `yaml:"MySqlCfg"`
+	ServerCfg     ServerConfigModel     `yaml:"ServerCfg"`
+	LogCfg        LogConfigModel        `yaml:"LogCfg"`
+	TraceCfg      TraceConfigModel      `yaml:"TraceCfg"`
+	BusCfg        BusConfigModel        `yaml:"BusCfg"`
+	KafkaCfg      KafkaConfigModel      `yaml:"KafkaCfg"`
+	RedisCfg      RedisConfigModel      `yaml:"RedisCfg"`
+	MySqlCfg      MySqlConfigModel      `yaml:"MySqlCfg"`
+	PipelineCfg   PipelineConfigModel   `yaml:"PipelineCfg"`
+	AlertCfg      AlertConfigModel      `yaml:"AlertCfg"`
+	WebhookCfg    WebhookConfigModel    `yaml:"WebhookCfg"`
+	RateLimitCfg  RateLimitConfigModel  `yaml:"RateLimitCfg"`
+	ValidationCfg ValidationConfigModel `yaml:"ValidationCfg"`
 }
@@ -1,7 +1,43 @@
 package db_config_model
 
 type KafkaConfigModel struct {
-	Addr               string `yaml:"Addr"`
-	AircraftDataTopic  string `yaml:"AircraftDataTopic"`
-	AircraftEventTopic string `yaml:"AircraftEventTopic"`
+	// Addr 兼容旧配置的单个 broker 地址，也可用逗号分隔多个；配置了 Brokers 时以 Brokers 为准
+	Addr               string   `yaml:"Addr"`
+	Brokers            []string `yaml:"Brokers"`
+	AircraftDataTopic  string   `yaml:"AircraftDataTopic"`
+	AircraftEventTopic string   `yaml:"AircraftEventTopic"`
+	// EnsureTopics 启动时检查 topic，不存在则按 Partitions/ReplicationFactor 创建
+	EnsureTopics      bool                 `yaml:"EnsureTopics"`
+	Partitions        int                  `yaml:"Partitions"`
+	ReplicationFactor int                  `yaml:"ReplicationFactor"`
+	SASL              KafkaSASLConfigModel `yaml:"SASL"`
+	TLS               TLSConfigModel       `yaml:"TLS"`
+	// 生产者参数
+	BatchSize    int    `yaml:"BatchSize"`
+	LingerMs     int    `yaml:"LingerMs"`
+	Compression  string `yaml:"Compression"`
+	RequiredAcks string `yaml:"RequiredAcks"`
+	// DataDelivery/EventDelivery 分别为状态与事件 topic 的投递方式
+	DataDelivery  KafkaDeliveryConfigModel `yaml:"DataDelivery"`
+	EventDelivery KafkaDeliveryConfigModel `yaml:"EventDelivery"`
+	// 消费者参数
+	FetchMinBytes  int `yaml:"FetchMinBytes"`
+	FetchMaxBytes  int `yaml:"FetchMaxBytes"`
+	FetchMaxWaitMs int `yaml:"FetchMaxWaitMs"`
+}
+
+type KafkaSASLConfigModel struct {
+	Mechanism string `yaml:"Mechanism"`
+	Username  string `yaml:"Username"`
+	Password  string `yaml:"Password" secret:"true"`
+}
+
+// KafkaDeliveryConfigModel 单个 topic 的投递方式
+type KafkaDeliveryConfigModel struct {
+	// Mode async：写入本地批次即返回，失败由回调计入指标与日志；sync：等待 broker 确认后返回
+	Mode string `yaml:"Mode"`
+	// RequiredAcks 为空时沿用 KafkaCfg.RequiredAcks
+	RequiredAcks string `yaml:"RequiredAcks"`
+	// TimeoutMs sync 模式下单次写入的超时，0 表示只受请求 context 约束
+	TimeoutMs int `yaml:"TimeoutMs"`
 }
@@ -0,0 +1,14 @@
+package db_config_model
+
+type LogConfigModel struct {
+	// Level 日志级别：debug / info / warn / error
+	Level string `yaml:"Level"`
+	// Format 输出格式：console / json
+	Format string `yaml:"Format"`
+	// ToFile 为 true 时同时写入 Dir 下的滚动日志文件
+	ToFile     bool   `yaml:"ToFile"`
+	Dir        string `yaml:"Dir"`
+	MaxSizeMB  int    `yaml:"MaxSizeMB"`
+	MaxBackups int    `yaml:"MaxBackups"`
+	MaxAgeDays int    `yaml:"MaxAgeDays"`
+}
@@ -2,10 +2,12 @@ package db_config_model
 
 type MySqlConfigModel struct {
 	Usr      string `yaml:"Usr"`
-	Psw      string `yaml:"Psw"`
+	Psw      string `yaml:"Psw" secret:"true"`
 	Host     string `yaml:"Host"`
 	DB       string `yaml:"DB"`
 	EventDB  string `yaml:"EventDB"`
 	FlightDB string `yaml:"FlightDB"`
 	Port     int    `yaml:"Port"`
+	// AutoMigrate 启动时自动执行未应用的数据库迁移
+	AutoMigrate bool `yaml:"AutoMigrate"`
 }
@@ -0,0 +1,25 @@
+package db_config_model
+
+type PipelineConfigModel struct {
+	// DedupWindowSec 去重窗口时长（秒），为 0 时关闭去重
+	DedupWindowSec int `yaml:"DedupWindowSec"`
+	// DedupMaxEntries 每架飞行器在窗口内最多保留的消息标识数
+	DedupMaxEntries int `yaml:"DedupMaxEntries"`
+	// EventHistorySize 每架飞行器在 Redis 中保留的最近事件数，为 0 时不保留历史
+	EventHistorySize int `yaml:"EventHistorySize"`
+	// EventGlobalHistorySize 全部飞行器共用的最近事件列表保留条数
+	EventGlobalHistorySize int `yaml:"EventGlobalHistorySize"`
+	// EventHistoryTTLSec 事件历史在无新事件后的过期时间（秒），为 0 时不过期
+	EventHistoryTTLSec int `yaml:"EventHistoryTTLSec"`
+	// StatusTTLSec 飞行器最新状态在无新数据后的过期时间（秒），为 0 时不过期
+	StatusTTLSec int `yaml:"StatusTTLSec"`
+	// LinkTimeoutSec 进行中任务的飞行器超过该时长未上报状态即判定失联（秒），为 0 时关闭链路监测
+	LinkTimeoutSec int `yaml:"LinkTimeoutSec"`
+	// LinkCheckIntervalSec 链路监测的检查周期（秒）
+	LinkCheckIntervalSec int `yaml:"LinkCheckIntervalSec"`
+	// EventCatalogRefreshSec 各实例缓存事件目录的时长（秒），为 0 时每次上传都读取 MySQL
+	EventCatalogRefreshSec int `yaml:"EventCatalogRefreshSec"`
+	// LegacyTimeZone 上传与查询时不带时区的旧格式时间（2006-01-02 15:04:05.000000）所在的时区，
+	// 为 IANA 名称（如 Asia/Shanghai），默认 UTC；归一化后统一按 UTC 存储
+	LegacyTimeZone string `yaml:"LegacyTimeZone"`
+}
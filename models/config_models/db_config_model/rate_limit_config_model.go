@@ -0,0 +1,31 @@
+package db_config_model
+
+// RateLimitRule 一个维度的限流规则，各项为 0 时不按该项限制
+type RateLimitRule struct {
+	// Rate 令牌桶每秒补充的令牌数，Burst 为桶容量（允许的突发请求数）
+	Rate  float64 `yaml:"Rate"`
+	Burst int     `yaml:"Burst"`
+	// DailyQuota 每个 key 每天（UTC）允许的请求数
+	DailyQuota int `yaml:"DailyQuota"`
+}
+
+// RateLimitScopeModel 一组接口按飞行器、API 凭证与客户端 IP 三个维度的限流规则，请求须同时满足
+type RateLimitScopeModel struct {
+	Aircraft   RateLimitRule `yaml:"Aircraft"`
+	Credential RateLimitRule `yaml:"Credential"`
+	IP         RateLimitRule `yaml:"IP"`
+}
+
+type RateLimitConfigModel struct {
+	// Enable 对上传与查询接口限流，令牌桶保存在 Redis 中，多个实例共享同一限额
+	Enable bool `yaml:"Enable"`
+	// CredentialHeader 携带 API 凭证的请求头，未携带的请求不按凭证限流
+	CredentialHeader string `yaml:"CredentialHeader"`
+	// FailOpen Redis 不可用时放行请求，为 false 时返回 503
+	FailOpen bool `yaml:"FailOpen"`
+	// UsageRetentionDays 按天统计的各 key 用量保留天数
+	UsageRetentionDays int `yaml:"UsageRetentionDays"`
+	// Upload/Query 分别作用于 /upload 与 /request 下的接口
+	Upload RateLimitScopeModel `yaml:"Upload"`
+	Query  RateLimitScopeModel `yaml:"Query"`
+}
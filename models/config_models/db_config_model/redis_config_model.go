@@ -1,10 +1,25 @@
 package db_config_model
 
 type RedisConfigModel struct {
-	Port         int    `yaml:"Port"`
-	StatusDBno   int    `yaml:"StatusDBno"`
-	EventDBno    int    `yaml:"EventDBno"`
-	Host         string `yaml:"Host"`
-	AircraftDBno int    `yaml:"AircraftDBno"`
-	TaskInfoDBno int    `yaml:"TaskInfoDBno"`
+	// Mode 部署模式：standalone / sentinel / cluster
+	Mode string `yaml:"Mode"`
+	Port int    `yaml:"Port"`
+	Host string `yaml:"Host"`
+	// Addrs sentinel 模式下为哨兵地址，cluster 模式下为集群节点地址
+	Addrs            []string       `yaml:"Addrs"`
+	MasterName       string         `yaml:"MasterName"`
+	SentinelPassword string         `yaml:"SentinelPassword" secret:"true"`
+	Username         string         `yaml:"Username"`
+	Password         string         `yaml:"Password" secret:"true"`
+	DB               int            `yaml:"DB"`
+	TLS              TLSConfigModel `yaml:"TLS"`
+	// 各类数据共用同一个 keyspace，通过前缀区分
+	StatusPrefix   string `yaml:"StatusPrefix"`
+	EventPrefix    string `yaml:"EventPrefix"`
+	AircraftPrefix string `yaml:"AircraftPrefix"`
+	TaskInfoPrefix string `yaml:"TaskInfoPrefix"`
+	// DedupPrefix 上传去重窗口、链路质量统计、链路监测状态与合理性校验的上一个点使用的前缀
+	DedupPrefix string `yaml:"DedupPrefix"`
+	// RateLimitPrefix 限流令牌桶与各 key 用量统计使用的前缀
+	RateLimitPrefix string `yaml:"RateLimitPrefix"`
 }
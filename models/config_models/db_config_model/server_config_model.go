@@ -0,0 +1,8 @@
+package db_config_model
+
+type ServerConfigModel struct {
+	Port int    `yaml:"Port"`
+	Mode string `yaml:"Mode"`
+	// ShutdownTimeoutSec 收到退出信号后等待进行中的请求完成的最长时间
+	ShutdownTimeoutSec int `yaml:"ShutdownTimeoutSec"`
+}
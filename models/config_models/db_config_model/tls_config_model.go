@@ -0,0 +1,10 @@
+package db_config_model
+
+type TLSConfigModel struct {
+	Enable             bool   `yaml:"Enable"`
+	CAFile             string `yaml:"CAFile"`
+	CertFile           string `yaml:"CertFile"`
+	KeyFile            string `yaml:"KeyFile"`
+	ServerName         string `yaml:"ServerName"`
+	InsecureSkipVerify bool   `yaml:"InsecureSkipVerify"`
+}
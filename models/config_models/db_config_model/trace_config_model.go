@@ -0,0 +1,15 @@
+package db_config_model
+
+type TraceConfigModel struct {
+	Enable bool `yaml:"Enable"`
+	// Exporter 导出方式：otlp（OTLP/HTTP）/ stdout / file
+	Exporter    string `yaml:"Exporter"`
+	ServiceName string `yaml:"ServiceName"`
+	// Endpoint OTLP/HTTP 接收端地址，如 127.0.0.1:4318
+	Endpoint string `yaml:"Endpoint"`
+	Insecure bool   `yaml:"Insecure"`
+	// FilePath Exporter 为 file 时写入的文件
+	FilePath string `yaml:"FilePath"`
+	// SampleRatio 根 span 的采样比例，0-1
+	SampleRatio float64 `yaml:"SampleRatio"`
+}
@@ -0,0 +1,19 @@
+package db_config_model
+
+type ValidationConfigModel struct {
+	// Enable 上传状态时做合理性校验，未通过的点写入隔离区而不进入轨迹表与最新状态
+	Enable bool `yaml:"Enable"`
+	// MinAltitude/MaxAltitude 允许的高度范围（米）
+	MinAltitude float64 `yaml:"MinAltitude"`
+	MaxAltitude float64 `yaml:"MaxAltitude"`
+	// RejectNullIsland 拒绝经纬度同时为 0 的点（定位未就绪时常见的默认值）
+	RejectNullIsland bool `yaml:"RejectNullIsland"`
+	// MaxSpeedMps 与同一飞行器上一个通过校验的点相比允许的最大速度（米/秒），0 不检查
+	MaxSpeedMps float64 `yaml:"MaxSpeedMps"`
+	// SpeedWindowSec 上一个点早于该时长（秒）时不做速度检查，避免一个错误的点长期拦截后续的点；
+	// 上一个点在缓存中同样只保留该时长
+	SpeedWindowSec int `yaml:"SpeedWindowSec"`
+	// MaxFutureSec/MaxPastSec 点的时间晚于或早于服务器当前时间的上限（秒），0 不检查
+	MaxFutureSec int `yaml:"MaxFutureSec"`
+	MaxPastSec   int `yaml:"MaxPastSec"`
+}
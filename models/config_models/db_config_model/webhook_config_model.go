@@ -0,0 +1,17 @@
+package db_config_model
+
+type WebhookConfigModel struct {
+	// Enable 生成任务与事件通知并投递到订阅的 URL
+	Enable bool `yaml:"Enable"`
+	// TimeoutMs 单次投递的 HTTP 超时（毫秒）
+	TimeoutMs int `yaml:"TimeoutMs"`
+	// MaxAttempts 每条通知最多尝试投递的次数，用尽后标记为 failed
+	MaxAttempts int `yaml:"MaxAttempts"`
+	// InitialBackoffSec/MaxBackoffSec 首次失败后的重试间隔与上限（秒），此后每次失败间隔翻倍
+	InitialBackoffSec int `yaml:"InitialBackoffSec"`
+	MaxBackoffSec     int `yaml:"MaxBackoffSec"`
+	// PollIntervalSec 检查待投递记录的周期（秒）
+	PollIntervalSec int `yaml:"PollIntervalSec"`
+	// BatchSize 每次取出并发投递的记录数
+	BatchSize int `yaml:"BatchSize"`
+}
@@ -0,0 +1,85 @@
+package alert_model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 告警状态：open -> acknowledged -> resolved，open 也可直接 resolved
+const (
+	StatusOpen         = "open"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+// 审计记录的动作
+const (
+	ActionOpen        = "open"
+	ActionAcknowledge = "acknowledge"
+	ActionResolve     = "resolve"
+	ActionEscalate    = "escalate"
+)
+
+// Alert 由事件生成的告警，同一飞行器同一时间的同一事件只生成一条
+type Alert struct {
+	AlertID    int             `json:"AlertID"`
+	AircraftID int             `json:"AircraftID"`
+	Event      string          `json:"Event"`
+	Severity   string          `json:"Severity"`
+	EventTime  time.Time       `json:"EventTime"`
+	Payload    json.RawMessage `json:"Payload,omitempty"`
+	Status     string          `json:"Status"`
+	Assignee   string          `json:"Assignee"`
+	// EscalationLevel 未确认期间已升级的次数
+	EscalationLevel int        `json:"EscalationLevel"`
+	CreateTime      time.Time  `json:"CreateTime"`
+	UpdateTime      time.Time  `json:"UpdateTime"`
+	AckTime         *time.Time `json:"AckTime"`
+	ResolveTime     *time.Time `json:"ResolveTime"`
+}
+
+// AlertAudit 告警的一次状态变化
+type AlertAudit struct {
+	AuditID    int       `json:"AuditID"`
+	AlertID    int       `json:"AlertID"`
+	Action     string    `json:"Action"`
+	FromStatus string    `json:"FromStatus"`
+	ToStatus   string    `json:"ToStatus"`
+	Operator   string    `json:"Operator"`
+	Note       string    `json:"Note"`
+	CreateTime time.Time `json:"CreateTime"`
+}
+
+// AlertChange 一次状态变化：Status 为目标状态，Assignee 为空时保持原负责人
+type AlertChange struct {
+	Action   string
+	Status   string
+	Assignee string
+	Operator string
+	Note     string
+}
+
+// ListAlertsRequest 查询告警，各条件为零值时不筛选；按创建时间从新到旧返回至多 Count 条
+type ListAlertsRequest struct {
+	Status     string `json:"Status"`
+	AircraftID int    `json:"AircraftID"`
+	Severity   string `json:"Severity"`
+	Count      int    `json:"Count"`
+}
+
+// AlertActionRequest 确认或解决告警；Operator 为操作人，确认时 Assignee 为空则由操作人负责。
+// Note 只写入本次操作的审计记录，不在告警上保存，通过 /alert/audit 查询
+type AlertActionRequest struct {
+	AlertID  int    `json:"AlertID"`
+	Operator string `json:"Operator"`
+	Assignee string `json:"Assignee"`
+	Note     string `json:"Note"`
+}
+
+// AlertIDRequest 按 AlertID 查询
+type AlertIDRequest struct {
+	AlertID int `json:"AlertID"`
+}
+
+// SystemOperator 服务自身（生成与升级告警）在审计记录中的操作人
+const SystemOperator = "system"
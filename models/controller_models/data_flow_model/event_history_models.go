@@ -0,0 +1,12 @@
+package data_flow_model
+
+import "strconv"
+
+// GlobalEventHistoryKey 全部飞行器最近事件的 ZSET key（不含前缀）
+const GlobalEventHistoryKey = "history:all"
+
+// EventHistoryKey 返回单架飞行器最近事件的 ZSET key（不含前缀），
+// {AircraftID} 作为 hash tag 与去重窗口落在同一个 slot
+func EventHistoryKey(AircraftID int) string {
+	return "history:{" + strconv.Itoa(AircraftID) + "}"
+}
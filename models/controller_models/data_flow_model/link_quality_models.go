@@ -0,0 +1,65 @@
+package data_flow_model
+
+import "strconv"
+
+// LinkQuality 单架飞行器按序号统计的链路质量
+type LinkQuality struct {
+	AircraftID int `json:"AircraftID"`
+	// Received 收到的不重复状态消息数
+	Received int64 `json:"Received"`
+	// Missing 按序号缺口推算的丢失消息数
+	Missing    int64 `json:"Missing"`
+	Duplicates int64 `json:"Duplicates"`
+	// LossRate Missing / (Received + Missing)
+	LossRate float64 `json:"LossRate"`
+	// Since 开始统计的毫秒时间戳
+	Since int64 `json:"Since"`
+	// ClockSkewMs 最近一个状态的服务端接收时间减去上报时间（毫秒），包含传输延迟；
+	// 正值表示飞行器时钟偏慢，负值表示偏快
+	ClockSkewMs int64 `json:"ClockSkewMs"`
+	// AvgClockSkewMs/MinClockSkewMs/MaxClockSkewMs 全部 ClockSkewSamples 个样本的平均、最小与最大偏差
+	AvgClockSkewMs   float64 `json:"AvgClockSkewMs"`
+	MinClockSkewMs   int64   `json:"MinClockSkewMs"`
+	MaxClockSkewMs   int64   `json:"MaxClockSkewMs"`
+	ClockSkewSamples int64   `json:"ClockSkewSamples"`
+}
+
+// LinkStatsKey 返回飞行器链路质量统计在 Redis 中的 key（不含前缀），
+// {AircraftID} 作为 hash tag 与去重窗口落在同一个 slot
+func LinkStatsKey(AircraftID int) string {
+	return "link:{" + strconv.Itoa(AircraftID) + "}"
+}
+
+const (
+	// LinkSeenKey 进行中任务的飞行器最近接收状态时间的 ZSET key（不含前缀）
+	LinkSeenKey = "watchdog:{link}:seen"
+	// LinkLostKey 已判定失联的飞行器集合的 key（不含前缀），与 LinkSeenKey 共用 hash tag
+	LinkLostKey = "watchdog:{link}:lost"
+	// EventLinkLost 飞行器超过 PipelineCfg.LinkTimeoutSec 未上报状态时由链路监测发出的事件
+	EventLinkLost = "LINK_LOST"
+	// EventLinkRestored 失联的飞行器恢复上报时发出的事件
+	EventLinkRestored = "LINK_RESTORED"
+)
+
+// NewLinkQuality 由 Redis 中的统计字段构造链路质量
+func NewLinkQuality(AircraftID int, fields map[string]string) LinkQuality {
+	parse := func(name string) int64 {
+		value, _ := strconv.ParseInt(fields[name], 10, 64)
+		return value
+	}
+	quality := LinkQuality{
+		AircraftID: AircraftID,
+		Received:   parse("received"),
+		Missing:    parse("missing"),
+		Duplicates: parse("duplicates"),
+		Since:      parse("since"),
+	}
+	if total := quality.Received + quality.Missing; total > 0 {
+		quality.LossRate = float64(quality.Missing) / float64(total)
+	}
+	if quality.ClockSkewSamples = parse("skew_count"); quality.ClockSkewSamples > 0 {
+		quality.ClockSkewMs, quality.MinClockSkewMs, quality.MaxClockSkewMs = parse("skew_last"), parse("skew_min"), parse("skew_max")
+		quality.AvgClockSkewMs = float64(parse("skew_sum")) / float64(quality.ClockSkewSamples)
+	}
+	return quality
+}
@@ -3,3 +3,20 @@ package data_flow_model
 type RecAircraftStatusRequest struct {
 	AircraftID int `json:"AircraftID"`
 }
+
+// RecAircraftEventRequest 查询飞行器事件；Count、Since 与 Severity 均为空时只返回最新一条事件，
+// 否则按时间从新到旧返回不早于 Since、严重级别为 Severity 的至多 Count 条事件；
+// Since 可为 RFC3339、毫秒时间戳或 UTC 的旧格式时间
+type RecAircraftEventRequest struct {
+	AircraftID int    `json:"AircraftID"`
+	Count      int    `json:"Count"`
+	Since      string `json:"Since"`
+	Severity   string `json:"Severity"`
+}
+
+// RecRecentEventsRequest 查询全部飞行器的最近事件
+type RecRecentEventsRequest struct {
+	Count    int    `json:"Count"`
+	Since    string `json:"Since"`
+	Severity string `json:"Severity"`
+}
@@ -1,16 +1,107 @@
 package data_flow_model
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
 type AircraftStatus struct {
+	// TimeString 上传时可为 RFC3339、毫秒时间戳（数字或数字串）或旧格式，上传接口将其归一化为 UTC 的
+	// 2006-01-02 15:04:05.000000 格式
 	TimeString string  `json:"TimeString"`
 	Yaw        float64 `json:"Yaw"`
 	Latitude   float64 `json:"Latitude"`
 	Longitude  float64 `json:"Longitude"`
 	Altitude   float64 `json:"Altitude"`
 	AircraftID int     `json:"AircraftID"`
+	// MessageID 可选的消息唯一标识，重发时保持不变
+	MessageID string `json:"MessageID,omitempty"`
+	// Seq 可选的单架飞行器内递增序号，用于去重与丢包统计
+	Seq *int64 `json:"Seq,omitempty"`
+	// ReceiveTime 服务端收到上传的 UTC 时间，由上传接口填写，上传时携带的值会被覆盖
+	ReceiveTime string `json:"ReceiveTime,omitempty"`
 }
 
 type AircraftEvent struct {
 	TimeString string `json:"TimeString"`
+	// Event 事件目录中的事件类型代码
 	Event      string `json:"Event"`
 	AircraftID int    `json:"AircraftID"`
+	MessageID  string `json:"MessageID,omitempty"`
+	Seq        *int64 `json:"Seq,omitempty"`
+	// Severity 由上传接口按事件目录填写，上传时携带的值会被覆盖
+	Severity string `json:"Severity,omitempty"`
+	// Payload 可选的结构化内容，需符合事件类型的 PayloadSchema
+	Payload json.RawMessage `json:"Payload,omitempty"`
+	// ReceiveTime 与 AircraftStatus.ReceiveTime 相同
+	ReceiveTime string `json:"ReceiveTime,omitempty"`
+}
+
+// UnmarshalJSON 除字符串外也接受数字形式（毫秒时间戳）的 TimeString
+func (s *AircraftStatus) UnmarshalJSON(data []byte) error {
+	type plain AircraftStatus
+	request := struct {
+		*plain
+		TimeString json.RawMessage `json:"TimeString"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	var err error
+	s.TimeString, err = decodeTimeString(request.TimeString)
+	return err
+}
+
+// UnmarshalJSON 与 AircraftStatus.UnmarshalJSON 相同
+func (e *AircraftEvent) UnmarshalJSON(data []byte) error {
+	type plain AircraftEvent
+	request := struct {
+		*plain
+		TimeString json.RawMessage `json:"TimeString"`
+	}{plain: (*plain)(e)}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	var err error
+	e.TimeString, err = decodeTimeString(request.TimeString)
+	return err
+}
+
+// decodeTimeString 返回 JSON 字符串的内容或 JSON 整数的字面值，缺省或为 null 时返回空串
+func decodeTimeString(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] == '"' {
+		var value string
+		err := json.Unmarshal(raw, &value)
+		return value, err
+	}
+	var millis int64
+	if err := json.Unmarshal(raw, &millis); err != nil {
+		return "", fmt.Errorf("TimeString must be a string or integer milliseconds: %w", err)
+	}
+	return strconv.FormatInt(millis, 10), nil
+}
+
+// DedupKey 返回去重使用的消息标识，优先级为 Seq、MessageID、TimeString
+func (s *AircraftStatus) DedupKey() string {
+	return dedupKey(s.Seq, s.MessageID, s.TimeString)
+}
+
+// DedupKey 返回去重使用的消息标识，优先级为 Seq、MessageID、TimeString；
+// 同一秒内可能有多个不同事件，因此按时间去重时同时比较事件内容
+func (e *AircraftEvent) DedupKey() string {
+	return dedupKey(e.Seq, e.MessageID, e.TimeString+"|"+e.Event)
+}
+
+func dedupKey(seq *int64, messageID, timeString string) string {
+	switch {
+	case seq != nil:
+		return "seq:" + strconv.FormatInt(*seq, 10)
+	case messageID != "":
+		return "id:" + messageID
+	}
+	return "time:" + timeString
 }
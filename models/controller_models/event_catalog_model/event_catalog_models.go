@@ -0,0 +1,76 @@
+package event_catalog_model
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// 事件严重级别，由低到高
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities 全部严重级别，由低到高
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// CodePattern 事件类型代码的格式，与事件表 Event 列的 VARCHAR(64) 一致
+var CodePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+
+// EventType 事件目录中的一种事件类型；PayloadSchema 为空时该类事件不允许携带 Payload
+type EventType struct {
+	Code          string          `json:"Code"`
+	Severity      string          `json:"Severity"`
+	Description   string          `json:"Description"`
+	PayloadSchema json.RawMessage `json:"PayloadSchema,omitempty"`
+}
+
+// DeleteEventTypeRequest 删除事件类型
+type DeleteEventTypeRequest struct {
+	Code string `json:"Code"`
+}
+
+// BuiltinEventTypes 随迁移 0002_event_catalog 写入的内置事件类型，MemoryDatabase 以此初始化。
+// 链路事件由服务自身发出，不允许删除
+var BuiltinEventTypes = []EventType{
+	{Code: "LINK_LOST", Severity: SeverityWarning, Description: "飞行器超过链路超时时间未上报状态"},
+	{Code: "LINK_RESTORED", Severity: SeverityInfo, Description: "失联的飞行器恢复上报"},
+	{Code: "TAKEOFF", Severity: SeverityInfo, Description: "起飞"},
+	{Code: "LANDING", Severity: SeverityInfo, Description: "降落"},
+	{
+		Code: "LOW_BATTERY", Severity: SeverityWarning, Description: "电量低",
+		PayloadSchema: json.RawMessage(`{"type":"object","properties":{"Battery":{"type":"number","minimum":0,"maximum":100}},"required":["Battery"]}`),
+	},
+	{Code: "EMERGENCY", Severity: SeverityCritical, Description: "紧急情况"},
+}
+
+// IsValidSeverity 判断 severity 是否为已定义的严重级别
+func IsValidSeverity(severity string) bool {
+	return SeverityRank(severity) >= 0
+}
+
+// SeverityRank 返回严重级别由低到高的序号，未定义的级别返回 -1
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsSystemEventType 判断 code 是否为服务自身发出的事件类型
+func IsSystemEventType(code string) bool {
+	return code == "LINK_LOST" || code == "LINK_RESTORED"
+}
+
+// BuiltinSeverity 返回内置事件类型的严重级别，未定义时返回空串
+func BuiltinSeverity(code string) string {
+	for _, eventType := range BuiltinEventTypes {
+		if eventType.Code == code {
+			return eventType.Severity
+		}
+	}
+	return ""
+}
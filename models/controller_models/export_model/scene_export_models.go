@@ -0,0 +1,17 @@
+package export_model
+
+import "uam-power-backend/models/controller_models/aircraft_task_model"
+
+// SceneExportRequest 导出时间窗口内的场景；StartTime、EndTime 可为 RFC3339、毫秒时间戳或 UTC 的旧格式时间
+type SceneExportRequest struct {
+	StartTime  string `json:"StartTime"`
+	EndTime    string `json:"EndTime"`
+	AircraftID int    `json:"AircraftID"`
+	LaneID     int    `json:"LaneID"`
+}
+
+type SceneTrack struct {
+	Task   aircraft_task_model.MysqlAircraftTask
+	Points []TrackPoint
+	Events []TrackEvent
+}
@@ -0,0 +1,23 @@
+package export_model
+
+import "time"
+
+type TrackExportRequest struct {
+	TaskID       int    `json:"TaskID"`
+	Format       string `json:"Format"`
+	AltitudeMode string `json:"AltitudeMode"`
+}
+
+type TrackPoint struct {
+	Longitude float64   `json:"Longitude"`
+	Latitude  float64   `json:"Latitude"`
+	Altitude  float64   `json:"Altitude"`
+	Yaw       float64   `json:"Yaw"`
+	DataTime  time.Time `json:"DataTime"`
+}
+
+type TrackEvent struct {
+	Event    string      `json:"Event"`
+	DataTime time.Time   `json:"DataTime"`
+	Point    *TrackPoint `json:"Point"`
+}
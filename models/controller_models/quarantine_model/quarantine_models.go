@@ -0,0 +1,74 @@
+package quarantine_model
+
+import (
+	"time"
+	"uam-power-backend/models/controller_models/data_flow_model"
+)
+
+// 隔离记录状态：pending -> released（重新投递到状态 topic）或 discarded
+const (
+	StatusPending   = "pending"
+	StatusReleased  = "released"
+	StatusDiscarded = "discarded"
+)
+
+// 审核动作
+const (
+	ActionRelease = "release"
+	ActionDiscard = "discard"
+)
+
+// 未通过合理性校验的原因代码
+const (
+	ReasonLatitude   = "latitude_out_of_range"
+	ReasonLongitude  = "longitude_out_of_range"
+	ReasonNullIsland = "null_island"
+	ReasonAltitude   = "altitude_out_of_range"
+	ReasonFuture     = "time_in_future"
+	ReasonPast       = "time_too_old"
+	ReasonSpeed      = "implied_speed"
+)
+
+// Reason 一项未通过的检查，Detail 为便于审核的说明（如实际值与允许范围）
+type Reason struct {
+	Code   string `json:"Code"`
+	Detail string `json:"Detail"`
+}
+
+// QuarantinedPoint 未通过合理性校验的状态点，Point 为上传时的原始内容（Yaw 已归一化）
+type QuarantinedPoint struct {
+	QuarantineID int                            `json:"QuarantineID"`
+	AircraftID   int                            `json:"AircraftID"`
+	TimeString   string                         `json:"TimeString"`
+	Point        data_flow_model.AircraftStatus `json:"Point"`
+	Reasons      []Reason                       `json:"Reasons"`
+	Status       string                         `json:"Status"`
+	Reviewer     string                         `json:"Reviewer"`
+	Note         string                         `json:"Note"`
+	CreateTime   time.Time                      `json:"CreateTime"`
+	ReviewTime   *time.Time                     `json:"ReviewTime"`
+}
+
+// Review 一次审核：Status 为目标状态，回到 pending 时清空审核人与审核时间
+type Review struct {
+	Status   string
+	Reviewer string
+	Note     string
+}
+
+// ListQuarantineRequest 查询隔离记录，各条件为零值时不筛选；按隔离时间从新到旧返回至多 Count 条
+type ListQuarantineRequest struct {
+	Status     string `json:"Status"`
+	AircraftID int    `json:"AircraftID"`
+	// Reason 只返回包含该原因代码的记录
+	Reason string `json:"Reason"`
+	Count  int    `json:"Count"`
+}
+
+// ReviewQuarantineRequest 审核一条 pending 的隔离记录，Action 为 release 或 discard
+type ReviewQuarantineRequest struct {
+	QuarantineID int    `json:"QuarantineID"`
+	Action       string `json:"Action"`
+	Reviewer     string `json:"Reviewer"`
+	Note         string `json:"Note"`
+}
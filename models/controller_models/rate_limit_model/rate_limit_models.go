@@ -0,0 +1,33 @@
+package rate_limit_model
+
+// 限流作用的接口组
+const (
+	ScopeUpload = "upload"
+	ScopeQuery  = "query"
+)
+
+// 限流维度
+const (
+	DimensionAircraft   = "aircraft"
+	DimensionCredential = "credential"
+	DimensionIP         = "ip"
+)
+
+// UsageRequest 查询某天（UTC，格式 2006-01-02，为空时为当天）各 key 的用量，各条件为空时不筛选；
+// 按请求数从多到少返回至多 Count 条。Dimension 为 credential 时 Key 可为凭证原文或其摘要
+type UsageRequest struct {
+	Date      string `json:"Date"`
+	Scope     string `json:"Scope"`
+	Dimension string `json:"Dimension"`
+	Key       string `json:"Key"`
+	Count     int    `json:"Count"`
+}
+
+// UsageEntry 一个 key 在一天内被放行与拒绝的请求数；凭证以摘要表示，不保存原文
+type UsageEntry struct {
+	Scope     string `json:"Scope"`
+	Dimension string `json:"Dimension"`
+	Key       string `json:"Key"`
+	Allowed   int64  `json:"Allowed"`
+	Rejected  int64  `json:"Rejected"`
+}
@@ -0,0 +1,101 @@
+package webhook_model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 任务与告警升级通知的类型；事件通知的类型即事件类型代码（如 LINK_LOST）
+const (
+	TypeTaskStarted    = "TASK_STARTED"
+	TypeTaskEnded      = "TASK_ENDED"
+	TypeAlertEscalated = "ALERT_ESCALATED"
+)
+
+// 投递状态：pending 等待（重试）投递，delivered 已收到 2xx 响应，failed 重试次数用尽
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// 投递请求携带的请求头。签名为 HMAC-SHA256(Secret, Timestamp + "." + 请求体) 的十六进制，带 "sha256=" 前缀
+const (
+	HeaderEvent     = "X-UAM-Event"
+	HeaderDelivery  = "X-UAM-Delivery"
+	HeaderTimestamp = "X-UAM-Timestamp"
+	HeaderSignature = "X-UAM-Signature"
+)
+
+// Subscription 一个 webhook 订阅；EventTypes 为空时接收全部通知。Secret 只在创建时提交，不随接口返回
+type Subscription struct {
+	SubscriptionID int       `json:"SubscriptionID"`
+	URL            string    `json:"URL"`
+	Secret         string    `json:"-"`
+	EventTypes     []string  `json:"EventTypes"`
+	Description    string    `json:"Description"`
+	CreateTime     time.Time `json:"CreateTime"`
+}
+
+// Accepts 判断订阅是否接收该类型的通知
+func (s *Subscription) Accepts(notificationType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, eventType := range s.EventTypes {
+		if eventType == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification 投递的请求体。ID 在同一通知的重投与重试间保持不变，接收方可据此去重
+type Notification struct {
+	ID   string          `json:"ID"`
+	Type string          `json:"Type"`
+	Time time.Time       `json:"Time"`
+	Data json.RawMessage `json:"Data"`
+}
+
+// Delivery 一条通知对一个订阅的投递记录，同时作为投递日志返回
+type Delivery struct {
+	DeliveryID      int             `json:"DeliveryID"`
+	SubscriptionID  int             `json:"SubscriptionID"`
+	NotificationID  string          `json:"NotificationID"`
+	Type            string          `json:"Type"`
+	Body            json.RawMessage `json:"Body"`
+	Status          string          `json:"Status"`
+	Attempts        int             `json:"Attempts"`
+	NextAttemptTime time.Time       `json:"NextAttemptTime"`
+	// LastStatusCode/LastError 最近一次尝试的 HTTP 状态码（未收到响应时为 0）与错误信息
+	LastStatusCode int        `json:"LastStatusCode"`
+	LastError      string     `json:"LastError"`
+	CreateTime     time.Time  `json:"CreateTime"`
+	UpdateTime     time.Time  `json:"UpdateTime"`
+	DeliveredTime  *time.Time `json:"DeliveredTime"`
+	// URL/Secret 取出待投递记录时从订阅中补齐
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// CreateSubscriptionRequest 创建订阅
+type CreateSubscriptionRequest struct {
+	URL         string   `json:"URL"`
+	Secret      string   `json:"Secret"`
+	EventTypes  []string `json:"EventTypes"`
+	Description string   `json:"Description"`
+}
+
+// SubscriptionIDRequest 按 SubscriptionID 删除订阅
+type SubscriptionIDRequest struct {
+	SubscriptionID int `json:"SubscriptionID"`
+}
+
+// ListDeliveriesRequest 查询投递日志，各条件为零值时不筛选；按创建时间从新到旧返回至多 Count 条
+type ListDeliveriesRequest struct {
+	SubscriptionID int    `json:"SubscriptionID"`
+	Status         string `json:"Status"`
+	Type           string `json:"Type"`
+	Count          int    `json:"Count"`
+}
@@ -3,15 +3,12 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"uam-power-backend/controller/aircraft_id_controller"
-	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/app_service"
 	"uam-power-backend/utils"
 )
 
-func SetupAircraftIdRoutes(
-	r *gin.Engine, RedisCfg *db_config_model.RedisConfigModel,
-	MySqlCfg *db_config_model.MySqlConfigModel,
-) {
-	aircraftIDController := aircraft_id_controller.NewAircraftIdController(MySqlCfg, RedisCfg)
+func SetupAircraftIdRoutes(r *gin.Engine, app *app_service.Container) {
+	aircraftIDController := aircraft_id_controller.NewAircraftIdController(app)
 	uploadApis := r.Group("/aircraftID")
 	uploadApis.POST("/info", aircraftIDController.GetAircraftInfo)
 	uploadApis.POST("/create", aircraftIDController.CreateUser)
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/alert_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+// SetupAlertRoutes 配置告警的查询、确认、解决与审计接口
+func SetupAlertRoutes(r *gin.Engine, app *app_service.Container) {
+	alertController := alert_controller.NewAlertController(app)
+	alertApis := r.Group("/alert")
+	alertApis.POST("/list", alertController.ListAlerts)
+	alertApis.POST("/acknowledge", alertController.AcknowledgeAlert)
+	alertApis.POST("/resolve", alertController.ResolveAlert)
+	alertApis.POST("/audit", alertController.AlertAudit)
+	utils.MsgSuccess("    [AlertRoutes]Successfully init!")
+}
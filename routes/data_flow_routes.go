@@ -3,28 +3,29 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"uam-power-backend/controller/data_controller"
-	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/middleware"
+	"uam-power-backend/models/controller_models/rate_limit_model"
+	"uam-power-backend/service/app_service"
 	"uam-power-backend/utils"
 )
 
 // SetupDataFlowRoutes 配置所有路由
-func SetupDataFlowRoutes(
-	r *gin.Engine, kafkaCfg *db_config_model.KafkaConfigModel,
-	redisCfg *db_config_model.RedisConfigModel,
-) {
-	aircraftUploadController := data_controller.NewUploadAircraftController(kafkaCfg)
-	aircraftReqController := data_controller.NewReceiveAircraft(redisCfg)
+func SetupDataFlowRoutes(r *gin.Engine, app *app_service.Container) {
+	aircraftUploadController := data_controller.NewUploadAircraftController(app)
+	aircraftReqController := data_controller.NewReceiveAircraft(app)
 	// 设置公共路由
 	r.GET("/alive", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "OK"})
 	})
 
-	uploadApis := r.Group("/upload")
+	uploadApis := r.Group("/upload", middleware.RateLimit(app.RateLimiter, rate_limit_model.ScopeUpload))
 	uploadApis.POST("/aircraftData", aircraftUploadController.UploadData)
 	uploadApis.POST("/aircraftEvent", aircraftUploadController.UploadEvent)
 
-	recApis := r.Group("/request")
+	recApis := r.Group("/request", middleware.RateLimit(app.RateLimiter, rate_limit_model.ScopeQuery))
 	recApis.POST("/aircraftData", aircraftReqController.RequestAircraftStatus)
 	recApis.POST("/aircraftEvent", aircraftReqController.RequestAircraftEvent)
+	recApis.POST("/recentEvents", aircraftReqController.RequestRecentEvents)
+	recApis.POST("/linkQuality", aircraftReqController.RequestLinkQuality)
 	utils.MsgSuccess("    [SetupDataFlowRoutes]Successfully init!")
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/event_catalog_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+// SetupEventCatalogRoutes 配置事件目录的管理接口
+func SetupEventCatalogRoutes(r *gin.Engine, app *app_service.Container) {
+	eventCatalogController := event_catalog_controller.NewEventCatalogController(app)
+	catalogApis := r.Group("/eventCatalog")
+	catalogApis.POST("/list", eventCatalogController.ListEventTypes)
+	catalogApis.POST("/upsert", eventCatalogController.UpsertEventType)
+	catalogApis.POST("/delete", eventCatalogController.DeleteEventType)
+	utils.MsgSuccess("    [EventCatalogRoutes]Successfully init!")
+}
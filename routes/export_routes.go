@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/export_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+func SetupExportRoutes(r *gin.Engine, app *app_service.Container) {
+	trackExportController := export_controller.NewTrackExportController(app)
+	exportApis := r.Group("/export")
+	exportApis.POST("/track", trackExportController.ExportTrack)
+	exportApis.POST("/scene", trackExportController.ExportScene)
+	utils.MsgSuccess("    [SetupExportRoutes]Successfully init!")
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/health_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/utils"
+)
+
+// SetupHealthRoutes 注册存活与就绪检查接口
+func SetupHealthRoutes(r *gin.Engine, BusCfg *db_config_model.BusConfigModel, KafkaCfg *db_config_model.KafkaConfigModel) {
+	healthController := health_controller.NewHealthController(BusCfg, KafkaCfg)
+	healthApis := r.Group("/health")
+	healthApis.GET("/live", healthController.Live)
+	healthApis.GET("/ready", healthController.Ready)
+	utils.MsgSuccess("    [SetupHealthRoutes]Successfully init!")
+}
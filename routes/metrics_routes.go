@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/utils"
+)
+
+// SetupMetricsRoutes 注册 Prometheus 抓取接口
+func SetupMetricsRoutes(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(metrics_service.Handler()))
+	utils.MsgSuccess("    [SetupMetricsRoutes]Successfully init!")
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/quarantine_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+// SetupQuarantineRoutes 配置隔离区的查询与审核接口
+func SetupQuarantineRoutes(r *gin.Engine, app *app_service.Container) {
+	quarantineController := quarantine_controller.NewQuarantineController(app)
+	quarantineApis := r.Group("/quarantine")
+	quarantineApis.POST("/list", quarantineController.ListQuarantine)
+	quarantineApis.POST("/review", quarantineController.ReviewQuarantine)
+	utils.MsgSuccess("    [QuarantineRoutes]Successfully init!")
+}
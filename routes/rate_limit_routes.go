@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/rate_limit_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+// SetupRateLimitRoutes 配置限流用量查询接口
+func SetupRateLimitRoutes(r *gin.Engine, app *app_service.Container) {
+	rateLimitController := rate_limit_controller.NewRateLimitController(app)
+	rateLimitApis := r.Group("/rateLimit")
+	rateLimitApis.POST("/usage", rateLimitController.Usage)
+	utils.MsgSuccess("    [RateLimitRoutes]Successfully init!")
+}
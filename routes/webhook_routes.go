@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"uam-power-backend/controller/webhook_controller"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+// SetupWebhookRoutes 配置 webhook 订阅管理与投递日志接口
+func SetupWebhookRoutes(r *gin.Engine, app *app_service.Container) {
+	webhookController := webhook_controller.NewWebhookController(app)
+	webhookApis := r.Group("/webhook")
+	webhookApis.POST("/create", webhookController.CreateSubscription)
+	webhookApis.POST("/list", webhookController.ListSubscriptions)
+	webhookApis.POST("/delete", webhookController.DeleteSubscription)
+	webhookApis.POST("/deliveries", webhookController.ListDeliveries)
+	utils.MsgSuccess("    [WebhookRoutes]Successfully init!")
+}
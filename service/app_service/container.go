@@ -0,0 +1,151 @@
+package app_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/rate_limit_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/validation_service"
+	"uam-power-backend/service/webhook_service"
+	"uam-power-backend/utils"
+)
+
+// connectTimeout 启动时单个依赖连通性检查的超时时间
+const connectTimeout = 5 * time.Second
+
+// Container 进程内共享的外部连接：每个 MySQL 库一个连接池、一个 Redis 客户端、每个上传 topic 一个生产者。
+// 控制器与转发服务从中取用连接，不再各自建立；Close 按建立的逆序释放全部连接
+type Container struct {
+	Config *db_config_model.DbConfigModel
+	// SystemDB、FlightDB、EventDB 分别连接 MySqlCfg.DB、FlightDB、EventDB
+	SystemDB *dbservice.MySQLService
+	FlightDB *dbservice.MySQLService
+	EventDB  *dbservice.MySQLService
+	// Redis 不带 key 前缀的共享客户端，按用途通过 WithPrefix 取得带前缀的视图
+	Redis          *dbservice.RedisDict
+	StatusProducer bus_service.Producer
+	EventProducer  bus_service.Producer
+	// EventCatalog 事件目录，上传校验与目录管理接口共用同一份缓存
+	EventCatalog *event_catalog_service.Catalog
+	// Webhooks webhook 通知，任务控制器与 KafkaToWebhook 共用；未开启时为 nil
+	Webhooks *webhook_service.Dispatcher
+	// RateLimiter 上传与查询接口的限流器，未开启时为 nil
+	RateLimiter *rate_limit_service.Limiter
+	// Validator 上传状态的合理性校验，未开启时为 nil
+	Validator *validation_service.Validator
+	closers   []closer
+}
+
+// closer 一个待释放的连接
+type closer struct {
+	name  string
+	close func() error
+}
+
+// NewContainer 按配置建立全部连接并逐一检查连通性，任一依赖不可用时释放已建立的连接，
+// 返回指明该依赖的错误
+func NewContainer(cfg *db_config_model.DbConfigModel) (*Container, error) {
+	app := &Container{Config: cfg}
+	if err := app.connect(); err != nil {
+		_ = app.Close()
+		return nil, err
+	}
+	utils.MsgSuccess("        [Container]Successfully init!")
+	return app, nil
+}
+
+func (c *Container) connect() error {
+	var err error
+	mysqlCfg := &c.Config.MySqlCfg
+	for _, db := range []struct {
+		name    string
+		service **dbservice.MySQLService
+	}{
+		{mysqlCfg.DB, &c.SystemDB}, {mysqlCfg.FlightDB, &c.FlightDB}, {mysqlCfg.EventDB, &c.EventDB},
+	} {
+		if *db.service, err = dbservice.NewMySQLService(utils.MySqlDSN(mysqlCfg, db.name)); err != nil {
+			return fmt.Errorf("mysql %s@%s:%d: %w", db.name, mysqlCfg.Host, mysqlCfg.Port, err)
+		}
+		c.addCloser("mysql:"+db.name, (*db.service).Close)
+		metrics_service.RegisterMySQLPool("Container/"+db.name, (*db.service).DB())
+		health_service.RegisterReadiness("mysql:Container/"+db.name, (*db.service).Ping)
+	}
+	c.EventCatalog = event_catalog_service.NewCatalog(repository_service.NewMySQLEventCatalog(c.SystemDB),
+		time.Duration(c.Config.PipelineCfg.EventCatalogRefreshSec)*time.Second)
+	c.Webhooks = webhook_service.NewDispatcher(repository_service.NewMySQLWebhookRepository(c.SystemDB), &c.Config.WebhookCfg)
+
+	redisCfg := &c.Config.RedisCfg
+	if c.Redis, err = dbservice.NewRedisDictWithConfig(redisCfg, ""); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	c.addCloser("redis", c.Redis.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	err = c.Redis.Ping(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("redis %s:%d: %w", redisCfg.Host, redisCfg.Port, err)
+	}
+	metrics_service.RegisterRedisPool("Container", c.Redis.PoolStats)
+	c.RateLimiter = rate_limit_service.NewLimiter(c.Redis.WithPrefix(redisCfg.RateLimitPrefix), &c.Config.RateLimitCfg)
+	c.Validator = validation_service.NewValidator(c.Redis.WithPrefix(redisCfg.DedupPrefix),
+		repository_service.NewMySQLQuarantineRepository(c.SystemDB), &c.Config.ValidationCfg)
+	health_service.RegisterReadiness("redis:Container", c.Redis.Ping)
+
+	busCfg, kafkaCfg := &c.Config.BusCfg, &c.Config.KafkaCfg
+	if bus_service.Backend(busCfg) == "kafka" {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		err = dbservice.PingKafkaBrokers(ctx, kafkaCfg)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("kafka brokers: %w", err)
+		}
+	}
+	for _, producer := range []struct {
+		topic  string
+		target *bus_service.Producer
+	}{
+		{kafkaCfg.AircraftDataTopic, &c.StatusProducer}, {kafkaCfg.AircraftEventTopic, &c.EventProducer},
+	} {
+		if *producer.target, err = bus_service.NewProducer(busCfg, kafkaCfg, c.Redis, producer.topic); err != nil {
+			return fmt.Errorf("%s producer %s: %w", bus_service.Backend(busCfg), producer.topic, err)
+		}
+		c.addCloser("producer:"+producer.topic, (*producer.target).Close)
+	}
+	return nil
+}
+
+// NewConsumer 创建 topic 在消费组 group 中的消费者，消费者随 Container 一起关闭
+func (c *Container) NewConsumer(topic, group string) (bus_service.Consumer, error) {
+	consumer, err := bus_service.NewConsumer(&c.Config.BusCfg, &c.Config.KafkaCfg, c.Redis, topic, group)
+	if err != nil {
+		return nil, fmt.Errorf("%s consumer %s/%s: %w", bus_service.Backend(&c.Config.BusCfg), topic, group, err)
+	}
+	c.addCloser("consumer:"+topic+"/"+group, consumer.Close)
+	return consumer, nil
+}
+
+func (c *Container) addCloser(name string, close func() error) {
+	c.closers = append(c.closers, closer{name: name, close: close})
+}
+
+// Close 按建立的逆序关闭全部连接：先消费者与生产者，再 Redis 与 MySQL。
+// 调用前应先停止转发服务与 HTTP 服务
+func (c *Container) Close() error {
+	var errs []error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		if err := c.closers[i].close(); err != nil {
+			utils.MsgError("        [Container]Close " + c.closers[i].name + " failed > " + err.Error())
+			errs = append(errs, fmt.Errorf("%s: %w", c.closers[i].name, err))
+		}
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
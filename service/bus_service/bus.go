@@ -0,0 +1,96 @@
+package bus_service
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/trace_service"
+	"uam-power-backend/utils"
+)
+
+// Message 总线上的一条消息
+type Message struct {
+	Key     string
+	Value   string
+	Headers map[string]string
+	// ack 各实现用于确认消息的内部标识
+	ack interface{}
+}
+
+// Producer 消息生产者。SendKeyedMessage 按 key 保证同一飞行器的消息有序
+type Producer interface {
+	SendKeyedMessage(ctx context.Context, key, message string) error
+	// Sync 为 true 时 SendKeyedMessage 返回 nil 即表示消息已持久写入总线
+	Sync() bool
+	Close() error
+}
+
+// Consumer 消费组内的消费者，各实现语义一致：
+// FetchMessage 取得的消息须在处理后调用 CommitMessage 确认；
+// 未确认的消息在消费者重启、失联或再均衡后重新投递给同组消费者（至少一次）
+type Consumer interface {
+	FetchMessage(ctx context.Context) (*Message, error)
+	CommitMessage(ctx context.Context, msg *Message) error
+	Topic() string
+	// System 返回总线类型（kafka、redis 或 memory），用作 trace 的 messaging.system
+	System() string
+	// Lag 返回该消费组尚未确认的消息积压量
+	Lag() int64
+	Close() error
+}
+
+// Backend 返回配置的总线类型，未配置时为 kafka
+func Backend(cfg *db_config_model.BusConfigModel) string {
+	if cfg.Backend == "" {
+		return "kafka"
+	}
+	return strings.ToLower(cfg.Backend)
+}
+
+// NewProducer 按 BusCfg.Backend 创建 topic 的生产者，redis 总线复用共享的 Redis 客户端 rdb
+func NewProducer(
+	busCfg *db_config_model.BusConfigModel, kafkaCfg *db_config_model.KafkaConfigModel,
+	rdb *dbservice.RedisDict, topic string,
+) (Producer, error) {
+	switch Backend(busCfg) {
+	case "kafka":
+		return newKafkaProducer(kafkaCfg, topic)
+	case "redis":
+		return newStreamProducer(busCfg, rdb, topic), nil
+	case "memory":
+		return defaultMemoryBus(busCfg).Producer(topic), nil
+	}
+	return nil, fmt.Errorf("unsupported bus backend %q", busCfg.Backend)
+}
+
+// NewConsumer 按 BusCfg.Backend 创建 topic 在消费组 group 中的消费者，redis 总线复用共享的 Redis 客户端 rdb
+func NewConsumer(
+	busCfg *db_config_model.BusConfigModel, kafkaCfg *db_config_model.KafkaConfigModel,
+	rdb *dbservice.RedisDict, topic, group string,
+) (Consumer, error) {
+	switch Backend(busCfg) {
+	case "kafka":
+		return newKafkaConsumer(kafkaCfg, topic, group)
+	case "redis":
+		return newStreamConsumer(busCfg, rdb, topic, group)
+	case "memory":
+		return defaultMemoryBus(busCfg).Consumer(topic, group), nil
+	}
+	return nil, fmt.Errorf("unsupported bus backend %q", busCfg.Backend)
+}
+
+// startProduceSpan 开启生产者 span，并生成携带请求 ID 与 trace context 的消息头
+func startProduceSpan(ctx context.Context, system, topic string) (trace.Span, map[string]string) {
+	ctx, span := trace_service.StartSpan(ctx, system+".produce "+topic, trace.SpanKindProducer,
+		attribute.String("messaging.system", system), attribute.String("messaging.destination.name", topic))
+	headers := map[string]string{}
+	if requestID := utils.RequestIDFromContext(ctx); requestID != "" {
+		headers[utils.RequestIDHeader] = requestID
+	}
+	trace_service.InjectHeaders(ctx, headers)
+	return span, headers
+}
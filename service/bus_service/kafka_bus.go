@@ -0,0 +1,44 @@
+package bus_service
+
+import (
+	"context"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+)
+
+func newKafkaProducer(cfg *db_config_model.KafkaConfigModel, topic string) (Producer, error) {
+	producer, err := dbservice.NewKafkaProducerWithConfig(cfg, topic)
+	if err != nil {
+		return nil, err
+	}
+	return producer, nil
+}
+
+// kafkaConsumer 以 Kafka 消费组实现 Consumer，确认即提交 offset
+type kafkaConsumer struct {
+	*dbservice.KafkaConsumer
+}
+
+func newKafkaConsumer(cfg *db_config_model.KafkaConfigModel, topic, group string) (Consumer, error) {
+	consumer, err := dbservice.NewKafkaConsumerWithConfig(cfg, topic, group)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConsumer{KafkaConsumer: consumer}, nil
+}
+
+func (c *kafkaConsumer) FetchMessage(ctx context.Context) (*Message, error) {
+	msg, err := c.FetchKafkaMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Key: msg.Key, Value: msg.Value, Headers: msg.Headers, ack: msg}, nil
+}
+
+func (c *kafkaConsumer) CommitMessage(ctx context.Context, msg *Message) error {
+	return c.CommitKafkaMessage(ctx, msg.ack.(*dbservice.KafkaMessage))
+}
+
+func (c *kafkaConsumer) System() string {
+	return "kafka"
+}
@@ -0,0 +1,184 @@
+package bus_service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/trace_service"
+)
+
+// ErrMemoryBusFull memory 总线某个消费组的缓存已满
+var ErrMemoryBusFull = errors.New("memory bus buffer is full")
+
+var (
+	memoryBusOnce sync.Once
+	memoryBus     *MemoryBus
+)
+
+// defaultMemoryBus 返回进程内共享的 memory 总线，缓存大小取首次调用时的配置
+func defaultMemoryBus(cfg *db_config_model.BusConfigModel) *MemoryBus {
+	memoryBusOnce.Do(func() {
+		memoryBus = NewMemoryBus(cfg.MemoryBuffer)
+	})
+	return memoryBus
+}
+
+// MemoryBus 进程内消息总线，每个 topic 的消息投递给其下的每个消费组，组内消费者竞争消费。
+// 与 Kafka 的 latest offset 相同，消费组只能收到其创建之后发送的消息；进程退出时未处理的消息丢失
+type MemoryBus struct {
+	mutex  sync.Mutex
+	topics map[string]map[string]*memoryGroup
+	buffer int
+}
+
+// memoryGroup 一个消费组的待投递队列
+type memoryGroup struct {
+	queue []*Message
+	// notify 队列由空变为非空时通知等待中的消费者
+	notify chan struct{}
+}
+
+// NewMemoryBus 创建 memory 总线，buffer 为每个消费组最多缓存的未读消息数
+func NewMemoryBus(buffer int) *MemoryBus {
+	return &MemoryBus{topics: map[string]map[string]*memoryGroup{}, buffer: buffer}
+}
+
+// Producer 返回 topic 的生产者
+func (b *MemoryBus) Producer(topic string) Producer {
+	return &memoryProducer{bus: b, topic: topic}
+}
+
+// Consumer 返回 topic 在消费组 group 中的消费者，消费组不存在时创建
+func (b *MemoryBus) Consumer(topic, group string) Consumer {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return &memoryConsumer{bus: b, topic: topic, group: b.groupLocked(topic, group)}
+}
+
+func (b *MemoryBus) groupLocked(topic, group string) *memoryGroup {
+	groups, ok := b.topics[topic]
+	if !ok {
+		groups = map[string]*memoryGroup{}
+		b.topics[topic] = groups
+	}
+	g, ok := groups[group]
+	if !ok {
+		g = &memoryGroup{notify: make(chan struct{}, 1)}
+		groups[group] = g
+	}
+	return g
+}
+
+// publish 将消息放入 topic 下每个消费组的队列，任一消费组缓存已满时整条消息都不投递
+func (b *MemoryBus) publish(topic string, msg *Message) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, g := range b.topics[topic] {
+		if len(g.queue) >= b.buffer {
+			return ErrMemoryBusFull
+		}
+	}
+	for _, g := range b.topics[topic] {
+		copied := *msg
+		g.queue = append(g.queue, &copied)
+		g.signal()
+	}
+	return nil
+}
+
+func (g *memoryGroup) signal() {
+	select {
+	case g.notify <- struct{}{}:
+	default:
+	}
+}
+
+// memoryProducer 消息进入各消费组队列即返回，因此总是同步投递
+type memoryProducer struct {
+	bus   *MemoryBus
+	topic string
+}
+
+func (p *memoryProducer) SendKeyedMessage(ctx context.Context, key, message string) (err error) {
+	span, headers := startProduceSpan(ctx, "memory", p.topic)
+	defer func() { trace_service.EndSpan(span, err) }()
+	return p.bus.publish(p.topic, &Message{Key: key, Value: message, Headers: headers})
+}
+
+func (p *memoryProducer) Sync() bool {
+	return true
+}
+
+func (p *memoryProducer) Close() error {
+	return nil
+}
+
+// memoryConsumer 已取出未确认的消息在 Close 时放回队列头部，由同组其他消费者重新处理
+type memoryConsumer struct {
+	bus   *MemoryBus
+	topic string
+	group *memoryGroup
+	// inflight 按取出顺序保存未确认的消息，放回队列时保持原有顺序
+	inflight []*Message
+}
+
+func (c *memoryConsumer) FetchMessage(ctx context.Context) (*Message, error) {
+	for {
+		c.bus.mutex.Lock()
+		if len(c.group.queue) > 0 {
+			msg := c.group.queue[0]
+			c.group.queue = c.group.queue[1:]
+			c.inflight = append(c.inflight, msg)
+			if len(c.group.queue) > 0 {
+				c.group.signal()
+			}
+			c.bus.mutex.Unlock()
+			return msg, nil
+		}
+		c.bus.mutex.Unlock()
+		select {
+		case <-c.group.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *memoryConsumer) CommitMessage(_ context.Context, msg *Message) error {
+	c.bus.mutex.Lock()
+	defer c.bus.mutex.Unlock()
+	for i, inflight := range c.inflight {
+		if inflight == msg {
+			c.inflight = append(c.inflight[:i], c.inflight[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (c *memoryConsumer) Topic() string {
+	return c.topic
+}
+
+func (c *memoryConsumer) System() string {
+	return "memory"
+}
+
+func (c *memoryConsumer) Lag() int64 {
+	c.bus.mutex.Lock()
+	defer c.bus.mutex.Unlock()
+	return int64(len(c.group.queue) + len(c.inflight))
+}
+
+func (c *memoryConsumer) Close() error {
+	c.bus.mutex.Lock()
+	defer c.bus.mutex.Unlock()
+	if len(c.inflight) == 0 {
+		return nil
+	}
+	c.group.queue = append(c.inflight, c.group.queue...)
+	c.inflight = nil
+	c.group.signal()
+	return nil
+}
@@ -0,0 +1,151 @@
+package bus_service
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"os"
+	"strings"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/trace_service"
+)
+
+const (
+	// streamBatch 单次读取或接管的最大消息数
+	streamBatch = 16
+	// streamBlock 单次阻塞读取的最长时间
+	streamBlock = time.Second
+	// streamHeaderPrefix 消息头在 stream 字段中的前缀
+	streamHeaderPrefix = "h:"
+)
+
+// streamProducer 以 Redis Streams 实现 Producer，XADD 返回即已写入，因此总是同步投递
+type streamProducer struct {
+	redis  *dbservice.RedisDict
+	topic  string
+	maxLen int64
+}
+
+func newStreamProducer(busCfg *db_config_model.BusConfigModel, rdb *dbservice.RedisDict, topic string) Producer {
+	return &streamProducer{redis: rdb.WithPrefix(busCfg.StreamPrefix), topic: topic, maxLen: int64(busCfg.StreamMaxLen)}
+}
+
+func (p *streamProducer) SendKeyedMessage(ctx context.Context, key, message string) (err error) {
+	span, headers := startProduceSpan(ctx, "redis", p.topic)
+	defer func() { trace_service.EndSpan(span, err) }()
+	values := map[string]interface{}{"key": key, "value": message}
+	for name, value := range headers {
+		values[streamHeaderPrefix+name] = value
+	}
+	_, err = p.redis.StreamAdd(ctx, p.topic, p.maxLen, values)
+	return err
+}
+
+func (p *streamProducer) Sync() bool {
+	return true
+}
+
+func (p *streamProducer) Close() error {
+	return nil
+}
+
+// streamConsumer 以 Redis Streams 消费组实现 Consumer：确认即 XACK，
+// 组内超过 claimIdle 未确认的消息（包括已退出的消费者遗留的）由存活的消费者接管重投
+type streamConsumer struct {
+	redis     *dbservice.RedisDict
+	topic     string
+	group     string
+	name      string
+	claimIdle time.Duration
+	lastClaim time.Time
+	buffer    []redis.XMessage
+}
+
+func newStreamConsumer(
+	busCfg *db_config_model.BusConfigModel, rdb *dbservice.RedisDict, topic, group string,
+) (Consumer, error) {
+	redisStream := rdb.WithPrefix(busCfg.StreamPrefix)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisStream.StreamEnsureGroup(ctx, topic, group); err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &streamConsumer{
+		redis:     redisStream,
+		topic:     topic,
+		group:     group,
+		name:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		claimIdle: time.Duration(busCfg.ClaimIdleMs) * time.Millisecond,
+	}, nil
+}
+
+func (c *streamConsumer) FetchMessage(ctx context.Context) (*Message, error) {
+	if len(c.buffer) == 0 && time.Since(c.lastClaim) >= c.claimIdle {
+		c.lastClaim = time.Now()
+		claimed, err := c.redis.StreamClaim(ctx, c.topic, c.group, c.name, c.claimIdle, streamBatch)
+		if err != nil {
+			return nil, err
+		}
+		c.buffer = claimed
+	}
+	if len(c.buffer) == 0 {
+		block := streamBlock
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < block {
+			// BLOCK 0 表示无限阻塞，剩余时间不足时直接按超时返回
+			if block = time.Until(deadline); block < time.Millisecond {
+				return nil, context.DeadlineExceeded
+			}
+		}
+		messages, err := c.redis.StreamReadGroup(ctx, c.topic, c.group, c.name, streamBatch, block)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) == 0 {
+			return nil, context.DeadlineExceeded
+		}
+		c.buffer = messages
+	}
+	msg := c.buffer[0]
+	c.buffer = c.buffer[1:]
+	busMsg := &Message{Headers: map[string]string{}, ack: msg.ID}
+	for name, value := range msg.Values {
+		text, _ := value.(string)
+		switch {
+		case name == "key":
+			busMsg.Key = text
+		case name == "value":
+			busMsg.Value = text
+		case strings.HasPrefix(name, streamHeaderPrefix):
+			busMsg.Headers[strings.TrimPrefix(name, streamHeaderPrefix)] = text
+		}
+	}
+	return busMsg, nil
+}
+
+func (c *streamConsumer) CommitMessage(ctx context.Context, msg *Message) error {
+	return c.redis.StreamAck(ctx, c.topic, c.group, msg.ack.(string))
+}
+
+func (c *streamConsumer) Topic() string {
+	return c.topic
+}
+
+func (c *streamConsumer) System() string {
+	return "redis"
+}
+
+func (c *streamConsumer) Lag() int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lag, _ := c.redis.StreamLag(ctx, c.topic, c.group)
+	return lag
+}
+
+// Close 关闭消费者，缓冲中未处理的消息保持未确认状态，超过 claimIdle 后由同组消费者接管
+func (c *streamConsumer) Close() error {
+	c.buffer = nil
+	return nil
+}
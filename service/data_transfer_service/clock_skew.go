@@ -0,0 +1,38 @@
+package data_transfer_service
+
+import (
+	"log/slog"
+	"math"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// ClockSkew 按服务端接收时间与上报时间之差累计每架飞行器的时钟偏差，与链路质量统计保存在同一 HASH 中；
+// 正值表示上报时间早于接收时间（飞行器时钟偏慢或传输延迟），负值表示飞行器时钟偏快
+type ClockSkew struct {
+	RedisService repository_service.SkewStore
+}
+
+// NewClockSkew 由带 DedupPrefix 前缀的 Redis 创建时钟偏差统计
+func NewClockSkew(redisLink repository_service.SkewStore) *ClockSkew {
+	return &ClockSkew{RedisService: redisLink}
+}
+
+// Record 累计一条状态的时钟偏差；s 为 nil 或消息未携带 ReceiveTime（升级前上传的消息）时不记录
+func (s *ClockSkew) Record(logger *slog.Logger, status *data_flow_model.AircraftStatus) {
+	if s == nil || status.ReceiveTime == "" {
+		return
+	}
+	receiveTime, receiveErr := utils.ParseSqlTimeStr(status.ReceiveTime)
+	statusTime, statusErr := utils.ParseSqlTimeStr(status.TimeString)
+	if receiveErr != nil || statusErr != nil {
+		return
+	}
+	skew := receiveTime.Sub(statusTime)
+	metrics_service.ClockSkew.Observe(math.Abs(skew.Seconds()))
+	if err := s.RedisService.RecordClockSkew(data_flow_model.LinkStatsKey(status.AircraftID), skew.Milliseconds()); err != nil {
+		logger.Warn("failed to record clock skew", "error", err)
+	}
+}
@@ -0,0 +1,93 @@
+package data_transfer_service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"strconv"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/trace_service"
+)
+
+// Deduplicator 在 Redis 中按飞行器维护有界的已处理消息窗口，过滤弱网重传造成的重复消息。
+// 各转发服务使用独立的窗口，互不影响
+type Deduplicator struct {
+	RedisService *dbservice.RedisDict
+	Service      string
+	Window       time.Duration
+	MaxEntries   int
+	// LinkStats 为 true 时由该服务累计每架飞行器的链路质量统计
+	LinkStats bool
+}
+
+// NewDeduplicator 按配置创建去重器，窗口保存在 redisDedup（带 DedupPrefix 前缀）中；
+// DedupWindowSec 为 0 时返回 nil（不去重）
+func NewDeduplicator(
+	service string, redisDedup *dbservice.RedisDict,
+	PipelineConfig *db_config_model.PipelineConfigModel, linkStats bool,
+) *Deduplicator {
+	if PipelineConfig.DedupWindowSec <= 0 {
+		return nil
+	}
+	return &Deduplicator{
+		RedisService: redisDedup,
+		Service:      service,
+		Window:       time.Duration(PipelineConfig.DedupWindowSec) * time.Second,
+		MaxEntries:   PipelineConfig.DedupMaxEntries,
+		LinkStats:    linkStats,
+	}
+}
+
+// Seen 登记一条消息，返回其是否已在窗口内处理过；d 为 nil 时不去重
+func (d *Deduplicator) Seen(ctx context.Context, stream string, AircraftID int, dedupKey string, seq *int64) (bool, error) {
+	if d == nil {
+		return false, nil
+	}
+	_, span := trace_service.StartSpan(ctx, "redis.dedup", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	result, err := d.RedisService.MarkSeen(
+		d.scope(stream, AircraftID), dedupKey, seq, d.Window, d.MaxEntries, d.statsKey(stream, AircraftID),
+	)
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		return false, err
+	}
+	if result.Duplicate {
+		metrics_service.PipelineDuplicates.WithLabelValues(d.Service, stream).Inc()
+	}
+	if result.Gap > 0 {
+		metrics_service.LinkSequenceGaps.WithLabelValues(d.Service, stream).Add(float64(result.Gap))
+	}
+	return result.Duplicate, nil
+}
+
+// Forget 撤销 Seen 对一条消息的登记，用于暂时性失败后将要重试的消息，避免重试时被当作重复丢弃；
+// d 为 nil 时不做任何事。撤销失败时重试的消息会被丢弃，记录错误
+func (d *Deduplicator) Forget(ctx context.Context, msgLogger *slog.Logger, stream string, AircraftID int, dedupKey string) {
+	if d == nil {
+		return
+	}
+	_, span := trace_service.StartSpan(ctx, "redis.dedup_forget", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	err := d.RedisService.UnmarkSeen(d.scope(stream, AircraftID), dedupKey, d.statsKey(stream, AircraftID))
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		msgLogger.Error("failed to forget dedup entry, retried msg may be dropped", "dedup_key", dedupKey, "error", err)
+	}
+}
+
+// scope 返回飞行器在 stream 上的去重窗口，{AircraftID} 为 hash tag
+func (d *Deduplicator) scope(stream string, AircraftID int) string {
+	return d.Service + ":" + stream + ":{" + strconv.Itoa(AircraftID) + "}"
+}
+
+// statsKey 返回链路统计的 key，不由该服务累计时为空
+func (d *Deduplicator) statsKey(stream string, AircraftID int) string {
+	if d.LinkStats && stream == "status" {
+		return data_flow_model.LinkStatsKey(AircraftID)
+	}
+	return ""
+}
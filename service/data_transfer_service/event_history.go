@@ -0,0 +1,55 @@
+package data_transfer_service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/trace_service"
+	"uam-power-backend/utils"
+)
+
+// EventHistory 在 Redis 中按飞行器及全局保留有界的最近事件，按事件时间排序，
+// 乱序晚到的事件也会落到正确的位置
+type EventHistory struct {
+	RedisService repository_service.HistoryStore
+	Size         int
+	GlobalSize   int
+	TTL          time.Duration
+}
+
+// NewEventHistory 按配置创建事件历史，EventHistorySize 为 0 时返回 nil（不保留历史）
+func NewEventHistory(redisEvent repository_service.HistoryStore, PipelineConfig *db_config_model.PipelineConfigModel) *EventHistory {
+	if PipelineConfig.EventHistorySize <= 0 {
+		return nil
+	}
+	return &EventHistory{
+		RedisService: redisEvent,
+		Size:         PipelineConfig.EventHistorySize,
+		GlobalSize:   PipelineConfig.EventGlobalHistorySize,
+		TTL:          time.Duration(PipelineConfig.EventHistoryTTLSec) * time.Second,
+	}
+}
+
+// Add 记录一条事件；h 为 nil 时不记录
+func (h *EventHistory) Add(ctx context.Context, event *data_flow_model.AircraftEvent, value string) error {
+	if h == nil {
+		return nil
+	}
+	eventTime, err := utils.ParseSqlTimeStr(event.TimeString)
+	if err != nil {
+		return err
+	}
+	keys := []dbservice.HistoryKey{{Key: data_flow_model.EventHistoryKey(event.AircraftID), MaxEntries: h.Size}}
+	if h.GlobalSize > 0 {
+		keys = append(keys, dbservice.HistoryKey{Key: data_flow_model.GlobalEventHistoryKey, MaxEntries: h.GlobalSize})
+	}
+	_, span := trace_service.StartSpan(ctx, "redis.event_history", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	err = h.RedisService.AddHistory(value, float64(eventTime.UnixMilli()), h.TTL, keys...)
+	trace_service.EndSpan(span, err)
+	return err
+}
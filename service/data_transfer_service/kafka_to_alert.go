@@ -0,0 +1,232 @@
+package data_transfer_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/webhook_service"
+	"uam-power-backend/utils"
+)
+
+// KafkaToAlert 消费事件 topic，将 AlertCfg 中配置的事件生成告警，并定期升级超时未确认的告警。
+// 同一事件重投时由告警表的唯一键去重；升级以条件更新实现，多个实例同时检查时每次只升级一次
+type KafkaToAlert struct {
+	KafkaEventConsumerService bus_service.Consumer
+	Alerts                    repository_service.AlertRepository
+	// Webhooks 每次升级向订阅了 ALERT_ESCALATED 的 webhook 发送通知，为 nil（未开启 webhook）时只记录日志
+	Webhooks *webhook_service.Dispatcher
+	// EventTypes/MinSeverity 生成告警的事件类型与最低严重级别（-1 表示不按级别生成）
+	EventTypes         map[string]bool
+	MinSeverity        int
+	EscalateAfter      time.Duration
+	MaxEscalationLevel int
+	CheckInterval      time.Duration
+	EventHeartbeat     *health_service.Heartbeat
+	StopFlag           atomic.Bool
+	EventDone          chan bool
+	EscalationDone     chan bool
+	// stop 关闭后升级检查立即退出，不等待下一个周期（周期通常为数十秒），重试中的消息也立即放弃退避等待
+	stop chan struct{}
+}
+
+// NewKafkaToAlert 使用 app 中共享的系统库连接池与 webhook 投递服务创建告警服务，AlertCfg.Enable 为 false 时返回 nil
+func NewKafkaToAlert(app *app_service.Container) (*KafkaToAlert, error) {
+	if !app.Config.AlertCfg.Enable {
+		return nil, nil
+	}
+	kafkaEvent, err := app.NewConsumer(app.Config.KafkaCfg.AircraftEventTopic, "KafkaToAlert")
+	if err != nil {
+		return nil, err
+	}
+	utils.MsgSuccess("        [KafkaToAlert]Successfully init!")
+	return NewKafkaToAlertFromStores(kafkaEvent, repository_service.NewMySQLAlertRepository(app.SystemDB), app.Webhooks,
+		&app.Config.AlertCfg), nil
+}
+
+// NewKafkaToAlertFromStores 由消费者、告警存储与 webhook 投递服务（可为 nil）组装告警服务并登记积压指标与存活检查，
+// 测试中可传入内存实现
+func NewKafkaToAlertFromStores(
+	eventConsumer bus_service.Consumer, alerts repository_service.AlertRepository, webhooks *webhook_service.Dispatcher,
+	AlertConfig *db_config_model.AlertConfigModel,
+) *KafkaToAlert {
+	metrics_service.RegisterKafkaConsumer(eventConsumer.Topic(), "KafkaToAlert", eventConsumer.Lag)
+	eventHeartbeat := health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:KafkaToAlert/event", eventHeartbeat.Check(heartbeatMaxAge))
+	eventTypes := make(map[string]bool, len(AlertConfig.EventTypes))
+	for _, code := range AlertConfig.EventTypes {
+		eventTypes[code] = true
+	}
+	minSeverity := -1
+	if AlertConfig.MinSeverity != "" {
+		minSeverity = event_catalog_model.SeverityRank(AlertConfig.MinSeverity)
+	}
+	return &KafkaToAlert{
+		KafkaEventConsumerService: eventConsumer,
+		Alerts:                    alerts,
+		Webhooks:                  webhooks,
+		EventTypes:                eventTypes,
+		MinSeverity:               minSeverity,
+		EscalateAfter:             time.Duration(AlertConfig.EscalateAfterSec) * time.Second,
+		MaxEscalationLevel:        AlertConfig.MaxEscalationLevel,
+		CheckInterval:             time.Duration(AlertConfig.CheckIntervalSec) * time.Second,
+		EventHeartbeat:            eventHeartbeat,
+		EventDone:                 make(chan bool),
+		EscalationDone:            make(chan bool),
+		stop:                      make(chan struct{}),
+	}
+}
+
+func (ser *KafkaToAlert) KafkaEventToAlert() {
+	logger := utils.ComponentLogger("KafkaToAlert").With("stream", "event")
+	logger.Info("start KafkaEventToAlert successfully!")
+	for !ser.StopFlag.Load() {
+		ser.EventHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaEventConsumerService.FetchMessage(ctx)
+		cancel()
+		if err != nil {
+			logReceiveError(logger, err)
+			continue
+		}
+		// 告警写入失败时不确认，重试直到写入告警表
+		consumeMessage("KafkaToAlert", "event", logger, ser.KafkaEventConsumerService, KafkaRe, ser.handleEvent,
+			ser.EventHeartbeat, ser.stop)
+	}
+	ser.EventDone <- true
+}
+
+// severityOf 返回事件的严重级别，未携带时（如旧版本发出的事件）按内置事件类型补齐
+func severityOf(event *data_flow_model.AircraftEvent) string {
+	if event.Severity != "" {
+		return event.Severity
+	}
+	return event_catalog_model.BuiltinSeverity(event.Event)
+}
+
+// Matches 判断事件是否需要生成告警
+func (ser *KafkaToAlert) Matches(event *data_flow_model.AircraftEvent) bool {
+	if ser.EventTypes[event.Event] {
+		return true
+	}
+	return ser.MinSeverity >= 0 && event_catalog_model.SeverityRank(severityOf(event)) >= ser.MinSeverity
+}
+
+func (ser *KafkaToAlert) handleEvent(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	var reStruct data_flow_model.AircraftEvent
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	if !ser.Matches(&reStruct) {
+		return "", nil
+	}
+	msgLogger = msgLogger.With("aircraft_id", reStruct.AircraftID, "event", reStruct.Event)
+	eventTime, err := utils.ParseSqlTimeStr(reStruct.TimeString)
+	if err != nil {
+		msgLogger.Error("invalid event time", "error", err)
+		return "invalid_time", err
+	}
+	alert := alert_model.Alert{
+		AircraftID: reStruct.AircraftID, Event: reStruct.Event, Severity: severityOf(&reStruct),
+		EventTime: eventTime, Payload: reStruct.Payload,
+	}
+	created, err := ser.Alerts.CreateAlert(ctx, &alert)
+	if err != nil {
+		msgLogger.Error("failed to create alert", "error", err)
+		return "mysql_error", err
+	}
+	if created {
+		metrics_service.AlertTransitions.WithLabelValues(alert_model.ActionOpen).Inc()
+		msgLogger.Warn("alert opened", "alert_id", alert.AlertID, "severity", alert.Severity)
+	}
+	return "", nil
+}
+
+// RunEscalation 每隔 CheckInterval 检查一次升级，直到 Stop
+func (ser *KafkaToAlert) RunEscalation() {
+	logger := utils.ComponentLogger("KafkaToAlert").With("stream", "escalation")
+	ticker := time.NewTicker(ser.CheckInterval)
+	defer ticker.Stop()
+	for !ser.StopFlag.Load() {
+		ser.CheckEscalation(context.Background(), logger)
+		select {
+		case <-ticker.C:
+		case <-ser.stop:
+		}
+	}
+	ser.EscalationDone <- true
+}
+
+// CheckEscalation 将 open 状态的告警按创建后经过的时长升级：每满 EscalateAfter 升一级，至多 MaxEscalationLevel 级。
+// 每次升级发送一条 ALERT_ESCALATED 通知；通知写入失败时只记录日志，不回滚已提交的升级
+func (ser *KafkaToAlert) CheckEscalation(ctx context.Context, logger *slog.Logger) {
+	alerts, err := ser.Alerts.ListAlerts(ctx, &alert_model.ListAlertsRequest{Status: alert_model.StatusOpen})
+	if err != nil {
+		logger.Error("failed to list open alerts", "error", err)
+		return
+	}
+	now := time.Now()
+	for _, alert := range alerts {
+		due := int(now.Sub(alert.CreateTime) / ser.EscalateAfter)
+		if due > ser.MaxEscalationLevel {
+			due = ser.MaxEscalationLevel
+		}
+		for level := alert.EscalationLevel; level < due; level++ {
+			escalated, err := ser.Alerts.EscalateAlert(ctx, alert.AlertID, level)
+			if err != nil {
+				logger.Error("failed to escalate alert", "alert_id", alert.AlertID, "error", err)
+				break
+			}
+			if !escalated {
+				break
+			}
+			metrics_service.AlertTransitions.WithLabelValues(alert_model.ActionEscalate).Inc()
+			logger.Warn("alert escalated", "alert_id", alert.AlertID, "aircraft_id", alert.AircraftID,
+				"event", alert.Event, "level", level+1)
+			alert.EscalationLevel = level + 1
+			// 通知 ID 由告警与升级后的级别确定，多个实例不会重复通知同一次升级
+			id := fmt.Sprintf("alert:%d:escalate:%d", alert.AlertID, alert.EscalationLevel)
+			if err = ser.Webhooks.Notify(ctx, webhook_model.TypeAlertEscalated, id, &alert); err != nil {
+				logger.Error("failed to enqueue escalation notification", "alert_id", alert.AlertID, "error", err)
+			}
+		}
+	}
+}
+
+// Start 启动事件消费与升级检查；ser 为 nil（未开启告警）时不做任何事
+func (ser *KafkaToAlert) Start() {
+	if ser == nil {
+		return
+	}
+	go ser.KafkaEventToAlert()
+	if ser.EscalateAfter > 0 {
+		go ser.RunEscalation()
+	}
+}
+
+// Stop 停止消费与升级检查并等待当前处理结束；ser 为 nil 时不做任何事
+func (ser *KafkaToAlert) Stop() {
+	if ser == nil {
+		return
+	}
+	ser.StopFlag.Store(true)
+	close(ser.stop)
+	<-ser.EventDone
+	if ser.EscalateAfter > 0 {
+		<-ser.EscalationDone
+	}
+}
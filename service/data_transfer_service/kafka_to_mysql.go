@@ -3,148 +3,235 @@ package data_transfer_service
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"strconv"
+	"sync/atomic"
 	"time"
-	"uam-power-backend/models/config_models/db_config_model"
 	"uam-power-backend/models/controller_models/aircraft_task_model"
 	"uam-power-backend/models/controller_models/data_flow_model"
-	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/trace_service"
 	"uam-power-backend/utils"
 )
 
 type KafkaToMysql struct {
-	KafkaEventConsumerService  *dbservice.KafkaConsumer
-	KafkaStatusConsumerService *dbservice.KafkaConsumer
-	MysqlStatusService         *dbservice.MySQLService
-	MysqlEventService          *dbservice.MySQLService
-	RedisService               *dbservice.RedisDict
-	StopFlag                   bool
-	StatusDone                 chan bool
-	EventDone                  chan bool
+	// 消费者按 BusCfg.Backend 可为 Kafka、Redis Streams 或进程内总线
+	KafkaEventConsumerService  bus_service.Consumer
+	KafkaStatusConsumerService bus_service.Consumer
+	Telemetry                  repository_service.TelemetryStore
+	// RedisService 进行中的任务，按 AircraftID 保存
+	RedisService    repository_service.KVStore
+	Dedup           *Deduplicator
+	StatusHeartbeat *health_service.Heartbeat
+	EventHeartbeat  *health_service.Heartbeat
+	StopFlag        atomic.Bool
+	StatusDone      chan bool
+	EventDone       chan bool
+	// stop 关闭后重试中的消息立即放弃退避等待
+	stop chan struct{}
 }
 
-func NewKafkaToMysql(
-	KafkaConfig *db_config_model.KafkaConfigModel,
-	MySqlConfig *db_config_model.MySqlConfigModel,
-	RedisConfig *db_config_model.RedisConfigModel,
-) *KafkaToMysql {
-	kafkaStatus := dbservice.NewKafkaConsumer(KafkaConfig.Addr, KafkaConfig.AircraftDataTopic, "KafkaToMysql")
-	kafkaEvent := dbservice.NewKafkaConsumer(KafkaConfig.Addr, KafkaConfig.AircraftEventTopic, "KafkaToMysql")
-	mysqlLink := fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		MySqlConfig.Usr, MySqlConfig.Psw, MySqlConfig.Host, MySqlConfig.Port,
-		MySqlConfig.FlightDB,
-	)
-	FlightMysqlService, FlightErr := dbservice.NewMySQLService(mysqlLink)
-	if FlightErr != nil {
-		return nil
-	}
-	mysqlLink = fmt.Sprintf(
-		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		MySqlConfig.Usr, MySqlConfig.Psw, MySqlConfig.Host, MySqlConfig.Port,
-		MySqlConfig.EventDB,
-	)
-	EventMysqlService, EventErr := dbservice.NewMySQLService(mysqlLink)
-	if EventErr != nil {
-		return nil
-	}
-	RedisInfo := dbservice.NewRedisDict(RedisConfig.Host, RedisConfig.Port, RedisConfig.TaskInfoDBno)
+// NewKafkaToMysql 使用 app 中共享的 MySQL 连接池与 Redis 客户端创建转发服务，消费者创建失败时返回错误
+func NewKafkaToMysql(app *app_service.Container) (*KafkaToMysql, error) {
+	kafkaCfg, redisCfg := &app.Config.KafkaCfg, &app.Config.RedisCfg
+	kafkaStatus, err := app.NewConsumer(kafkaCfg.AircraftDataTopic, "KafkaToMysql")
+	if err != nil {
+		return nil, err
+	}
+	kafkaEvent, err := app.NewConsumer(kafkaCfg.AircraftEventTopic, "KafkaToMysql")
+	if err != nil {
+		return nil, err
+	}
+	dedup := NewDeduplicator("KafkaToMysql", app.Redis.WithPrefix(redisCfg.DedupPrefix), &app.Config.PipelineCfg, false)
 	utils.MsgSuccess("        [KafkaToMysql]Successfully init!")
+	return NewKafkaToMysqlFromStores(
+		kafkaStatus, kafkaEvent, repository_service.NewMySQLTelemetryStore(app.FlightDB, app.EventDB),
+		app.Redis.WithPrefix(redisCfg.TaskInfoPrefix), dedup,
+	), nil
+}
+
+// NewKafkaToMysqlFromStores 由消费者、轨迹存储与任务缓存组装转发服务并登记积压指标与存活检查，
+// 测试中可传入 memory 总线与内存实现；dedup 为 nil 时不去重
+func NewKafkaToMysqlFromStores(
+	statusConsumer, eventConsumer bus_service.Consumer,
+	telemetry repository_service.TelemetryStore, tasks repository_service.KVStore, dedup *Deduplicator,
+) *KafkaToMysql {
+	metrics_service.RegisterKafkaConsumer(statusConsumer.Topic(), "KafkaToMysql", statusConsumer.Lag)
+	metrics_service.RegisterKafkaConsumer(eventConsumer.Topic(), "KafkaToMysql", eventConsumer.Lag)
+	statusHeartbeat, eventHeartbeat := health_service.NewHeartbeat(), health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:KafkaToMysql/status", statusHeartbeat.Check(heartbeatMaxAge))
+	health_service.RegisterLiveness("transfer:KafkaToMysql/event", eventHeartbeat.Check(heartbeatMaxAge))
 	return &KafkaToMysql{
-		KafkaEventConsumerService:  kafkaEvent,
-		KafkaStatusConsumerService: kafkaStatus,
-		MysqlStatusService:         FlightMysqlService,
-		MysqlEventService:          EventMysqlService,
-		RedisService:               RedisInfo,
+		KafkaEventConsumerService:  eventConsumer,
+		KafkaStatusConsumerService: statusConsumer,
+		Telemetry:                  telemetry,
+		RedisService:               tasks,
+		Dedup:                      dedup,
+		StatusHeartbeat:            statusHeartbeat,
+		EventHeartbeat:             eventHeartbeat,
 		StatusDone:                 make(chan bool),
 		EventDone:                  make(chan bool),
-		StopFlag:                   false,
+		stop:                       make(chan struct{}),
 	}
 }
 
 func (ser *KafkaToMysql) KafkaStatusToMysql() {
-	for !ser.StopFlag {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-		KafkaRe, err := ser.KafkaStatusConsumerService.ReceiveMessage(ctx)
-		if err != nil {
-			utils.MsgError("        [KafkaToMysql]receive msg error >" + err.Error())
-			continue
-		}
-
-		var reStruct data_flow_model.AircraftStatus
-		err = json.Unmarshal([]byte(KafkaRe), &reStruct)
-		if err != nil {
-			utils.MsgError("        [KafkaToMysql]invalid json")
-			continue
-		}
-		re, redisErr := ser.RedisService.Get(strconv.Itoa(reStruct.AircraftID))
-		if redisErr != nil {
-			utils.MsgError("        [KafkaToMysql]Can not hit redis!")
-			continue
-		}
-		if re == nil {
-			utils.MsgError("        [KafkaToMysql]Can not hit redis!")
-			continue
-		}
-		jsonData, _ := json.Marshal(re)
-		var mysqlData aircraft_task_model.MysqlAircraftTask
-		err = json.Unmarshal(jsonData, &mysqlData)
-		if err != nil {
-			utils.MsgError("        [KafkaToMysql]Invalid Json!")
-			continue
-		}
-		sql := fmt.Sprintf("INSERT INTO flightdb.%s (Longitude, Latitude, Altitude, Yaw, DataTime) VALUES (%f, %f, %f, %f, '%s');",
-			mysqlData.TrackTable, reStruct.Longitude, reStruct.Latitude, reStruct.Altitude, reStruct.Yaw,
-			reStruct.TimeString,
-		)
-		utils.MsgInfo("SQL>" + sql)
-		_, err = ser.MysqlStatusService.ExecuteCmd(sql)
+	logger := utils.ComponentLogger("KafkaToMysql").With("stream", "status")
+	logger.Info("start KafkaStatusToMysql successfully!")
+	for !ser.StopFlag.Load() {
+		ser.StatusHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaStatusConsumerService.FetchMessage(ctx)
+		cancel()
 		if err != nil {
-			utils.MsgError("        [KafkaToMysql]Can not insert! err>" + err.Error())
+			logReceiveError(logger, err)
 			continue
 		}
+		consumeMessage("KafkaToMysql", "status", logger, ser.KafkaStatusConsumerService, KafkaRe, ser.handleStatus,
+			ser.StatusHeartbeat, ser.stop)
 	}
 	ser.StatusDone <- true
 }
 
+func (ser *KafkaToMysql) handleStatus(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	var reStruct data_flow_model.AircraftStatus
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	msgLogger = msgLogger.With("aircraft_id", reStruct.AircraftID)
+	if duplicate, dedupErr := ser.Dedup.Seen(ctx, "status", reStruct.AircraftID, reStruct.DedupKey(), reStruct.Seq); dedupErr != nil {
+		// 去重失败时宁可重复写入也不丢数据
+		msgLogger.Warn("dedup check failed, processing anyway", "error", dedupErr)
+	} else if duplicate {
+		msgLogger.Debug("duplicate msg dropped", "dedup_key", reStruct.DedupKey())
+		return "", nil
+	}
+	reason, err := ser.insertStatus(ctx, msgLogger, &reStruct)
+	if isTransient(reason) {
+		ser.Dedup.Forget(ctx, msgLogger, "status", reStruct.AircraftID, reStruct.DedupKey())
+	}
+	return reason, err
+}
+
+// insertStatus 将状态写入飞行器当前任务的轨迹表
+func (ser *KafkaToMysql) insertStatus(
+	ctx context.Context, msgLogger *slog.Logger, reStruct *data_flow_model.AircraftStatus,
+) (string, error) {
+	mysqlData, reason, err := ser.lookupTask(ctx, msgLogger, reStruct.AircraftID)
+	if err != nil {
+		return reason, err
+	}
+	msgLogger = msgLogger.With("task_id", mysqlData.TaskID)
+	_, span := trace_service.StartSpan(ctx, "mysql.insert", trace.SpanKindClient,
+		attribute.String("db.system", "mysql"), attribute.String("db.sql.table", mysqlData.TrackTable))
+	err = ser.Telemetry.InsertStatus(ctx, mysqlData, reStruct)
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		msgLogger.Error("Can not insert!", "error", err)
+		return "mysql_error", err
+	}
+	return "", nil
+}
+
 func (ser *KafkaToMysql) KafkaEventToMysql() {
-	for !ser.StopFlag {
-		utils.MsgSuccess("        [KafkaToMysql]start KafkaEventToMysql successfully!")
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-		KafkaRe, err := ser.KafkaEventConsumerService.ReceiveMessage(ctx)
+	logger := utils.ComponentLogger("KafkaToMysql").With("stream", "event")
+	logger.Info("start KafkaEventToMysql successfully!")
+	for !ser.StopFlag.Load() {
+		ser.EventHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaEventConsumerService.FetchMessage(ctx)
+		cancel()
 		if err != nil {
-			utils.MsgError("        [KafkaToMysql]KafkaEventToMysql receive msg error")
-			continue
-		}
-		var reStruct data_flow_model.AircraftEvent
-		err = json.Unmarshal([]byte(KafkaRe), &reStruct)
-		if err != nil {
-			utils.MsgError("        [KafkaToMysql]KafkaEventToMysql invalid json")
-			continue
-		}
-		re, redisErr := ser.RedisService.Get(strconv.Itoa(reStruct.AircraftID))
-		if redisErr != nil {
-			utils.MsgError("        [KafkaToMysql]KafkaEventToMysql failed to hit!")
-			continue
-		}
-		jsonData, _ := json.Marshal(re)
-		var mysqlData aircraft_task_model.MysqlAircraftTask
-		err = json.Unmarshal(jsonData, &mysqlData)
-		_, err = ser.MysqlEventService.ExecuteCmd(
-			fmt.Sprintf("INSERT INTO eventdb.%s(DataTime, Event) VALUES ('%s', '%s')",
-				mysqlData.EventTable, reStruct.TimeString, reStruct.Event,
-			))
-		if err != nil {
-			utils.MsgError("        [KafkaToMysql]KafkaEventToMysql failed to insert!")
+			logReceiveError(logger, err)
 			continue
 		}
+		consumeMessage("KafkaToMysql", "event", logger, ser.KafkaEventConsumerService, KafkaRe, ser.handleEvent,
+			ser.EventHeartbeat, ser.stop)
 	}
 	ser.EventDone <- true
 }
 
+func (ser *KafkaToMysql) handleEvent(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	var reStruct data_flow_model.AircraftEvent
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	msgLogger = msgLogger.With("aircraft_id", reStruct.AircraftID)
+	if duplicate, dedupErr := ser.Dedup.Seen(ctx, "event", reStruct.AircraftID, reStruct.DedupKey(), reStruct.Seq); dedupErr != nil {
+		// 去重失败时宁可重复写入也不丢数据
+		msgLogger.Warn("dedup check failed, processing anyway", "error", dedupErr)
+	} else if duplicate {
+		msgLogger.Debug("duplicate msg dropped", "dedup_key", reStruct.DedupKey())
+		return "", nil
+	}
+	reason, err := ser.insertEvent(ctx, msgLogger, &reStruct)
+	if isTransient(reason) {
+		ser.Dedup.Forget(ctx, msgLogger, "event", reStruct.AircraftID, reStruct.DedupKey())
+	}
+	return reason, err
+}
+
+// insertEvent 将事件写入飞行器当前任务的事件表
+func (ser *KafkaToMysql) insertEvent(
+	ctx context.Context, msgLogger *slog.Logger, reStruct *data_flow_model.AircraftEvent,
+) (string, error) {
+	mysqlData, reason, err := ser.lookupTask(ctx, msgLogger, reStruct.AircraftID)
+	if err != nil {
+		return reason, err
+	}
+	msgLogger = msgLogger.With("task_id", mysqlData.TaskID)
+	_, span := trace_service.StartSpan(ctx, "mysql.insert", trace.SpanKindClient,
+		attribute.String("db.system", "mysql"), attribute.String("db.sql.table", mysqlData.EventTable))
+	err = ser.Telemetry.InsertEvent(ctx, mysqlData, reStruct)
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		msgLogger.Error("failed to insert!", "error", err)
+		return "mysql_error", err
+	}
+	return "", nil
+}
+
+// lookupTask 从任务 Redis 中查找飞行器当前任务，失败时返回失败原因
+func (ser *KafkaToMysql) lookupTask(
+	ctx context.Context, msgLogger *slog.Logger, AircraftID int,
+) (*aircraft_task_model.MysqlAircraftTask, string, error) {
+	_, span := trace_service.StartSpan(ctx, "redis.get", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	re, redisErr := ser.RedisService.Get(strconv.Itoa(AircraftID))
+	metrics_service.ObserveRedisLookup("KafkaToMysql", re, redisErr)
+	if redisErr == nil && re == nil {
+		redisErr = errors.New("no running task")
+		trace_service.EndSpan(span, redisErr)
+		msgLogger.Error("Can not hit redis!")
+		return nil, "task_not_found", redisErr
+	}
+	trace_service.EndSpan(span, redisErr)
+	if redisErr != nil {
+		msgLogger.Error("Can not hit redis!", "error", redisErr)
+		return nil, "redis_error", redisErr
+	}
+	jsonData, _ := json.Marshal(re)
+	var mysqlData aircraft_task_model.MysqlAircraftTask
+	if err := json.Unmarshal(jsonData, &mysqlData); err != nil {
+		msgLogger.Error("Invalid Json!", "error", err)
+		return nil, "invalid_task", err
+	}
+	return &mysqlData, "", nil
+}
+
 func (ser *KafkaToMysql) Stop() {
-	ser.StopFlag = true
+	ser.StopFlag.Store(true)
+	close(ser.stop)
 	<-ser.StatusDone
 	<-ser.EventDone
 }
@@ -3,99 +3,244 @@ package data_transfer_service
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"strconv"
+	"sync/atomic"
 	"time"
-	"uam-power-backend/models/config_models/db_config_model"
 	"uam-power-backend/models/controller_models/data_flow_model"
-	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/trace_service"
 	"uam-power-backend/utils"
 )
 
 type KafkaToRedis struct {
-	KafkaEventConsumerService  *dbservice.KafkaConsumer
-	KafkaStatusConsumerService *dbservice.KafkaConsumer
-	RedisStatusService         *dbservice.RedisDict
-	RedisEventService          *dbservice.RedisDict
-	StopFlag                   bool
-	StatusDone                 chan bool
-	EventDone                  chan bool
+	// 消费者按 BusCfg.Backend 可为 Kafka、Redis Streams 或进程内总线
+	KafkaEventConsumerService  bus_service.Consumer
+	KafkaStatusConsumerService bus_service.Consumer
+	RedisStatusService         repository_service.LatestStore
+	RedisEventService          repository_service.EventStore
+	Dedup                      *Deduplicator
+	EventHistory               *EventHistory
+	LinkWatchdog               *LinkWatchdog
+	ClockSkew                  *ClockSkew
+	// StatusTTL 最新状态在无新数据后的过期时间，为 0 时不过期
+	StatusTTL       time.Duration
+	StatusHeartbeat *health_service.Heartbeat
+	EventHeartbeat  *health_service.Heartbeat
+	StopFlag        atomic.Bool
+	StatusDone      chan bool
+	EventDone       chan bool
+	// stop 关闭后重试中的消息立即放弃退避等待
+	stop chan struct{}
 }
 
-func NewKafkaToRedis(
-	KafkaConfig *db_config_model.KafkaConfigModel, RedisConfig *db_config_model.RedisConfigModel,
-) *KafkaToRedis {
-	redisStatus := dbservice.NewRedisDict(RedisConfig.Host, RedisConfig.Port, RedisConfig.StatusDBno)
-	redisEvent := dbservice.NewRedisDict(RedisConfig.Host, RedisConfig.Port, RedisConfig.EventDBno)
-	kafkaStatus := dbservice.NewKafkaConsumer(KafkaConfig.Addr, KafkaConfig.AircraftDataTopic, "KafkaToRedis")
-	kafkaEvent := dbservice.NewKafkaConsumer(KafkaConfig.Addr, KafkaConfig.AircraftEventTopic, "KafkaToRedis")
+// NewKafkaToRedis 使用 app 中共享的 Redis 客户端创建转发服务，消费者创建失败时返回错误
+func NewKafkaToRedis(app *app_service.Container) (*KafkaToRedis, error) {
+	kafkaCfg, redisCfg, pipelineCfg := &app.Config.KafkaCfg, &app.Config.RedisCfg, &app.Config.PipelineCfg
+	kafkaStatus, err := app.NewConsumer(kafkaCfg.AircraftDataTopic, "KafkaToRedis")
+	if err != nil {
+		return nil, err
+	}
+	kafkaEvent, err := app.NewConsumer(kafkaCfg.AircraftEventTopic, "KafkaToRedis")
+	if err != nil {
+		return nil, err
+	}
+	redisEvent, redisLink := app.Redis.WithPrefix(redisCfg.EventPrefix), app.Redis.WithPrefix(redisCfg.DedupPrefix)
+	dedup := NewDeduplicator("KafkaToRedis", redisLink, pipelineCfg, true)
+	watchdog := NewLinkWatchdog(app.Redis.WithPrefix(redisCfg.TaskInfoPrefix), redisLink, app.EventProducer, pipelineCfg)
 	utils.MsgSuccess("        [KafkaToRedis]init successfully!")
+	return NewKafkaToRedisFromStores(
+		kafkaStatus, kafkaEvent, app.Redis.WithPrefix(redisCfg.StatusPrefix), redisEvent, dedup,
+		NewEventHistory(redisEvent, pipelineCfg), watchdog, NewClockSkew(redisLink),
+		time.Duration(pipelineCfg.StatusTTLSec)*time.Second,
+	), nil
+}
+
+// NewKafkaToRedisFromStores 由消费者与缓存组装转发服务并登记积压指标与存活检查，
+// 测试中可传入 memory 总线与内存缓存；dedup、history、watchdog、skew 为 nil 时不去重、不保留事件历史、
+// 不监测链路、不统计时钟偏差
+func NewKafkaToRedisFromStores(
+	statusConsumer, eventConsumer bus_service.Consumer,
+	status repository_service.LatestStore, event repository_service.EventStore,
+	dedup *Deduplicator, history *EventHistory, watchdog *LinkWatchdog, skew *ClockSkew, statusTTL time.Duration,
+) *KafkaToRedis {
+	metrics_service.RegisterKafkaConsumer(statusConsumer.Topic(), "KafkaToRedis", statusConsumer.Lag)
+	metrics_service.RegisterKafkaConsumer(eventConsumer.Topic(), "KafkaToRedis", eventConsumer.Lag)
+	statusHeartbeat, eventHeartbeat := health_service.NewHeartbeat(), health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:KafkaToRedis/status", statusHeartbeat.Check(heartbeatMaxAge))
+	health_service.RegisterLiveness("transfer:KafkaToRedis/event", eventHeartbeat.Check(heartbeatMaxAge))
 	return &KafkaToRedis{
-		KafkaEventConsumerService:  kafkaEvent,
-		KafkaStatusConsumerService: kafkaStatus,
-		RedisStatusService:         redisStatus,
-		RedisEventService:          redisEvent,
+		KafkaEventConsumerService:  eventConsumer,
+		KafkaStatusConsumerService: statusConsumer,
+		RedisStatusService:         status,
+		RedisEventService:          event,
+		Dedup:                      dedup,
+		EventHistory:               history,
+		LinkWatchdog:               watchdog,
+		ClockSkew:                  skew,
+		StatusTTL:                  statusTTL,
+		StatusHeartbeat:            statusHeartbeat,
+		EventHeartbeat:             eventHeartbeat,
 		StatusDone:                 make(chan bool),
 		EventDone:                  make(chan bool),
-		StopFlag:                   false,
+		stop:                       make(chan struct{}),
 	}
 }
 
 func (ser *KafkaToRedis) KafkaStatusToRedis() {
-	utils.MsgSuccess("        [KafkaToRedis]start KafkaStatusToRedis successfully!")
-	for !ser.StopFlag {
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-		KafkaRe, err := ser.KafkaStatusConsumerService.ReceiveMessage(ctx)
+	logger := utils.ComponentLogger("KafkaToRedis").With("stream", "status")
+	logger.Info("start KafkaStatusToRedis successfully!")
+	for !ser.StopFlag.Load() {
+		ser.StatusHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaStatusConsumerService.FetchMessage(ctx)
+		cancel()
 		if err != nil {
-			utils.MsgError("        [KafkaToRedis]receive msg error")
+			logReceiveError(logger, err)
 			continue
 		}
-		utils.MsgSuccess(fmt.Sprintf("        [KafkaToRedis]KafkaStatusToRedis receive msg -> %s", KafkaRe))
-
-		var reStruct data_flow_model.AircraftStatus
-		err = json.Unmarshal([]byte(KafkaRe), &reStruct)
-		if err != nil {
-			utils.MsgError("        [KafkaToRedis]invalid json")
-			continue
-		}
-		err = ser.RedisStatusService.Set(strconv.Itoa(reStruct.AircraftID), KafkaRe)
-		if err != nil {
-			utils.MsgError("        [KafkaToRedis]invalid json")
-			continue
-		}
-		utils.MsgSuccess("        [KafkaToRedis]KafkaStatusToRedis successfully!")
+		consumeMessage("KafkaToRedis", "status", logger, ser.KafkaStatusConsumerService, KafkaRe, ser.handleStatus,
+			ser.StatusHeartbeat, ser.stop)
 	}
 	ser.StatusDone <- true
 }
 
+func (ser *KafkaToRedis) handleStatus(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	msgLogger.Debug("receive msg", "payload", value)
+	var reStruct data_flow_model.AircraftStatus
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	msgLogger = msgLogger.With("aircraft_id", reStruct.AircraftID)
+	// 重传与乱序的消息同样说明链路仍然连通
+	ser.LinkWatchdog.Received(ctx, msgLogger, reStruct.AircraftID)
+	if duplicate, dedupErr := ser.Dedup.Seen(ctx, "status", reStruct.AircraftID, reStruct.DedupKey(), reStruct.Seq); dedupErr != nil {
+		// 去重失败时宁可重复写入也不丢数据
+		msgLogger.Warn("dedup check failed, processing anyway", "error", dedupErr)
+	} else if duplicate {
+		msgLogger.Debug("duplicate msg dropped", "dedup_key", reStruct.DedupKey())
+		return "", nil
+	}
+	ser.ClockSkew.Record(msgLogger, &reStruct)
+	reason, err := ser.storeStatus(ctx, msgLogger, &reStruct, value)
+	if isTransient(reason) {
+		ser.Dedup.Forget(ctx, msgLogger, "status", reStruct.AircraftID, reStruct.DedupKey())
+	}
+	return reason, err
+}
+
+// storeStatus 在状态不早于已缓存的最新状态时写入 Redis
+func (ser *KafkaToRedis) storeStatus(
+	ctx context.Context, msgLogger *slog.Logger, reStruct *data_flow_model.AircraftStatus, value string,
+) (string, error) {
+	statusTime, err := utils.ParseSqlTimeStr(reStruct.TimeString)
+	if err != nil {
+		msgLogger.Error("invalid time", "time", reStruct.TimeString, "error", err)
+		return "invalid_time", err
+	}
+	// 乱序晚到的旧消息不能覆盖更新的最新状态
+	_, span := trace_service.StartSpan(ctx, "redis.set_if_newer", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	stored, err := ser.RedisStatusService.SetIfNewer(strconv.Itoa(reStruct.AircraftID), value, statusTime, ser.StatusTTL)
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		msgLogger.Error("failed to set redis", "error", err)
+		return "redis_error", err
+	}
+	if !stored {
+		metrics_service.PipelineStale.WithLabelValues("KafkaToRedis", "status").Inc()
+		msgLogger.Debug("stale msg ignored", "time", reStruct.TimeString)
+		return "", nil
+	}
+	msgLogger.Debug("KafkaStatusToRedis successfully!")
+	return "", nil
+}
+
 func (ser *KafkaToRedis) KafkaEventToRedis() {
-	for !ser.StopFlag {
-		utils.MsgSuccess("        [KafkaToRedis]start KafkaEventToRedis successfully!")
-		ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-		KafkaRe, err := ser.KafkaEventConsumerService.ReceiveMessage(ctx)
-		if err != nil {
-			utils.MsgError("        [KafkaToRedis]KafkaEventToRedis receive msg error")
-			continue
-		}
-		utils.MsgSuccess(fmt.Sprintf("        [KafkaToRedis]KafkaEventToRedis receive msg -> %s", KafkaRe))
-		var reStruct data_flow_model.AircraftEvent
-		err = json.Unmarshal([]byte(KafkaRe), &reStruct)
+	logger := utils.ComponentLogger("KafkaToRedis").With("stream", "event")
+	logger.Info("start KafkaEventToRedis successfully!")
+	for !ser.StopFlag.Load() {
+		ser.EventHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaEventConsumerService.FetchMessage(ctx)
+		cancel()
 		if err != nil {
-			utils.MsgError("        [KafkaToRedis]KafkaEventToRedis invalid json")
+			logReceiveError(logger, err)
 			continue
 		}
-		err = ser.RedisEventService.Set(strconv.Itoa(reStruct.AircraftID), KafkaRe)
-		if err != nil {
-			utils.MsgError("        [KafkaToRedis]KafkaEventToRedis invalid json")
-			continue
-		}
-		utils.MsgSuccess("        [KafkaToRedis]KafkaEventToRedis successfully!")
+		consumeMessage("KafkaToRedis", "event", logger, ser.KafkaEventConsumerService, KafkaRe, ser.handleEvent,
+			ser.EventHeartbeat, ser.stop)
 	}
 	ser.EventDone <- true
 }
 
+func (ser *KafkaToRedis) handleEvent(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	msgLogger.Debug("receive msg", "payload", value)
+	var reStruct data_flow_model.AircraftEvent
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	msgLogger = msgLogger.With("aircraft_id", reStruct.AircraftID)
+	if duplicate, dedupErr := ser.Dedup.Seen(ctx, "event", reStruct.AircraftID, reStruct.DedupKey(), reStruct.Seq); dedupErr != nil {
+		// 去重失败时宁可重复写入也不丢数据
+		msgLogger.Warn("dedup check failed, processing anyway", "error", dedupErr)
+	} else if duplicate {
+		msgLogger.Debug("duplicate msg dropped", "dedup_key", reStruct.DedupKey())
+		return "", nil
+	}
+	reason, err := ser.storeEvent(ctx, msgLogger, &reStruct, value)
+	if isTransient(reason) {
+		ser.Dedup.Forget(ctx, msgLogger, "event", reStruct.AircraftID, reStruct.DedupKey())
+	}
+	return reason, err
+}
+
+// storeEvent 将事件计入历史，并在不早于已缓存的最新事件时写入 Redis
+func (ser *KafkaToRedis) storeEvent(
+	ctx context.Context, msgLogger *slog.Logger, reStruct *data_flow_model.AircraftEvent, value string,
+) (string, error) {
+	eventTime, err := utils.ParseSqlTimeStr(reStruct.TimeString)
+	if err != nil {
+		msgLogger.Error("invalid time", "time", reStruct.TimeString, "error", err)
+		return "invalid_time", err
+	}
+	// 乱序晚到的事件仍按事件时间计入历史
+	if err = ser.EventHistory.Add(ctx, reStruct, value); err != nil {
+		msgLogger.Error("failed to add event history", "error", err)
+		return "redis_error", err
+	}
+	// 乱序晚到的旧消息不能覆盖更新的最新事件
+	_, span := trace_service.StartSpan(ctx, "redis.set_if_newer", trace.SpanKindClient, attribute.String("db.system", "redis"))
+	stored, err := ser.RedisEventService.SetIfNewer(strconv.Itoa(reStruct.AircraftID), value, eventTime, 0)
+	trace_service.EndSpan(span, err)
+	if err != nil {
+		msgLogger.Error("failed to set redis", "error", err)
+		return "redis_error", err
+	}
+	if !stored {
+		metrics_service.PipelineStale.WithLabelValues("KafkaToRedis", "event").Inc()
+		msgLogger.Debug("stale msg ignored", "time", reStruct.TimeString)
+		return "", nil
+	}
+	msgLogger.Info("KafkaEventToRedis successfully!", "event", reStruct.Event)
+	return "", nil
+}
+
 func (ser *KafkaToRedis) Stop() {
-	ser.StopFlag = true
+	ser.StopFlag.Store(true)
+	close(ser.stop)
+	if ser.LinkWatchdog != nil {
+		ser.LinkWatchdog.Stop()
+	}
 	<-ser.StatusDone
 	<-ser.EventDone
 }
@@ -103,4 +248,7 @@ func (ser *KafkaToRedis) Stop() {
 func (ser *KafkaToRedis) Start() {
 	go ser.KafkaStatusToRedis()
 	go ser.KafkaEventToRedis()
+	if ser.LinkWatchdog != nil {
+		go ser.LinkWatchdog.Run()
+	}
 }
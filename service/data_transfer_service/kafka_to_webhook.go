@@ -0,0 +1,109 @@
+package data_transfer_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/webhook_service"
+	"uam-power-backend/utils"
+)
+
+// KafkaToWebhook 消费事件 topic，为订阅了该事件类型的 webhook 写入投递记录，由 webhook_service.Dispatcher 投递
+type KafkaToWebhook struct {
+	KafkaEventConsumerService bus_service.Consumer
+	Webhooks                  *webhook_service.Dispatcher
+	EventHeartbeat            *health_service.Heartbeat
+	StopFlag                  atomic.Bool
+	EventDone                 chan bool
+	// stop 关闭后重试中的消息立即放弃退避等待
+	stop chan struct{}
+}
+
+// NewKafkaToWebhook 使用 app 中共享的 webhook 投递服务创建事件通知服务，WebhookCfg.Enable 为 false 时返回 nil
+func NewKafkaToWebhook(app *app_service.Container) (*KafkaToWebhook, error) {
+	if app.Webhooks == nil {
+		return nil, nil
+	}
+	kafkaEvent, err := app.NewConsumer(app.Config.KafkaCfg.AircraftEventTopic, "KafkaToWebhook")
+	if err != nil {
+		return nil, err
+	}
+	utils.MsgSuccess("        [KafkaToWebhook]Successfully init!")
+	return NewKafkaToWebhookFromStores(kafkaEvent, app.Webhooks), nil
+}
+
+// NewKafkaToWebhookFromStores 由消费者与投递服务组装事件通知服务并登记积压指标与存活检查
+func NewKafkaToWebhookFromStores(eventConsumer bus_service.Consumer, webhooks *webhook_service.Dispatcher) *KafkaToWebhook {
+	metrics_service.RegisterKafkaConsumer(eventConsumer.Topic(), "KafkaToWebhook", eventConsumer.Lag)
+	eventHeartbeat := health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:KafkaToWebhook/event", eventHeartbeat.Check(heartbeatMaxAge))
+	return &KafkaToWebhook{
+		KafkaEventConsumerService: eventConsumer,
+		Webhooks:                  webhooks,
+		EventHeartbeat:            eventHeartbeat,
+		EventDone:                 make(chan bool),
+		stop:                      make(chan struct{}),
+	}
+}
+
+func (ser *KafkaToWebhook) KafkaEventToWebhook() {
+	logger := utils.ComponentLogger("KafkaToWebhook").With("stream", "event")
+	logger.Info("start KafkaEventToWebhook successfully!")
+	for !ser.StopFlag.Load() {
+		ser.EventHeartbeat.Beat()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		KafkaRe, err := ser.KafkaEventConsumerService.FetchMessage(ctx)
+		cancel()
+		if err != nil {
+			logReceiveError(logger, err)
+			continue
+		}
+		// 投递记录写入失败时不确认，重试直到写入投递表，之后由 Dispatcher 负责重试投递
+		consumeMessage("KafkaToWebhook", "event", logger, ser.KafkaEventConsumerService, KafkaRe, ser.handleEvent,
+			ser.EventHeartbeat, ser.stop)
+	}
+	ser.EventDone <- true
+}
+
+func (ser *KafkaToWebhook) handleEvent(ctx context.Context, msgLogger *slog.Logger, value string) (string, error) {
+	var reStruct data_flow_model.AircraftEvent
+	err := json.Unmarshal([]byte(value), &reStruct)
+	if err != nil {
+		msgLogger.Error("invalid json", "error", err)
+		return "invalid_json", err
+	}
+	// 通知 ID 与告警的唯一键一致：同一飞行器同一时间的同一事件
+	id := fmt.Sprintf("event:%d:%s:%s", reStruct.AircraftID, reStruct.TimeString, reStruct.Event)
+	if err = ser.Webhooks.Notify(ctx, reStruct.Event, id, &reStruct); err != nil {
+		msgLogger.Error("failed to enqueue webhook deliveries", "aircraft_id", reStruct.AircraftID,
+			"event", reStruct.Event, "error", err)
+		return "mysql_error", err
+	}
+	return "", nil
+}
+
+// Start 启动事件消费；ser 为 nil（未开启 webhook）时不做任何事
+func (ser *KafkaToWebhook) Start() {
+	if ser == nil {
+		return
+	}
+	go ser.KafkaEventToWebhook()
+}
+
+// Stop 停止消费并等待当前消息处理结束；ser 为 nil 时不做任何事
+func (ser *KafkaToWebhook) Stop() {
+	if ser == nil {
+		return
+	}
+	ser.StopFlag.Store(true)
+	close(ser.stop)
+	<-ser.EventDone
+}
@@ -0,0 +1,149 @@
+package data_transfer_service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// LinkWatchdog 跟踪进行中任务的飞行器最近一次收到状态的时间，超过 Timeout 未收到时发出 LINK_LOST，
+// 恢复上报时发出 LINK_RESTORED。事件经事件 topic 发送，与上传的事件一样写入 Redis 与 MySQL。
+// 状态保存在 Redis 中，多个实例同时运行时每次失联与恢复只发出一次事件
+type LinkWatchdog struct {
+	// Tasks 进行中的任务，key 为 AircraftID
+	Tasks     repository_service.KVStore
+	Links     repository_service.LinkStore
+	Producer  bus_service.Producer
+	Timeout   time.Duration
+	Interval  time.Duration
+	Heartbeat *health_service.Heartbeat
+	StopFlag  atomic.Bool
+	Done      chan bool
+	// stop 关闭后检查立即退出，不等待下一个周期
+	stop chan struct{}
+}
+
+// NewLinkWatchdog 按配置创建链路监测，LinkTimeoutSec 为 0 时返回 nil（不监测）；
+// producer 为事件 topic 的生产者
+func NewLinkWatchdog(
+	tasks repository_service.KVStore, links repository_service.LinkStore, producer bus_service.Producer,
+	PipelineConfig *db_config_model.PipelineConfigModel,
+) *LinkWatchdog {
+	if PipelineConfig.LinkTimeoutSec <= 0 {
+		return nil
+	}
+	interval := time.Duration(PipelineConfig.LinkCheckIntervalSec) * time.Second
+	heartbeat := health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:LinkWatchdog", heartbeat.Check(heartbeatMaxAge+interval))
+	return &LinkWatchdog{
+		Tasks:     tasks,
+		Links:     links,
+		Producer:  producer,
+		Timeout:   time.Duration(PipelineConfig.LinkTimeoutSec) * time.Second,
+		Interval:  interval,
+		Heartbeat: heartbeat,
+		Done:      make(chan bool),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Received 记录收到飞行器的状态，飞行器此前已判定失联时发出 LINK_RESTORED；w 为 nil 时不做任何事。
+// 记录失败只写日志，不影响状态消息的处理
+func (w *LinkWatchdog) Received(ctx context.Context, msgLogger *slog.Logger, AircraftID int) {
+	if w == nil {
+		return
+	}
+	id := strconv.Itoa(AircraftID)
+	restored, err := w.Links.TouchLink(data_flow_model.LinkSeenKey, data_flow_model.LinkLostKey, id, time.Now())
+	if err != nil {
+		msgLogger.Warn("failed to record link activity", "error", err)
+		return
+	}
+	if restored {
+		w.raise(ctx, msgLogger, AircraftID, data_flow_model.EventLinkRestored)
+	}
+}
+
+// Run 每隔 Interval 检查一次，直到 Stop
+func (w *LinkWatchdog) Run() {
+	logger := utils.ComponentLogger("LinkWatchdog")
+	logger.Info("start LinkWatchdog successfully!", "timeout", w.Timeout.String())
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for !w.StopFlag.Load() {
+		w.Heartbeat.Beat()
+		w.Check(context.Background(), logger)
+		select {
+		case <-ticker.C:
+		case <-w.stop:
+		}
+	}
+	logger.Info("LinkWatchdog stopped")
+	w.Done <- true
+}
+
+// Check 对进行中任务的每架飞行器检查最近接收时间，超过 Timeout 的发出 LINK_LOST；
+// 新开始的任务从首次检查时开始计时，已结束任务的记录被清除
+func (w *LinkWatchdog) Check(ctx context.Context, logger *slog.Logger) {
+	active, err := w.Tasks.Keys()
+	if err != nil {
+		logger.Error("failed to list running tasks", "error", err)
+		return
+	}
+	now := time.Now()
+	seen, err := w.Links.LinkLastSeen(data_flow_model.LinkSeenKey, active, now)
+	if err != nil {
+		logger.Error("failed to read link activity", "error", err)
+		return
+	}
+	for _, id := range active {
+		if now.Sub(seen[id]) < w.Timeout {
+			continue
+		}
+		lost, err := w.Links.SetLinkLost(data_flow_model.LinkLostKey, id, true)
+		if err != nil {
+			logger.Error("failed to mark link lost", "aircraft_id", id, "error", err)
+			continue
+		}
+		if AircraftID, convErr := strconv.Atoi(id); lost && convErr == nil {
+			w.raise(ctx, logger, AircraftID, data_flow_model.EventLinkLost)
+		}
+	}
+	if err = w.Links.PruneLinks(data_flow_model.LinkSeenKey, data_flow_model.LinkLostKey, active); err != nil {
+		logger.Warn("failed to prune link activity", "error", err)
+	}
+}
+
+// raise 经事件 topic 发出链路事件；发送失败时回退失联状态，由下一次检查或下一条状态重新发出
+func (w *LinkWatchdog) raise(ctx context.Context, logger *slog.Logger, AircraftID int, event string) {
+	value, _ := json.Marshal(data_flow_model.AircraftEvent{
+		TimeString: utils.GetMySqlTimeStr(), Event: event, AircraftID: AircraftID,
+		Severity: event_catalog_model.BuiltinSeverity(event),
+	})
+	id := strconv.Itoa(AircraftID)
+	if err := w.Producer.SendKeyedMessage(ctx, id, string(value)); err != nil {
+		logger.Error("failed to send link event", "aircraft_id", AircraftID, "event", event, "error", err)
+		_, _ = w.Links.SetLinkLost(data_flow_model.LinkLostKey, id, event == data_flow_model.EventLinkRestored)
+		return
+	}
+	metrics_service.LinkEvents.WithLabelValues(event).Inc()
+	logger.Warn("link event raised", "aircraft_id", AircraftID, "event", event)
+}
+
+// Stop 停止检查并等待当前检查结束
+func (w *LinkWatchdog) Stop() {
+	w.StopFlag.Store(true)
+	close(w.stop)
+	<-w.Done
+}
@@ -0,0 +1,120 @@
+package data_transfer_service
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/trace_service"
+	"uam-power-backend/utils"
+)
+
+// heartbeatMaxAge 转发循环超过该时长未迭代即视为停止（单次接收超时为 5 秒）
+const heartbeatMaxAge = 30 * time.Second
+
+// retryBackoff/maxRetryBackoff 暂时性失败后重试同一条消息的初始与最大间隔
+const (
+	retryBackoff    = 100 * time.Millisecond
+	maxRetryBackoff = 5 * time.Second
+)
+
+// transientReasons 依赖暂时不可用造成的失败原因，消息不确认并在退避后重试；
+// 其他失败原因（如 invalid_json、invalid_time）重试也不会成功，确认后计入指标
+var transientReasons = map[string]bool{"mysql_error": true, "redis_error": true}
+
+// isTransient 判断失败原因是否为暂时性失败
+func isTransient(reason string) bool {
+	return transientReasons[reason]
+}
+
+// messageHandler 处理一条消息的值，失败时返回用于统计的失败原因与错误
+type messageHandler func(ctx context.Context, msgLogger *slog.Logger, value string) (string, error)
+
+// processMessage 恢复消息头中的 trace context 并在 consumer span 内执行 handle，
+// 同时记录处理耗时与失败原因，返回失败原因
+func processMessage(
+	service, stream string, logger *slog.Logger, consumer bus_service.Consumer, msg *bus_service.Message, handle messageHandler,
+) string {
+	start := time.Now()
+	metrics_service.PipelineMessages.WithLabelValues(service, stream).Inc()
+	ctx := trace_service.ExtractHeaders(context.Background(), msg.Headers)
+	ctx, span := trace_service.StartSpan(ctx, service+" "+stream, trace.SpanKindConsumer,
+		attribute.String("messaging.system", consumer.System()), attribute.String("messaging.operation", "process"))
+	msgLogger := messageLogger(logger, msg)
+	if traceID := trace_service.TraceID(ctx); traceID != "" {
+		msgLogger = msgLogger.With("trace_id", traceID)
+	}
+	reason, err := handle(ctx, msgLogger, msg.Value)
+	observePipeline(service, stream, start, reason)
+	trace_service.EndSpan(span, err)
+	return reason
+}
+
+// consumeMessage 处理一条消息，成功或永久失败时确认；暂时性失败时不确认，按指数退避重试同一条消息，
+// 保持同一飞行器消息的顺序。stop 关闭后（包括退避等待期间）放弃重试，消息保持未确认，由总线重新投递：
+// Kafka 未提交 offset、Redis Streams 未 XACK 由同组消费者接管、memory 总线在 Close 时放回队列
+func consumeMessage(
+	service, stream string, logger *slog.Logger, consumer bus_service.Consumer, msg *bus_service.Message,
+	handle messageHandler, heartbeat *health_service.Heartbeat, stop <-chan struct{},
+) {
+	backoff := retryBackoff
+	for {
+		reason := processMessage(service, stream, logger, consumer, msg, handle)
+		if !isTransient(reason) {
+			commitMessage(logger, consumer, msg)
+			return
+		}
+		messageLogger(logger, msg).Warn("transient failure, retrying msg", "reason", reason, "backoff", backoff)
+		wait := time.NewTimer(backoff)
+		select {
+		case <-wait.C:
+		case <-stop:
+			wait.Stop()
+			messageLogger(logger, msg).Warn("stopping with msg unacknowledged", "reason", reason)
+			return
+		}
+		heartbeat.Beat()
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// commitMessage 确认消息已处理，只在处理成功或永久失败后调用
+func commitMessage(logger *slog.Logger, consumer bus_service.Consumer, msg *bus_service.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := consumer.CommitMessage(ctx, msg); err != nil {
+		logger.Error("commit msg error", "error", err)
+	}
+}
+
+// messageLogger 为一条总线消息派生日志器，附带上游透传的请求 ID
+func messageLogger(logger *slog.Logger, msg *bus_service.Message) *slog.Logger {
+	if requestID := msg.Headers[utils.RequestIDHeader]; requestID != "" {
+		return logger.With("request_id", requestID)
+	}
+	return logger
+}
+
+// logReceiveError 记录总线接收错误，空闲超时仅以 debug 级别记录
+func logReceiveError(logger *slog.Logger, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Debug("no msg received before timeout")
+		return
+	}
+	logger.Error("receive msg error", "error", err)
+}
+
+// observePipeline 记录一条消息的处理耗时，reason 非空时计为处理失败
+func observePipeline(service, stream string, start time.Time, reason string) {
+	metrics_service.PipelineDuration.WithLabelValues(service, stream).Observe(time.Since(start).Seconds())
+	if reason != "" {
+		metrics_service.PipelineFailures.WithLabelValues(service, stream, reason).Inc()
+	}
+}
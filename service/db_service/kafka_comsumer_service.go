@@ -3,8 +3,22 @@ package dbservice
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
 )
 
+// KafkaMessage Kafka 消息的值与消息头
+type KafkaMessage struct {
+	Key     string
+	Value   string
+	Headers map[string]string
+	// raw 原始消息，FetchKafkaMessage 取得的消息据此提交 offset
+	raw kafka.Message
+}
+
+// kafkaCommitInterval offset 的异步提交间隔，进程异常退出时最多重投该间隔内已处理的消息
+const kafkaCommitInterval = time.Second
+
 // KafkaConsumer 封装 Kafka 消费者
 type KafkaConsumer struct {
 	reader *kafka.Reader
@@ -20,6 +34,26 @@ func NewKafkaConsumer(addr, topic, groupID string) *KafkaConsumer {
 	return &KafkaConsumer{reader: reader}
 }
 
+// NewKafkaConsumerWithConfig 按配置创建 Kafka 消费者，支持多 broker、SASL、TLS 及拉取参数
+func NewKafkaConsumerWithConfig(cfg *db_config_model.KafkaConfigModel, topic, groupID string) (*KafkaConsumer, error) {
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  KafkaBrokers(cfg),
+		Topic:    topic,
+		GroupID:  groupID,
+		Dialer:   dialer,
+		MinBytes: cfg.FetchMinBytes,
+		MaxBytes: cfg.FetchMaxBytes,
+		MaxWait:  time.Duration(cfg.FetchMaxWaitMs) * time.Millisecond,
+		// 消息处理后由 CommitKafkaMessage 提交
+		CommitInterval: kafkaCommitInterval,
+	})
+	return &KafkaConsumer{reader: reader}, nil
+}
+
 // ReceiveMessage 从 Kafka 中接收消息
 func (c *KafkaConsumer) ReceiveMessage(ctx context.Context) (string, error) {
 	msg, err := c.reader.ReadMessage(ctx)
@@ -31,6 +65,48 @@ func (c *KafkaConsumer) ReceiveMessage(ctx context.Context) (string, error) {
 	return string(msg.Value), nil
 }
 
+// ReceiveKafkaMessage 从 Kafka 中接收消息，同时返回消息头
+func (c *KafkaConsumer) ReceiveKafkaMessage(ctx context.Context) (*KafkaMessage, error) {
+	msg, err := c.reader.ReadMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return &KafkaMessage{Key: string(msg.Key), Value: string(msg.Value), Headers: headers}, nil
+}
+
+// FetchKafkaMessage 从 Kafka 中拉取消息但不提交 offset，处理完成后需调用 CommitKafkaMessage；
+// 未提交的消息在消费者重启或再均衡后会重新投递
+func (c *KafkaConsumer) FetchKafkaMessage(ctx context.Context) (*KafkaMessage, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return &KafkaMessage{Key: string(msg.Key), Value: string(msg.Value), Headers: headers, raw: msg}, nil
+}
+
+// CommitKafkaMessage 提交 FetchKafkaMessage 取得的消息的 offset
+func (c *KafkaConsumer) CommitKafkaMessage(ctx context.Context, msg *KafkaMessage) error {
+	return c.reader.CommitMessages(ctx, msg.raw)
+}
+
+// Topic 返回消费的 topic
+func (c *KafkaConsumer) Topic() string {
+	return c.reader.Config().Topic
+}
+
+// Lag 返回最近一次拉取时的消息积压量
+func (c *KafkaConsumer) Lag() int64 {
+	return c.reader.Stats().Lag
+}
+
 // Close 关闭 Kafka 消费者
 func (c *KafkaConsumer) Close() error {
 	return c.reader.Close()
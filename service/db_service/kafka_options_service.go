@@ -0,0 +1,124 @@
+package dbservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"strings"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/utils"
+)
+
+// KafkaBrokers 返回配置的 broker 列表，优先使用 Brokers，其次使用逗号分隔的 Addr
+func KafkaBrokers(cfg *db_config_model.KafkaConfigModel) []string {
+	if len(cfg.Brokers) > 0 {
+		return cfg.Brokers
+	}
+	var brokers []string
+	for _, addr := range strings.Split(cfg.Addr, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			brokers = append(brokers, addr)
+		}
+	}
+	return brokers
+}
+
+// KafkaSASLMechanism 按配置创建 SASL 认证方式，未配置时返回 nil
+func KafkaSASLMechanism(cfg *db_config_model.KafkaSASLConfigModel) (sasl.Mechanism, error) {
+	switch strings.ToUpper(cfg.Mechanism) {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", cfg.Mechanism)
+}
+
+// KafkaCompression 解析压缩算法，none 或空表示不压缩
+func KafkaCompression(name string) (kafka.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	}
+	return 0, fmt.Errorf("unsupported compression codec %q", name)
+}
+
+// KafkaRequiredAcks 解析 acks 配置：none/one/all 或对应的 0/1/-1，空值等同于 none（不等待 broker 确认）
+func KafkaRequiredAcks(name string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(name) {
+	case "", "none", "0":
+		return kafka.RequireNone, nil
+	case "one", "1":
+		return kafka.RequireOne, nil
+	case "all", "-1":
+		return kafka.RequireAll, nil
+	}
+	return kafka.RequireNone, fmt.Errorf("unsupported required acks %q", name)
+}
+
+// newKafkaTransport 创建带 SASL/TLS 的生产者传输层
+func newKafkaTransport(cfg *db_config_model.KafkaConfigModel) (*kafka.Transport, error) {
+	mechanism, err := KafkaSASLMechanism(&cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := utils.LoadTLSConfig(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Transport{SASL: mechanism, TLS: tlsConfig}, nil
+}
+
+// newKafkaDialer 创建带 SASL/TLS 的消费者拨号器
+func newKafkaDialer(cfg *db_config_model.KafkaConfigModel) (*kafka.Dialer, error) {
+	mechanism, err := KafkaSASLMechanism(&cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := utils.LoadTLSConfig(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		SASLMechanism: mechanism,
+		TLS:           tlsConfig,
+	}, nil
+}
+
+// PingKafkaBrokers 依次拨号配置中的 broker（含 SASL/TLS 握手），任一可达即返回 nil，
+// 集群中个别 broker 不可用时客户端仍能通过其余 broker 获取元数据
+func PingKafkaBrokers(ctx context.Context, cfg *db_config_model.KafkaConfigModel) error {
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, broker := range KafkaBrokers(cfg) {
+		conn, dialErr := dialer.DialContext(ctx, "tcp", broker)
+		if dialErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", broker, dialErr))
+			continue
+		}
+		return conn.Close()
+	}
+	return errors.Join(errs...)
+}
@@ -3,11 +3,21 @@ package dbservice
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/trace_service"
+	"uam-power-backend/utils"
 )
 
 // KafkaProducer 封装 Kafka 生产者
 type KafkaProducer struct {
 	writer *kafka.Writer
+	// timeout sync 模式下单次写入的超时
+	timeout time.Duration
 }
 
 // NewKafkaProducer 创建一个新的 Kafka 生产者
@@ -21,12 +31,128 @@ func NewKafkaProducer(addr, topic string) *KafkaProducer {
 	return &KafkaProducer{writer: writer}
 }
 
+// syncBatchTimeout 同步投递且未配置 LingerMs 时的凑批等待时间
+const syncBatchTimeout = time.Millisecond
+
+// NewKafkaProducerWithConfig 按配置创建 Kafka 生产者，支持多 broker、SASL、TLS 及批量/压缩/acks 参数，
+// 带 key 的消息按 key 哈希分区；投递方式（async/sync）按 topic 取自 KafkaDelivery
+func NewKafkaProducerWithConfig(cfg *db_config_model.KafkaConfigModel, topic string) (*KafkaProducer, error) {
+	transport, err := newKafkaTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := KafkaCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	delivery := KafkaDelivery(cfg, topic)
+	acksName := delivery.RequiredAcks
+	if acksName == "" {
+		acksName = cfg.RequiredAcks
+	}
+	acks, err := KafkaRequiredAcks(acksName)
+	if err != nil {
+		return nil, err
+	}
+	async := !strings.EqualFold(delivery.Mode, "sync")
+	batchTimeout := time.Duration(cfg.LingerMs) * time.Millisecond
+	if batchTimeout <= 0 && !async {
+		// kafka-go 在 BatchTimeout 为 0 时按 1 秒凑批，同步投递的每次调用都会因此阻塞约 1 秒
+		batchTimeout = syncBatchTimeout
+	}
+	logger := utils.ComponentLogger("KafkaProducer")
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(KafkaBrokers(cfg)...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		Async:        async,
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: batchTimeout,
+		Compression:  compression,
+		RequiredAcks: acks,
+		Transport:    transport,
+		// 同步与异步模式下每个批次完成后都会回调，失败统一在此计数；
+		// 同步模式的错误还会返回给调用方，异步模式只能在此记录日志
+		Completion: func(messages []kafka.Message, err error) {
+			if err == nil {
+				return
+			}
+			metrics_service.KafkaProduceErrors.WithLabelValues(topic).Add(float64(len(messages)))
+			if async {
+				logger.Error("async write failed", "topic", topic, "messages", len(messages),
+					"request_id", firstHeader(messages, utils.RequestIDHeader), "error", err)
+			}
+		},
+	}
+	return &KafkaProducer{writer: writer, timeout: time.Duration(delivery.TimeoutMs) * time.Millisecond}, nil
+}
+
+// KafkaDelivery 返回 topic 对应的投递方式，未单独配置的 topic 按 async 处理
+func KafkaDelivery(cfg *db_config_model.KafkaConfigModel, topic string) db_config_model.KafkaDeliveryConfigModel {
+	switch topic {
+	case cfg.AircraftEventTopic:
+		return cfg.EventDelivery
+	case cfg.AircraftDataTopic:
+		return cfg.DataDelivery
+	}
+	return db_config_model.KafkaDeliveryConfigModel{Mode: "async"}
+}
+
+// firstHeader 返回批次中第一条消息的指定消息头
+func firstHeader(messages []kafka.Message, key string) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	for _, header := range messages[0].Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Sync 返回是否为同步投递：为 true 时 SendKeyedMessage 返回 nil 即表示 broker 已按 RequiredAcks 确认写入
+func (p *KafkaProducer) Sync() bool {
+	return !p.writer.Async
+}
+
 // SendMessage 发送消息到 Kafka
 func (p *KafkaProducer) SendMessage(message string) error {
+	return p.SendMessageContext(context.Background(), message)
+}
+
+// SendMessageContext 发送不带 key 的消息到 Kafka，并将 context 中的请求 ID 与 trace context 写入消息头
+func (p *KafkaProducer) SendMessageContext(ctx context.Context, message string) error {
+	return p.SendKeyedMessage(ctx, "", message)
+}
+
+// SendKeyedMessage 发送带 key 的消息，按 key 哈希分区，相同 key 的消息进入同一分区并保持顺序；
+// key 为空时轮询分区。异步模式下返回 nil 仅表示消息已进入发送批次
+func (p *KafkaProducer) SendKeyedMessage(ctx context.Context, key, message string) (err error) {
+	ctx, span := trace_service.StartSpan(ctx, "kafka.produce "+p.writer.Topic, trace.SpanKindProducer,
+		attribute.String("messaging.system", "kafka"), attribute.String("messaging.destination.name", p.writer.Topic))
+	defer func() { trace_service.EndSpan(span, err) }()
+
+	headers := map[string]string{}
+	if requestID := utils.RequestIDFromContext(ctx); requestID != "" {
+		headers[utils.RequestIDHeader] = requestID
+	}
+	trace_service.InjectHeaders(ctx, headers)
 	msg := kafka.Message{
 		Value: []byte(message),
 	}
-	return p.writer.WriteMessages(context.Background(), msg)
+	if key != "" {
+		msg.Key = []byte(key)
+	}
+	for name, value := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: name, Value: []byte(value)})
+	}
+	if !p.writer.Async && p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return p.writer.WriteMessages(ctx, msg)
 }
 
 // Close 关闭 Kafka 生产者
@@ -0,0 +1,62 @@
+package dbservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/utils"
+)
+
+// EnsureKafkaTopics 检查飞行数据与事件 topic，不存在时按配置的分区数与副本数创建；
+// 已存在但分区数少于配置时只告警，扩容分区会改变飞行器到分区的映射，需人工评估后执行
+func EnsureKafkaTopics(ctx context.Context, cfg *db_config_model.KafkaConfigModel) error {
+	transport, err := newKafkaTransport(cfg)
+	if err != nil {
+		return err
+	}
+	client := &kafka.Client{Addr: kafka.TCP(KafkaBrokers(cfg)...), Transport: transport}
+	// 不指定 topic 以读取全部元数据，避免 broker 开启自动建 topic 时以默认分区数创建
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]int{}
+	for _, topic := range metadata.Topics {
+		if topic.Error == nil {
+			existing[topic.Name] = len(topic.Partitions)
+		}
+	}
+
+	var missing []kafka.TopicConfig
+	for _, topic := range []string{cfg.AircraftDataTopic, cfg.AircraftEventTopic} {
+		partitions, ok := existing[topic]
+		switch {
+		case !ok:
+			missing = append(missing, kafka.TopicConfig{
+				Topic: topic, NumPartitions: cfg.Partitions, ReplicationFactor: cfg.ReplicationFactor,
+			})
+		case partitions < cfg.Partitions:
+			utils.MsgWarn(fmt.Sprintf("        [EnsureKafkaTopics]topic %s has %d partitions, %d configured",
+				topic, partitions, cfg.Partitions))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: missing})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, topic := range missing {
+		if createErr := resp.Errors[topic.Topic]; createErr != nil && !errors.Is(createErr, kafka.TopicAlreadyExists) {
+			errs = append(errs, fmt.Errorf("create topic %s: %w", topic.Topic, createErr))
+			continue
+		}
+		utils.MsgSuccess(fmt.Sprintf("        [EnsureKafkaTopics]created topic %s with %d partitions",
+			topic.Topic, topic.NumPartitions))
+	}
+	return errors.Join(errs...)
+}
@@ -1,6 +1,7 @@
 package dbservice
 
 import (
+	"context"
 	"database/sql"
 	_ "github.com/go-sql-driver/mysql"
 	"time"
@@ -28,8 +29,18 @@ func NewMySQLService(dsn string) (*MySQLService, error) {
 	return &MySQLService{db: db}, nil
 }
 
-func (s *MySQLService) ExecuteCmd(sql string) (int, error) {
-	result, err := s.db.Exec(sql)
+// DB 返回底层连接池，用于上报连接池统计
+func (s *MySQLService) DB() *sql.DB {
+	return s.db
+}
+
+// Ping 检查数据库连接是否可用
+func (s *MySQLService) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *MySQLService) ExecuteCmd(sql string, args ...interface{}) (int, error) {
+	result, err := s.db.Exec(sql, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -44,6 +55,10 @@ func (s *MySQLService) QueryRow(query string, args ...interface{}) (map[string]i
 		return nil, err
 	}
 
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
 	// 获取列名
 	columns, err := rows.Columns()
 	if err != nil {
@@ -90,6 +105,10 @@ func (s *MySQLService) QueryRows(query string, args ...interface{}) (map[string]
 		return nil, err
 	}
 
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
 	// 获取列名
 	columns, err := rows.Columns()
 	if err != nil {
@@ -135,3 +154,26 @@ func (s *MySQLService) QueryRows(query string, args ...interface{}) (map[string]
 
 	return result, nil
 }
+
+// QueryEach 逐行遍历查询结果，由 scan 自行读取每一行，适用于大结果集的流式处理
+func (s *MySQLService) QueryEach(query string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Close 关闭数据库连接池
+func (s *MySQLService) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,28 @@
+package dbservice
+
+import (
+	"github.com/go-redis/redis/v8"
+)
+
+// recordClockSkewScript 在链路统计 HASH（KEYS[1]）中累计时钟偏差：ARGV[1] 为偏差毫秒数，
+// 字段 skew_last/skew_count/skew_sum/skew_min/skew_max
+var recordClockSkewScript = redis.NewScript(`
+local skew = tonumber(ARGV[1])
+redis.call('HSET', KEYS[1], 'skew_last', skew)
+redis.call('HINCRBY', KEYS[1], 'skew_count', 1)
+redis.call('HINCRBY', KEYS[1], 'skew_sum', skew)
+local min = tonumber(redis.call('HGET', KEYS[1], 'skew_min'))
+if not min or skew < min then
+  redis.call('HSET', KEYS[1], 'skew_min', skew)
+end
+local max = tonumber(redis.call('HGET', KEYS[1], 'skew_max'))
+if not max or skew > max then
+  redis.call('HSET', KEYS[1], 'skew_max', skew)
+end
+return 1
+`)
+
+// RecordClockSkew 在 statsKey 对应的链路统计 HASH 中累计一次时钟偏差（毫秒）
+func (r *RedisDict) RecordClockSkew(statsKey string, skewMs int64) error {
+	return recordClockSkewScript.Run(r.ctx, r.client, []string{r.key(statsKey)}, skewMs).Err()
+}
@@ -0,0 +1,114 @@
+package dbservice
+
+import (
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// markSeenScript 在有界窗口内登记消息：
+// KEYS[1] 已见消息的 ZSET（score 为登记时间），KEYS[2] 最大序号，KEYS[3] 链路统计 HASH；
+// ARGV 依次为 消息标识、当前毫秒时间、窗口毫秒数、窗口最大条数、序号（可为空）、是否记录链路统计。
+// 返回 {是否重复, 本条消息之前缺失的序号数}
+var markSeenScript = redis.NewScript(`
+local now = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local stats = ARGV[6] == '1'
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+  if stats then redis.call('HINCRBY', KEYS[3], 'duplicates', 1) end
+  return {1, 0}
+end
+redis.call('ZADD', KEYS[1], now, ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local size = redis.call('ZCARD', KEYS[1])
+local max = tonumber(ARGV[4])
+if size > max then
+  redis.call('ZREMRANGEBYRANK', KEYS[1], 0, size - max - 1)
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local gap = 0
+local late = false
+if ARGV[5] ~= '' then
+  local seq = tonumber(ARGV[5])
+  local last = tonumber(redis.call('GET', KEYS[2]))
+  if last and seq > last + 1 then
+    gap = seq - last - 1
+  elseif last and seq < last then
+    late = true
+  end
+  if (not last) or seq > last then
+    redis.call('SET', KEYS[2], ARGV[5], 'PX', window)
+  end
+end
+if stats then
+  redis.call('HSETNX', KEYS[3], 'since', now)
+  redis.call('HINCRBY', KEYS[3], 'received', 1)
+  if gap > 0 then redis.call('HINCRBY', KEYS[3], 'missing', gap) end
+  -- 乱序晚到的消息此前已计入缺失，此处扣回
+  if late and tonumber(redis.call('HGET', KEYS[3], 'missing') or '0') > 0 then
+    redis.call('HINCRBY', KEYS[3], 'missing', -1)
+  end
+end
+return {0, gap}
+`)
+
+// unmarkSeenScript 撤销 MarkSeen 的登记：KEYS[1] 已见消息的 ZSET，KEYS[2] 链路统计 HASH；
+// ARGV 依次为 消息标识、是否扣回链路统计
+var unmarkSeenScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 and ARGV[2] == '1' then
+  redis.call('HINCRBY', KEYS[2], 'received', -1)
+end
+return 0
+`)
+
+// SeenResult MarkSeen 的结果
+type SeenResult struct {
+	Duplicate bool
+	// Gap 本条消息与此前最大序号之间缺失的序号数
+	Gap int64
+}
+
+// MarkSeen 在 scope 对应的窗口内登记 member，已登记过时返回 Duplicate。
+// 窗口按时间 window 与条数 maxEntries 双重限制；seq 非 nil 时检测序号缺口，
+// statsKey 非空时在该 HASH 中累计 received/missing/duplicates 链路统计。
+// scope 与 statsKey 应包含相同的 {hash tag}，保证集群模式下相关的 key 落在同一个 slot
+func (r *RedisDict) MarkSeen(
+	scope, member string, seq *int64, window time.Duration, maxEntries int, statsKey string,
+) (SeenResult, error) {
+	seqArg, statsArg := "", "0"
+	if seq != nil {
+		seqArg = strconv.FormatInt(*seq, 10)
+	}
+	if statsKey != "" {
+		statsArg = "1"
+	} else {
+		// 不记录统计时仍需占位，沿用 scope 保证 slot 一致
+		statsKey = scope + ":link"
+	}
+	keys := []string{r.key(scope + ":seen"), r.key(scope + ":last"), r.key(statsKey)}
+	reply, err := markSeenScript.Run(r.ctx, r.client, keys,
+		member, time.Now().UnixMilli(), window.Milliseconds(), maxEntries, seqArg, statsArg,
+	).Int64Slice()
+	if err != nil {
+		return SeenResult{}, err
+	}
+	return SeenResult{Duplicate: reply[0] == 1, Gap: reply[1]}, nil
+}
+
+// UnmarkSeen 从 scope 对应的窗口中移除 member，使其再次到达时不被视为重复；
+// statsKey 非空时扣回 MarkSeen 累计的 received。序号与缺口统计不回退，重试的消息序号不变
+func (r *RedisDict) UnmarkSeen(scope, member, statsKey string) error {
+	statsArg := "0"
+	if statsKey != "" {
+		statsArg = "1"
+	} else {
+		statsKey = scope + ":link"
+	}
+	keys := []string{r.key(scope + ":seen"), r.key(statsKey)}
+	return unmarkSeenScript.Run(r.ctx, r.client, keys, member, statsArg).Err()
+}
+
+// GetHash 读取 HASH 的全部字段，key 不存在时返回空 map
+func (r *RedisDict) GetHash(key string) (map[string]string, error) {
+	return r.client.HGetAll(r.ctx, r.key(key)).Result()
+}
@@ -0,0 +1,59 @@
+package dbservice
+
+import (
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"math"
+	"strconv"
+	"time"
+)
+
+// HistoryKey 历史记录所在的 ZSET 及其保留条数
+type HistoryKey struct {
+	Key        string
+	MaxEntries int
+}
+
+// AddHistory 将 member 以 score（通常为事件的毫秒时间戳）写入各个历史 ZSET，
+// 每个 ZSET 只保留 score 最大的 MaxEntries 条，并在 ttl 内无新记录时整体过期。
+// 各 key 独立写入，集群模式下可分布在不同 slot
+func (r *RedisDict) AddHistory(member string, score float64, ttl time.Duration, keys ...HistoryKey) error {
+	_, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		for _, history := range keys {
+			key := r.key(history.Key)
+			pipe.ZAdd(r.ctx, key, &redis.Z{Score: score, Member: member})
+			pipe.ZRemRangeByRank(r.ctx, key, 0, int64(-history.MaxEntries-1))
+			if ttl > 0 {
+				pipe.PExpire(r.ctx, key, ttl)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// RangeHistory 按 score 从新到旧返回历史 ZSET 中 score 不小于 since 的至多 count 条记录，
+// since 为 -Inf 时不限起点。JSON 记录解析后返回，无法解析的记录按原字符串返回
+func (r *RedisDict) RangeHistory(key string, since float64, count int) ([]interface{}, error) {
+	min := "-inf"
+	if !math.IsInf(since, -1) {
+		min = strconv.FormatFloat(since, 'f', -1, 64)
+	}
+	members, err := r.client.ZRevRangeByScore(r.ctx, r.key(key), &redis.ZRangeBy{
+		Max:   "+inf",
+		Min:   min,
+		Count: int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		var record interface{}
+		if err := json.Unmarshal([]byte(member), &record); err != nil {
+			record = member
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,95 @@
+package dbservice
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// 链路监测状态由两个 key 组成：seenKey 为以最近接收时间（毫秒）为 score 的 ZSET，lostKey 为已判定失联的 SET。
+// 二者需以同一 hash tag 落在同一 slot，集群模式下才能在同一事务中更新
+
+// TouchLink 将 member 的最近接收时间记为 at 并移出失联集合，返回其此前是否处于失联状态
+func (r *RedisDict) TouchLink(seenKey, lostKey, member string, at time.Time) (bool, error) {
+	var removed *redis.IntCmd
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(r.ctx, r.key(seenKey), &redis.Z{Score: float64(at.UnixMilli()), Member: member})
+		removed = pipe.SRem(r.ctx, r.key(lostKey), member)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed.Val() == 1, nil
+}
+
+// LinkLastSeen 返回 members 的最近接收时间；尚无记录的 member 以 at 登记，从此刻开始计时
+func (r *RedisDict) LinkLastSeen(seenKey string, members []string, at time.Time) (map[string]time.Time, error) {
+	scores := make([]*redis.FloatCmd, len(members))
+	_, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		for i, member := range members {
+			pipe.ZAddNX(r.ctx, r.key(seenKey), &redis.Z{Score: float64(at.UnixMilli()), Member: member})
+			scores[i] = pipe.ZScore(r.ctx, r.key(seenKey), member)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]time.Time, len(members))
+	for i, member := range members {
+		seen[member] = time.UnixMilli(int64(scores[i].Val()))
+	}
+	return seen, nil
+}
+
+// LinkSeenAt 返回 member 的最近接收时间，未记录时第二个返回值为 false
+func (r *RedisDict) LinkSeenAt(seenKey, member string) (time.Time, bool, error) {
+	score, err := r.client.ZScore(r.ctx, r.key(seenKey), member).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.UnixMilli(int64(score)), true, nil
+}
+
+// SetLinkLost 将 member 加入（lost 为 true）或移出失联集合，返回状态是否因此改变。
+// 多个实例同时检测到失联时只有一个返回 true
+func (r *RedisDict) SetLinkLost(lostKey, member string, lost bool) (bool, error) {
+	var changed int64
+	var err error
+	if lost {
+		changed, err = r.client.SAdd(r.ctx, r.key(lostKey), member).Result()
+	} else {
+		changed, err = r.client.SRem(r.ctx, r.key(lostKey), member).Result()
+	}
+	return changed == 1, err
+}
+
+// PruneLinks 移除不在 keep 中的 member（如任务已结束的飞行器）
+func (r *RedisDict) PruneLinks(seenKey, lostKey string, keep []string) error {
+	members, err := r.client.ZRange(r.ctx, r.key(seenKey), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, member := range keep {
+		kept[member] = true
+	}
+	var stale []interface{}
+	for _, member := range members {
+		if !kept[member] {
+			stale = append(stale, member)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	_, err = r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(r.ctx, r.key(seenKey), stale...)
+		pipe.SRem(r.ctx, r.key(lostKey), stale...)
+		return nil
+	})
+	return err
+}
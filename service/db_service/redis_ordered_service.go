@@ -0,0 +1,49 @@
+package dbservice
+
+import (
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// setIfNewerScript 仅当新值的时间不早于已存值时写入：
+// KEYS[1] 目标 key，KEYS[2] 已存值的时间（Unix 微秒）；ARGV 依次为 新值、新值的时间（Unix 微秒）、过期毫秒数（0 不过期）。
+// 按数值比较，与时间字符串的格式与时区无关；KEYS[2] 不存在（如升级前写入的值）时直接写入。返回 1 表示已写入
+var setIfNewerScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[2]))
+if current and current > tonumber(ARGV[2]) then
+  return 0
+end
+if tonumber(ARGV[3]) > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+  redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+  redis.call('SET', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// SetIfNewer 原子地比较已存值的时间与 at，仅在新值不早于已存值时写入，返回是否写入。
+// 时间保存在与 key 同 slot 的独立 key 中，没有已存时间时直接写入；ttl 大于 0 时写入后在 ttl 内无新值即过期
+func (r *RedisDict) SetIfNewer(key, value string, at time.Time, ttl time.Duration) (bool, error) {
+	fullKey := r.key(key)
+	stored, err := setIfNewerScript.Run(
+		r.ctx, r.client, []string{fullKey, sameSlotKey(fullKey, ":t")}, value, at.UnixMicro(), ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return stored == 1, nil
+}
+
+// sameSlotKey 返回与 key 落在同一个集群 slot 的辅助 key：key 已有 {hash tag} 时直接追加 suffix，
+// 否则以整个 key 作为 hash tag
+func sameSlotKey(key, suffix string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key + suffix
+		}
+	}
+	return "{" + key + "}" + suffix
+}
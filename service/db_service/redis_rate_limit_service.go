@@ -0,0 +1,108 @@
+package dbservice
+
+import (
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// takeTokensScript 对一次请求的各维度令牌桶做原子判断：KEYS 每个维度两个，依次为令牌桶 HASH 与当日已放行次数；
+// ARGV 依次为 当前毫秒时间、距每日配额重置的毫秒数，之后每个维度 3 个参数：每秒令牌数、桶容量、每日配额。
+// 任一维度不足时不扣减任何令牌与配额。返回 {受限维度的下标（从 1 起，0 表示放行）, 需等待的毫秒数}
+var takeTokensScript = redis.NewScript(`
+local now, quotaReset = tonumber(ARGV[1]), tonumber(ARGV[2])
+local n = #KEYS / 2
+local buckets = {}
+local limited, wait = 0, 0
+for i = 1, n do
+  local base = 2 + (i - 1) * 3
+  local rate, burst, quota = tonumber(ARGV[base + 1]), tonumber(ARGV[base + 2]), tonumber(ARGV[base + 3])
+  local dimWait = 0
+  if quota > 0 and tonumber(redis.call('GET', KEYS[2 * i]) or '0') >= quota then
+    dimWait = quotaReset
+  end
+  if rate > 0 then
+    local bucket = redis.call('HMGET', KEYS[2 * i - 1], 'tokens', 'ts')
+    local tokens, ts = tonumber(bucket[1]), tonumber(bucket[2])
+    if not tokens then
+      tokens, ts = burst, now
+    end
+    -- 各实例时钟略有偏差，时间回退时不补充令牌
+    if now > ts then
+      tokens, ts = math.min(burst, tokens + (now - ts) * rate / 1000), now
+    end
+    buckets[i] = {tokens, ts}
+    if tokens < 1 and dimWait == 0 then
+      dimWait = math.ceil((1 - tokens) * 1000 / rate)
+    end
+  end
+  if dimWait > wait then
+    limited, wait = i, dimWait
+  end
+end
+if limited > 0 then
+  return {limited, wait}
+end
+for i = 1, n do
+  local base = 2 + (i - 1) * 3
+  local rate, burst, quota = tonumber(ARGV[base + 1]), tonumber(ARGV[base + 2]), tonumber(ARGV[base + 3])
+  if rate > 0 then
+    redis.call('HSET', KEYS[2 * i - 1], 'tokens', buckets[i][1] - 1, 'ts', buckets[i][2])
+    redis.call('PEXPIRE', KEYS[2 * i - 1], math.ceil(burst * 1000 / rate) + 1000)
+  end
+  if quota > 0 then
+    redis.call('INCR', KEYS[2 * i])
+    redis.call('PEXPIRE', KEYS[2 * i], quotaReset + 60000)
+  end
+end
+return {0, 0}
+`)
+
+// TokenBucket 一次请求在某一维度上的令牌桶；Rate 为 0 时只按 DailyQuota 限制。QuotaKey 应按天区分
+type TokenBucket struct {
+	Key        string
+	QuotaKey   string
+	UsageField string
+	Rate       float64
+	Burst      int
+	DailyQuota int
+}
+
+// TakeResult TakeTokens 的结果，Limited 为受限的 TokenBucket 下标，放行时为 -1
+type TakeResult struct {
+	Limited    int
+	RetryAfter time.Duration
+}
+
+// TakeTokens 在全部 buckets 都有令牌且未超出每日配额时各扣减一个令牌，否则不扣减并返回需等待的时长。
+// quotaReset 为距每日配额重置的时长。各 bucket 的 Key 与 QuotaKey 应包含相同的 {hash tag}
+func (r *RedisDict) TakeTokens(buckets []TokenBucket, now time.Time, quotaReset time.Duration) (TakeResult, error) {
+	keys := make([]string, 0, 2*len(buckets))
+	args := []interface{}{now.UnixMilli(), quotaReset.Milliseconds()}
+	for _, bucket := range buckets {
+		keys = append(keys, r.key(bucket.Key), r.key(bucket.QuotaKey))
+		args = append(args, strconv.FormatFloat(bucket.Rate, 'f', -1, 64), bucket.Burst, bucket.DailyQuota)
+	}
+	reply, err := takeTokensScript.Run(r.ctx, r.client, keys, args...).Int64Slice()
+	if err != nil {
+		return TakeResult{}, err
+	}
+	return TakeResult{Limited: int(reply[0]) - 1, RetryAfter: time.Duration(reply[1]) * time.Millisecond}, nil
+}
+
+// CountUsage 在 usageKey 的 HASH 中为每个 bucket 累计 "<UsageField>|allowed"（allowed 为 true 时）或 "<UsageField>|rejected"，
+// 并将其保留 usageTTL。usageKey 不与令牌桶共用 hash tag，独立于限流判断更新
+func (r *RedisDict) CountUsage(usageKey string, usageTTL time.Duration, buckets []TokenBucket, allowed bool) error {
+	result := "|rejected"
+	if allowed {
+		result = "|allowed"
+	}
+	_, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		for _, bucket := range buckets {
+			pipe.HIncrBy(r.ctx, r.key(usageKey), bucket.UsageField+result, 1)
+		}
+		pipe.PExpire(r.ctx, r.key(usageKey), usageTTL)
+		return nil
+	})
+	return err
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/go-redis/redis/v8"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/utils"
 )
 
 type RedisDict struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ctx    context.Context
+	// prefix is prepended to every key so several kinds of data can share one keyspace
+	prefix string
 }
 
 // NewRedisDict initializes a new RedisDict instance
@@ -29,93 +36,195 @@ func NewRedisDict(host string, port int, db int) *RedisDict {
 	}
 }
 
+// NewRedisDictWithConfig initializes a RedisDict from config, supporting auth, TLS,
+// Sentinel and Cluster modes. All keys are namespaced with prefix.
+func NewRedisDictWithConfig(cfg *db_config_model.RedisConfigModel, prefix string) (*RedisDict, error) {
+	tlsConfig, err := utils.LoadTLSConfig(&cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	var rdb redis.UniversalClient
+	switch strings.ToLower(cfg.Mode) {
+	case "", "standalone":
+		rdb = redis.NewClient(&redis.Options{
+			Addr:         cfg.Host + ":" + strconv.Itoa(cfg.Port),
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			TLSConfig:    tlsConfig,
+			PoolSize:     10,
+			MinIdleConns: 5,
+			IdleTimeout:  5 * time.Minute,
+		})
+	case "sentinel":
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsConfig,
+			PoolSize:         10,
+			MinIdleConns:     5,
+			IdleTimeout:      5 * time.Minute,
+		})
+	case "cluster":
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Addrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			TLSConfig:    tlsConfig,
+			PoolSize:     10,
+			MinIdleConns: 5,
+			IdleTimeout:  5 * time.Minute,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported redis mode %q", cfg.Mode)
+	}
+	return &RedisDict{
+		client: rdb,
+		ctx:    context.Background(),
+		prefix: prefix,
+	}, nil
+}
+
+// WithPrefix returns a RedisDict sharing the same client and connection pool whose keys are namespaced with prefix
+func (r *RedisDict) WithPrefix(prefix string) *RedisDict {
+	return &RedisDict{client: r.client, ctx: r.ctx, prefix: prefix}
+}
+
+// Close closes the underlying client; every RedisDict created with WithPrefix from it becomes unusable
+func (r *RedisDict) Close() error {
+	return r.client.Close()
+}
+
+// key returns the namespaced Redis key
+func (r *RedisDict) key(key string) string {
+	return r.prefix + key
+}
+
+// PoolStats returns the connection pool statistics of the underlying client
+func (r *RedisDict) PoolStats() *redis.PoolStats {
+	return r.client.PoolStats()
+}
+
+// Ping checks that the Redis server is reachable
+func (r *RedisDict) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Get retrieves and converts a value from Redis by key
 func (r *RedisDict) Get(key string) (interface{}, error) {
-	value, err := r.client.Get(r.ctx, key).Result()
+	value, err := r.client.Get(r.ctx, r.key(key)).Result()
 	if errors.Is(err, redis.Nil) {
 		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
+	return DecodeRedisValue(value), nil
+}
+
+// Set stores a key-value pair in Redis, handling different types
+func (r *RedisDict) Set(key string, value interface{}) error {
+	stringValue, err := EncodeRedisValue(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, r.key(key), stringValue, 0).Err()
+}
 
+// DecodeRedisValue converts a stored string back to bool, number, JSON or the original string
+func DecodeRedisValue(value string) interface{} {
 	// Attempt to convert the value to the appropriate type
 	if value == "true" {
-		return true, nil
+		return true
 	} else if value == "false" {
-		return false, nil
+		return false
 	} else if value == "null" {
-		return nil, nil
+		return nil
 	}
 
 	// Try to convert to float or integer
 	if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
 		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue, nil
+			return intValue
 		}
-		return floatValue, nil
+		return floatValue
 	}
 
 	// Try to parse JSON
 	var jsonData interface{}
 	if err := json.Unmarshal([]byte(value), &jsonData); err == nil {
-		return jsonData, nil
+		return jsonData
 	}
 
 	// If all conversions fail, return the original string
-	return value, nil
+	return value
 }
 
-// Set stores a key-value pair in Redis, handling different types
-func (r *RedisDict) Set(key string, value interface{}) error {
-	var stringValue string
-
+// EncodeRedisValue converts a value to the string stored in Redis
+func EncodeRedisValue(value interface{}) (string, error) {
 	switch v := value.(type) {
 	case bool:
 		if v {
-			stringValue = "true"
-		} else {
-			stringValue = "false"
+			return "true", nil
 		}
+		return "false", nil
 	case nil:
-		stringValue = "null"
+		return "null", nil
 	case int:
-		stringValue = strconv.Itoa(v)
+		return strconv.Itoa(v), nil
 	case int32:
-		stringValue = strconv.Itoa(int(v))
+		return strconv.Itoa(int(v)), nil
 	case int64:
-		stringValue = strconv.FormatInt(v, 10)
+		return strconv.FormatInt(v, 10), nil
 	case float32:
-		stringValue = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
 	case float64:
-		stringValue = strconv.FormatFloat(v, 'f', -1, 64)
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
 	case string:
-		stringValue = v
-	default:
-		// Marshal to JSON if it's a complex type (e.g., map or list)
-		jsonBytes, err := json.Marshal(value)
-		if err != nil {
-			return err
-		}
-		stringValue = string(jsonBytes)
+		return v, nil
 	}
-
-	return r.client.Set(r.ctx, key, stringValue, 0).Err()
+	// Marshal to JSON if it's a complex type (e.g., map or list)
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
 }
 
 // Delete removes a key from Redis
 func (r *RedisDict) Delete(key string) error {
-	return r.client.Del(r.ctx, key).Err()
+	return r.client.Del(r.ctx, r.key(key)).Err()
 }
 
 // Exists checks if a key exists in Redis
 func (r *RedisDict) Exists(key string) (bool, error) {
-	count, err := r.client.Exists(r.ctx, key).Result()
+	count, err := r.client.Exists(r.ctx, r.key(key)).Result()
 	return count > 0, err
 }
 
-// Keys retrieves all keys in Redis
+// Keys retrieves all keys under the prefix, with the prefix stripped
 func (r *RedisDict) Keys() ([]string, error) {
-	return r.client.Keys(r.ctx, "*").Result()
+	var keys []string
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, r.prefix+"*", 1000).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, strings.TrimPrefix(iter.Val(), r.prefix))
+		}
+		return iter.Err()
+	}
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		err := cluster.ForEachMaster(r.ctx, func(ctx context.Context, master *redis.Client) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return scan(ctx, master)
+		})
+		return keys, err
+	}
+	return keys, scan(r.ctx, r.client)
 }
 
 // Pop retrieves a value by key and deletes the key from Redis
@@ -124,7 +233,7 @@ func (r *RedisDict) Pop(key string) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, err := r.client.Del(r.ctx, key).Result(); err != nil {
+	if _, err := r.client.Del(r.ctx, r.key(key)).Result(); err != nil {
 		return nil, err
 	}
 	return value, nil
@@ -0,0 +1,121 @@
+package dbservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// StreamAdd 向 stream 追加一条消息，maxLen 大于 0 时按近似长度裁剪旧消息，返回消息 ID
+func (r *RedisDict) StreamAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.key(stream),
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Result()
+}
+
+// StreamEnsureGroup 创建消费组（stream 不存在时一并创建），新组从 stream 中最早的消息开始消费；组已存在时忽略
+func (r *RedisDict) StreamEnsureGroup(ctx context.Context, stream, group string) error {
+	err := r.client.XGroupCreateMkStream(ctx, r.key(stream), group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// StreamReadGroup 以 consumer 身份读取组内尚未投递的消息，最多阻塞 block；没有消息时返回空切片
+func (r *RedisDict) StreamReadGroup(
+	ctx context.Context, stream, group, consumer string, count int64, block time.Duration,
+) ([]redis.XMessage, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{r.key(stream), ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var messages []redis.XMessage
+	for _, s := range streams {
+		messages = append(messages, s.Messages...)
+	}
+	return messages, nil
+}
+
+// StreamClaim 将组内超过 minIdle 未确认的消息转给 consumer 重新处理，
+// 用于接管崩溃或失联消费者已读取但未确认的消息。
+// Redis 7 的 XAUTOCLAIM 回复为三个元素，go-redis v8 的 XAutoClaim 无法解析，因此直接发送命令
+func (r *RedisDict) StreamClaim(
+	ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64,
+) ([]redis.XMessage, error) {
+	reply, err := r.client.Do(ctx, "XAUTOCLAIM", r.key(stream), group, consumer,
+		minIdle.Milliseconds(), "0-0", "COUNT", count).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply of %d elements", len(reply))
+	}
+	entries, _ := reply[1].([]interface{})
+	messages := make([]redis.XMessage, 0, len(entries))
+	for _, entry := range entries {
+		// 已被删除的消息在 Redis 6.2 中以 nil 返回
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 2 {
+			continue
+		}
+		id, _ := fields[0].(string)
+		pairs, _ := fields[1].([]interface{})
+		values := make(map[string]interface{}, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if name, ok := pairs[i].(string); ok {
+				values[name] = pairs[i+1]
+			}
+		}
+		messages = append(messages, redis.XMessage{ID: id, Values: values})
+	}
+	return messages, nil
+}
+
+// StreamAck 确认消息已处理，确认后不再重投
+func (r *RedisDict) StreamAck(ctx context.Context, stream, group string, ids ...string) error {
+	return r.client.XAck(ctx, r.key(stream), group, ids...).Err()
+}
+
+// StreamLag 返回消费组的积压量：尚未投递的消息数（Redis 7 起由 XINFO GROUPS 提供）加上已投递未确认的消息数
+func (r *RedisDict) StreamLag(ctx context.Context, stream, group string) (int64, error) {
+	groups, err := r.client.Do(ctx, "XINFO", "GROUPS", r.key(stream)).Slice()
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range groups {
+		fields, ok := item.([]interface{})
+		if !ok {
+			continue
+		}
+		info := map[string]interface{}{}
+		for i := 0; i+1 < len(fields); i += 2 {
+			if name, ok := fields[i].(string); ok {
+				info[name] = fields[i+1]
+			}
+		}
+		if info["name"] != group {
+			continue
+		}
+		pending, _ := info["pending"].(int64)
+		lag, _ := info["lag"].(int64)
+		return lag + pending, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,134 @@
+package event_catalog_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+var (
+	// ErrUnknownEventType 上传的事件不在事件目录中
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrInvalidPayload 事件的 Payload 不符合其类型的 PayloadSchema
+	ErrInvalidPayload = errors.New("invalid event payload")
+	// ErrInvalidEventType 新增或修改的事件类型定义不合法
+	ErrInvalidEventType = errors.New("invalid event type")
+	// ErrSystemEventType 服务自身发出的事件类型不能删除
+	ErrSystemEventType = errors.New("system event type cannot be deleted")
+)
+
+// Catalog 带进程内缓存的事件目录。缓存每 Refresh 重新加载一次，本实例的修改立即生效，
+// 其他实例的修改至多延迟 Refresh；Refresh 为 0 时每次查询都读取存储
+type Catalog struct {
+	Repo     repository_service.EventCatalog
+	Refresh  time.Duration
+	mutex    sync.Mutex
+	types    map[string]event_catalog_model.EventType
+	loadedAt time.Time
+}
+
+// NewCatalog 创建事件目录，首次查询时加载
+func NewCatalog(repo repository_service.EventCatalog, refresh time.Duration) *Catalog {
+	return &Catalog{Repo: repo, Refresh: refresh}
+}
+
+// Lookup 返回 code 对应的事件类型，不存在时第二个返回值为 false
+func (c *Catalog) Lookup(ctx context.Context, code string) (event_catalog_model.EventType, bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.types == nil || time.Since(c.loadedAt) >= c.Refresh {
+		eventTypes, err := c.Repo.ListEventTypes(ctx)
+		if err != nil {
+			return event_catalog_model.EventType{}, false, err
+		}
+		c.types = make(map[string]event_catalog_model.EventType, len(eventTypes))
+		for _, eventType := range eventTypes {
+			c.types[eventType.Code] = eventType
+		}
+		c.loadedAt = time.Now()
+	}
+	eventType, ok := c.types[code]
+	return eventType, ok, nil
+}
+
+// Validate 检查事件类型是否在目录中、Payload 是否符合 PayloadSchema，通过后按目录填写 Severity。
+// 未携带 Payload 时按 JSON null 校验，PayloadSchema 要求对象的类型因此必须携带 Payload
+func (c *Catalog) Validate(ctx context.Context, event *data_flow_model.AircraftEvent) error {
+	eventType, ok, err := c.Lookup(ctx, event.Event)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEventType, event.Event)
+	}
+	hasPayload := len(event.Payload) > 0 && string(event.Payload) != "null"
+	if len(eventType.PayloadSchema) == 0 {
+		if hasPayload {
+			return fmt.Errorf("%w: %s does not accept a payload", ErrInvalidPayload, eventType.Code)
+		}
+	} else {
+		payload := event.Payload
+		if !hasPayload {
+			payload = []byte("null")
+		}
+		if err = utils.ValidateJSONSchema(eventType.PayloadSchema, payload); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+	}
+	if !hasPayload {
+		event.Payload = nil
+	}
+	event.Severity = eventType.Severity
+	return nil
+}
+
+// List 从存储读取全部事件类型
+func (c *Catalog) List(ctx context.Context) ([]event_catalog_model.EventType, error) {
+	return c.Repo.ListEventTypes(ctx)
+}
+
+// Upsert 校验并保存事件类型定义，已存在时覆盖
+func (c *Catalog) Upsert(ctx context.Context, eventType *event_catalog_model.EventType) error {
+	if !event_catalog_model.CodePattern.MatchString(eventType.Code) {
+		return fmt.Errorf("%w: Code must match %s", ErrInvalidEventType, event_catalog_model.CodePattern)
+	}
+	if !event_catalog_model.IsValidSeverity(eventType.Severity) {
+		return fmt.Errorf("%w: Severity must be one of %v", ErrInvalidEventType, event_catalog_model.Severities)
+	}
+	if len(eventType.PayloadSchema) > 0 && string(eventType.PayloadSchema) != "null" {
+		if err := utils.CompileJSONSchema(eventType.PayloadSchema); err != nil {
+			return fmt.Errorf("%w: PayloadSchema: %v", ErrInvalidEventType, err)
+		}
+	} else {
+		eventType.PayloadSchema = nil
+	}
+	if err := c.Repo.UpsertEventType(ctx, eventType); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+// Delete 删除事件类型，不存在时返回 repository_service.ErrNotFound
+func (c *Catalog) Delete(ctx context.Context, code string) error {
+	if event_catalog_model.IsSystemEventType(code) {
+		return fmt.Errorf("%w: %s", ErrSystemEventType, code)
+	}
+	if err := c.Repo.DeleteEventType(ctx, code); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+func (c *Catalog) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.types = nil
+}
@@ -0,0 +1,165 @@
+package export_service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"uam-power-backend/models/controller_models/export_model"
+)
+
+const (
+	// eventLabelSeconds 事件标签在场景中的显示时长
+	eventLabelSeconds = 30
+)
+
+var czmlPalette = [][4]int{
+	{230, 25, 75, 255}, {60, 180, 75, 255}, {0, 130, 200, 255}, {245, 130, 48, 255},
+	{145, 30, 180, 255}, {70, 240, 240, 255}, {240, 50, 230, 255}, {210, 245, 60, 255},
+}
+
+// WriteCZML 将时间窗内的多条任务轨迹合成为一个 CZML 文档，用于 Cesium 统一回放
+func WriteCZML(w io.Writer, start, end time.Time, tracks []export_model.SceneTrack) error {
+	packets := []map[string]interface{}{{
+		"id":      "document",
+		"name":    fmt.Sprintf("UAM scene %s - %s", czmlTime(start), czmlTime(end)),
+		"version": "1.0",
+		"clock": map[string]interface{}{
+			"interval":    czmlInterval(start, end),
+			"currentTime": czmlTime(start),
+			"multiplier":  10,
+			"range":       "LOOP_STOP",
+			"step":        "SYSTEM_CLOCK_MULTIPLIER",
+		},
+	}}
+
+	for i, track := range tracks {
+		if len(track.Points) == 0 {
+			continue
+		}
+		color := czmlPalette[i%len(czmlPalette)]
+		id := fmt.Sprintf("task-%d", track.Task.TaskID)
+		epoch := track.Points[0].DataTime
+		positions := make([]float64, 0, len(track.Points)*4)
+		orientations := make([]float64, 0, len(track.Points)*5)
+		for _, p := range track.Points {
+			offset := p.DataTime.Sub(epoch).Seconds()
+			q := headingQuaternion(p.Longitude, p.Latitude, p.Yaw)
+			positions = append(positions, offset, p.Longitude, p.Latitude, p.Altitude)
+			orientations = append(orientations, offset, q[0], q[1], q[2], q[3])
+		}
+		last := track.Points[len(track.Points)-1].DataTime
+		packets = append(packets, map[string]interface{}{
+			"id":           id,
+			"name":         fmt.Sprintf("Aircraft %d / Task %d", track.Task.AircraftID, track.Task.TaskID),
+			"availability": czmlInterval(epoch, last),
+			"properties": map[string]interface{}{
+				"TaskID": track.Task.TaskID, "AircraftID": track.Task.AircraftID, "LaneID": track.Task.LaneID,
+			},
+			"position": map[string]interface{}{
+				"epoch":                  czmlTime(epoch),
+				"cartographicDegrees":    positions,
+				"interpolationAlgorithm": "LAGRANGE",
+				"interpolationDegree":    1,
+			},
+			"orientation": map[string]interface{}{
+				"epoch":          czmlTime(epoch),
+				"unitQuaternion": orientations,
+			},
+			"point": map[string]interface{}{
+				"pixelSize": 8,
+				"color":     map[string]interface{}{"rgba": color},
+			},
+			"path": map[string]interface{}{
+				"width":     2,
+				"leadTime":  0,
+				"trailTime": last.Sub(epoch).Seconds(),
+				"material": map[string]interface{}{
+					"solidColor": map[string]interface{}{"color": map[string]interface{}{"rgba": color}},
+				},
+			},
+			"label": map[string]interface{}{
+				"text":        fmt.Sprintf("Aircraft %d", track.Task.AircraftID),
+				"font":        "12pt sans-serif",
+				"pixelOffset": map[string]interface{}{"cartesian2": []int{0, -20}},
+			},
+		})
+
+		// 事件标签引用所属飞机的位置，跟随飞机移动
+		for j, event := range track.Events {
+			packets = append(packets, map[string]interface{}{
+				"id":           fmt.Sprintf("%s-event-%d", id, j),
+				"name":         event.Event,
+				"parent":       id,
+				"availability": czmlInterval(event.DataTime, event.DataTime.Add(eventLabelSeconds*time.Second)),
+				"position":     map[string]interface{}{"reference": id + "#position"},
+				"label": map[string]interface{}{
+					"text":           event.Event,
+					"font":           "11pt sans-serif",
+					"fillColor":      map[string]interface{}{"rgba": []int{255, 255, 0, 255}},
+					"showBackground": true,
+					"pixelOffset":    map[string]interface{}{"cartesian2": []int{0, 20}},
+					"verticalOrigin": "TOP",
+				},
+			})
+		}
+	}
+	return json.NewEncoder(w).Encode(packets)
+}
+
+// headingQuaternion 由经纬度与航向角（度，正北为 0 顺时针）计算 ECEF 下的姿态四元数 [x, y, z, w]，
+// 与 Cesium 的 Transforms.headingPitchRollQuaternion(position, HeadingPitchRoll(yaw, 0, 0)) 一致
+func headingQuaternion(lon, lat, yaw float64) [4]float64 {
+	lambda := lon * math.Pi / 180
+	phi := lat * math.Pi / 180
+	sinL, cosL := math.Sin(lambda), math.Cos(lambda)
+	sinP, cosP := math.Sin(phi), math.Cos(phi)
+	// 东-北-天坐标系到地固坐标系的旋转矩阵，列依次为 east、north、up
+	enu := [3][3]float64{
+		{-sinL, -sinP * cosL, cosP * cosL},
+		{cosL, -sinP * sinL, cosP * sinL},
+		{0, cosP, sinP},
+	}
+	frame := quaternionFromMatrix(enu)
+	half := -yaw * math.Pi / 360
+	heading := [4]float64{0, 0, math.Sin(half), math.Cos(half)}
+	return quaternionMultiply(frame, heading)
+}
+
+func quaternionFromMatrix(m [3][3]float64) [4]float64 {
+	var q [4]float64
+	trace := m[0][0] + m[1][1] + m[2][2]
+	switch {
+	case trace > 0:
+		s := math.Sqrt(trace+1) * 2
+		q = [4]float64{(m[2][1] - m[1][2]) / s, (m[0][2] - m[2][0]) / s, (m[1][0] - m[0][1]) / s, s / 4}
+	case m[0][0] > m[1][1] && m[0][0] > m[2][2]:
+		s := math.Sqrt(1+m[0][0]-m[1][1]-m[2][2]) * 2
+		q = [4]float64{s / 4, (m[0][1] + m[1][0]) / s, (m[0][2] + m[2][0]) / s, (m[2][1] - m[1][2]) / s}
+	case m[1][1] > m[2][2]:
+		s := math.Sqrt(1+m[1][1]-m[0][0]-m[2][2]) * 2
+		q = [4]float64{(m[0][1] + m[1][0]) / s, s / 4, (m[1][2] + m[2][1]) / s, (m[0][2] - m[2][0]) / s}
+	default:
+		s := math.Sqrt(1+m[2][2]-m[0][0]-m[1][1]) * 2
+		q = [4]float64{(m[0][2] + m[2][0]) / s, (m[1][2] + m[2][1]) / s, s / 4, (m[1][0] - m[0][1]) / s}
+	}
+	return q
+}
+
+func quaternionMultiply(a, b [4]float64) [4]float64 {
+	return [4]float64{
+		a[3]*b[0] + a[0]*b[3] + a[1]*b[2] - a[2]*b[1],
+		a[3]*b[1] - a[0]*b[2] + a[1]*b[3] + a[2]*b[0],
+		a[3]*b[2] + a[0]*b[1] - a[1]*b[0] + a[2]*b[3],
+		a[3]*b[3] - a[0]*b[0] - a[1]*b[1] - a[2]*b[2],
+	}
+}
+
+func czmlTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+func czmlInterval(start, end time.Time) string {
+	return czmlTime(start) + "/" + czmlTime(end)
+}
@@ -0,0 +1,268 @@
+package export_service
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/export_model"
+)
+
+const (
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+	FormatGPX     = "gpx"
+
+	xmlTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// ContentType 返回导出格式对应的 HTTP Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatGeoJSON:
+		return "application/geo+json"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	case FormatGPX:
+		return "application/gpx+xml"
+	}
+	return "application/octet-stream"
+}
+
+// IsValidFormat 判断导出格式是否受支持
+func IsValidFormat(format string) bool {
+	return format == FormatGeoJSON || format == FormatKML || format == FormatGPX
+}
+
+// IsValidAltitudeMode 判断 KML 高度模式是否合法
+func IsValidAltitudeMode(mode string) bool {
+	return mode == "absolute" || mode == "relativeToGround" || mode == "clampToGround"
+}
+
+// PointSource 按时间顺序逐点回调轨迹数据，可被多次调用（每次重新遍历，各次遍历应返回相同的轨迹点）
+type PointSource func(handle func(point export_model.TrackPoint) error) error
+
+// WriteTrack 按指定格式将任务轨迹与事件写入 w。events 按时间排序，
+// 以事件时刻前（含）最近的轨迹点（没有时取之后最近的）作为事件位置，在遍历轨迹时归并定位
+func WriteTrack(
+	w io.Writer, format string, task *aircraft_task_model.MysqlAircraftTask,
+	points PointSource, events []export_model.TrackEvent, altitudeMode string,
+) error {
+	switch format {
+	case FormatGeoJSON:
+		return WriteGeoJSON(w, task, points, events)
+	case FormatKML:
+		return WriteKML(w, task, points, events, altitudeMode)
+	case FormatGPX:
+		return WriteGPX(w, task, points, events)
+	}
+	return fmt.Errorf("unsupported export format: %s", format)
+}
+
+// eventLocator 随轨迹点的遍历归并定位按时间排序的事件，不保存轨迹
+type eventLocator struct {
+	events []export_model.TrackEvent
+	next   int
+	prev   *export_model.TrackPoint
+}
+
+func newEventLocator(events []export_model.TrackEvent) *eventLocator {
+	return &eventLocator{events: append([]export_model.TrackEvent(nil), events...)}
+}
+
+// add 处理下一个轨迹点：时刻早于 p 的事件取上一个轨迹点（没有时取 p）作为位置
+func (l *eventLocator) add(p export_model.TrackPoint) {
+	for ; l.next < len(l.events) && p.DataTime.After(l.events[l.next].DataTime); l.next++ {
+		located := p
+		if l.prev != nil {
+			located = *l.prev
+		}
+		l.events[l.next].Point = &located
+	}
+	l.prev = &p
+}
+
+// located 结束遍历，剩余事件取最后一个轨迹点，返回已定位的事件
+func (l *eventLocator) located() []export_model.TrackEvent {
+	for ; l.next < len(l.events); l.next++ {
+		if l.prev != nil {
+			located := *l.prev
+			l.events[l.next].Point = &located
+		}
+	}
+	return l.events
+}
+
+// WriteGeoJSON 以 FeatureCollection 输出轨迹：一条 LineString、每个轨迹点一个 Point 以及事件点，轨迹遍历两次
+func WriteGeoJSON(
+	w io.Writer, task *aircraft_task_model.MysqlAircraftTask,
+	points PointSource, events []export_model.TrackEvent,
+) error {
+	bw := bufio.NewWriter(w)
+	header, _ := json.Marshal(map[string]interface{}{
+		"TaskID": task.TaskID, "AircraftID": task.AircraftID, "LaneID": task.LaneID,
+	})
+	_, _ = fmt.Fprintf(bw, `{"type":"FeatureCollection","properties":%s,"features":[`, header)
+	_, _ = bw.WriteString(`{"type":"Feature","geometry":{"type":"LineString","coordinates":[`)
+	first := true
+	err := points(func(p export_model.TrackPoint) error {
+		if !first {
+			_ = bw.WriteByte(',')
+		}
+		first = false
+		_, err := bw.WriteString(geoJSONCoord(&p))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(bw, `]},"properties":{"Kind":"track","TaskID":%d}}`, task.TaskID)
+
+	locator := newEventLocator(events)
+	index := 0
+	err = points(func(p export_model.TrackPoint) error {
+		locator.add(p)
+		props, _ := json.Marshal(map[string]interface{}{
+			"Kind": "point", "Index": index, "DataTime": p.DataTime.Format(xmlTimeLayout),
+			"Yaw": p.Yaw, "Altitude": p.Altitude,
+		})
+		index++
+		_, err := fmt.Fprintf(bw, `,{"type":"Feature","geometry":{"type":"Point","coordinates":%s},"properties":%s}`,
+			geoJSONCoord(&p), props)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range locator.located() {
+		geometry := "null"
+		if event.Point != nil {
+			geometry = `{"type":"Point","coordinates":` + geoJSONCoord(event.Point) + `}`
+		}
+		props, _ := json.Marshal(map[string]interface{}{
+			"Kind": "event", "Event": event.Event, "DataTime": event.DataTime.Format(xmlTimeLayout),
+		})
+		_, _ = fmt.Fprintf(bw, `,{"type":"Feature","geometry":%s,"properties":%s}`, geometry, props)
+	}
+	_, _ = bw.WriteString("]}")
+	return bw.Flush()
+}
+
+// WriteKML 以 gx:Track 输出带时间戳与航向的轨迹，事件输出为带 TimeStamp 的 Placemark
+func WriteKML(
+	w io.Writer, task *aircraft_task_model.MysqlAircraftTask,
+	points PointSource, events []export_model.TrackEvent, altitudeMode string,
+) error {
+	if altitudeMode == "" {
+		altitudeMode = "absolute"
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString(xml.Header)
+	_, _ = bw.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">` + "\n")
+	_, _ = fmt.Fprintf(bw, "<Document><name>Task %d (Aircraft %d, Lane %d)</name>\n", task.TaskID, task.AircraftID, task.LaneID)
+	_, _ = fmt.Fprintf(bw, "<Placemark><name>Track %d</name>\n<gx:Track><altitudeMode>%s</altitudeMode>\n", task.TaskID, altitudeMode)
+
+	// gx:Track 要求先给出全部 when，再给出全部 gx:coord 与 gx:angles，因此分三次遍历；
+	// 三者数量须一致，遍历得到的点数不同时返回错误
+	locator := newEventLocator(events)
+	count := 0
+	err := points(func(p export_model.TrackPoint) error {
+		locator.add(p)
+		count++
+		_, err := fmt.Fprintf(bw, "<when>%s</when>\n", p.DataTime.Format(xmlTimeLayout))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	passes := []func(p export_model.TrackPoint) error{
+		func(p export_model.TrackPoint) error {
+			_, err := fmt.Fprintf(bw, "<gx:coord>%s %s %s</gx:coord>\n", formatFloat(p.Longitude), formatFloat(p.Latitude), formatFloat(p.Altitude))
+			return err
+		},
+		func(p export_model.TrackPoint) error {
+			_, err := fmt.Fprintf(bw, "<gx:angles>%s 0 0</gx:angles>\n", formatFloat(p.Yaw))
+			return err
+		},
+	}
+	for _, write := range passes {
+		written := 0
+		err = points(func(p export_model.TrackPoint) error {
+			written++
+			return write(p)
+		})
+		if err != nil {
+			return err
+		}
+		if written != count {
+			return fmt.Errorf("track changed during export: %d points, then %d", count, written)
+		}
+	}
+	_, _ = bw.WriteString("</gx:Track></Placemark>\n")
+
+	for _, event := range locator.located() {
+		_, _ = bw.WriteString("<Placemark><name>")
+		_ = xml.EscapeText(bw, []byte(event.Event))
+		_, _ = fmt.Fprintf(bw, "</name><TimeStamp><when>%s</when></TimeStamp>", event.DataTime.Format(xmlTimeLayout))
+		if event.Point != nil {
+			_, _ = fmt.Fprintf(bw, "<Point><altitudeMode>%s</altitudeMode><coordinates>%s,%s,%s</coordinates></Point>",
+				altitudeMode, formatFloat(event.Point.Longitude), formatFloat(event.Point.Latitude), formatFloat(event.Point.Altitude))
+		}
+		_, _ = bw.WriteString("</Placemark>\n")
+	}
+	_, _ = bw.WriteString("</Document></kml>\n")
+	return bw.Flush()
+}
+
+// WriteGPX 以 GPX 1.1 输出轨迹，事件输出为 wpt（GPX 要求 wpt 位于 trk 之前，因此先遍历一次轨迹定位事件）
+func WriteGPX(
+	w io.Writer, task *aircraft_task_model.MysqlAircraftTask,
+	points PointSource, events []export_model.TrackEvent,
+) error {
+	locator := newEventLocator(events)
+	err := points(func(p export_model.TrackPoint) error {
+		locator.add(p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString(xml.Header)
+	_, _ = bw.WriteString(`<gpx version="1.1" creator="uam-power-backend" xmlns="http://www.topografix.com/GPX/1/1">` + "\n")
+	_, _ = fmt.Fprintf(bw, "<metadata><name>Task %d (Aircraft %d, Lane %d)</name><time>%s</time></metadata>\n",
+		task.TaskID, task.AircraftID, task.LaneID, task.CreateTime.Format(xmlTimeLayout))
+	for _, event := range locator.located() {
+		if event.Point == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(bw, `<wpt lat="%s" lon="%s"><ele>%s</ele><time>%s</time><name>`,
+			formatFloat(event.Point.Latitude), formatFloat(event.Point.Longitude), formatFloat(event.Point.Altitude),
+			event.DataTime.Format(xmlTimeLayout))
+		_ = xml.EscapeText(bw, []byte(event.Event))
+		_, _ = bw.WriteString("</name><type>event</type></wpt>\n")
+	}
+	_, _ = fmt.Fprintf(bw, "<trk><name>Track %d</name><trkseg>\n", task.TaskID)
+	err = points(func(p export_model.TrackPoint) error {
+		_, err := fmt.Fprintf(bw, `<trkpt lat="%s" lon="%s"><ele>%s</ele><time>%s</time></trkpt>`+"\n",
+			formatFloat(p.Latitude), formatFloat(p.Longitude), formatFloat(p.Altitude), p.DataTime.Format(xmlTimeLayout))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = bw.WriteString("</trkseg></trk></gpx>\n")
+	return bw.Flush()
+}
+
+func geoJSONCoord(p *export_model.TrackPoint) string {
+	return "[" + formatFloat(p.Longitude) + "," + formatFloat(p.Latitude) + "," + formatFloat(p.Altitude) + "]"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,157 @@
+package health_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// CheckFunc 检查一个依赖是否可用，应遵守 ctx 的超时
+type CheckFunc func(ctx context.Context) error
+
+// ComponentStatus 单个组件的检查结果
+type ComponentStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report 健康检查汇总，任一组件不可用时 Status 为 DOWN
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+var (
+	mutex     sync.RWMutex
+	liveness  = map[string]CheckFunc{}
+	readiness = map[string]CheckFunc{}
+)
+
+// RegisterLiveness 登记存活检查，失败说明进程需要重启（如转发协程已停止）
+func RegisterLiveness(name string, check CheckFunc) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	liveness[name] = check
+}
+
+// RegisterReadiness 登记就绪检查，失败说明实例暂时不应接收流量（如依赖不可达）
+func RegisterReadiness(name string, check CheckFunc) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	readiness[name] = check
+}
+
+// CheckLiveness 执行全部存活检查
+func CheckLiveness(ctx context.Context, timeout time.Duration) Report {
+	mutex.RLock()
+	checks := copyChecks(liveness)
+	mutex.RUnlock()
+	return runChecks(ctx, timeout, checks)
+}
+
+// CheckReadiness 执行全部存活与就绪检查
+func CheckReadiness(ctx context.Context, timeout time.Duration) Report {
+	mutex.RLock()
+	checks := copyChecks(liveness)
+	for name, check := range readiness {
+		checks[name] = check
+	}
+	mutex.RUnlock()
+	return runChecks(ctx, timeout, checks)
+}
+
+func copyChecks(checks map[string]CheckFunc) map[string]CheckFunc {
+	copied := make(map[string]CheckFunc, len(checks))
+	for name, check := range checks {
+		copied[name] = check
+	}
+	return copied
+}
+
+// runChecks 并发执行检查，每项检查单独受 timeout 限制
+func runChecks(ctx context.Context, timeout time.Duration, checks map[string]CheckFunc) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(checks))}
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]ComponentStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check CheckFunc) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, timeout, check)
+		}(i, checks[name])
+	}
+	wg.Wait()
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, timeout time.Duration, check CheckFunc) ComponentStatus {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(checkCtx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+		// 不遵守 ctx 的检查也不会阻塞整体结果
+		err = checkCtx.Err()
+	}
+	status := ComponentStatus{Status: StatusUp, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Status, status.Error = StatusDown, err.Error()
+	}
+	return status
+}
+
+// Heartbeat 记录循环协程最近一次迭代的时间
+type Heartbeat struct {
+	last atomic.Int64
+}
+
+// NewHeartbeat 创建心跳，初始时间为当前时间
+func NewHeartbeat() *Heartbeat {
+	heartbeat := &Heartbeat{}
+	heartbeat.Beat()
+	return heartbeat
+}
+
+// Beat 在每次循环迭代时调用
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Last 返回最近一次心跳时间
+func (h *Heartbeat) Last() time.Time {
+	return time.Unix(0, h.last.Load())
+}
+
+// Check 返回心跳检查，超过 maxAge 未更新即视为协程停止
+func (h *Heartbeat) Check(maxAge time.Duration) CheckFunc {
+	return func(ctx context.Context) error {
+		if age := time.Since(h.Last()); age > maxAge {
+			return fmt.Errorf("no heartbeat for %s", age.Truncate(time.Millisecond))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,168 @@
+package metrics_service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "uam"
+
+var (
+	// HTTPRequestDuration HTTP 请求耗时，按方法、路由与状态码区分
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// UploadTotal 上传接口接收/拒绝的数量，kind 为 status 或 event
+	UploadTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "upload",
+		Name:      "total",
+		Help:      "Uploaded aircraft messages by result and reject reason.",
+	}, []string{"kind", "result", "reason"})
+
+	// KafkaProduceErrors Kafka 发送失败的消息数
+	KafkaProduceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "produce_errors_total",
+		Help:      "Messages that failed to be written to Kafka.",
+	}, []string{"topic"})
+
+	// PipelineMessages 数据转发服务处理的消息数
+	PipelineMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "messages_total",
+		Help:      "Messages consumed by the transfer services.",
+	}, []string{"service", "stream"})
+
+	// PipelineFailures 数据转发服务处理失败的消息数，按失败原因区分
+	PipelineFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "failures_total",
+		Help:      "Messages the transfer services failed to process, by reason.",
+	}, []string{"service", "stream", "reason"})
+
+	// PipelineDuration 单条消息从接收到写入完成的处理耗时
+	PipelineDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "processing_seconds",
+		Help:      "Time spent processing one consumed message.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"service", "stream"})
+
+	// PipelineDuplicates 去重窗口内重复、被丢弃的消息数
+	PipelineDuplicates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "duplicates_total",
+		Help:      "Messages dropped as duplicates within the dedup window.",
+	}, []string{"service", "stream"})
+
+	// PipelineStale 时间早于已存最新值、未覆盖最新状态的乱序消息数
+	PipelineStale = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "stale_total",
+		Help:      "Out-of-order messages older than the stored latest value.",
+	}, []string{"service", "stream"})
+
+	// LinkSequenceGaps 按序号检测到的缺失消息数，反映链路丢包情况
+	LinkSequenceGaps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "link",
+		Name:      "sequence_gaps_total",
+		Help:      "Messages missing according to per-aircraft sequence numbers.",
+	}, []string{"service", "stream"})
+
+	// LinkEvents 链路监测发出的事件数，event 为 LINK_LOST / LINK_RESTORED
+	LinkEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "link",
+		Name:      "events_total",
+		Help:      "Link lost / restored events raised by the link watchdog.",
+	}, []string{"event"})
+
+	// AlertTransitions 告警状态变化数，action 为 open / acknowledge / resolve / escalate
+	AlertTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "alert",
+		Name:      "transitions_total",
+		Help:      "Alert state changes by action.",
+	}, []string{"action"})
+
+	// WebhookDeliveries webhook 投递尝试结果，result 为 delivered / retry / failed
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Webhook delivery attempts by result.",
+	}, []string{"result"})
+
+	// RateLimitRejections 被限流拒绝的请求数，dimension 为受限的维度 aircraft / credential / ip
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejections_total",
+		Help:      "Requests rejected by the rate limiter.",
+	}, []string{"scope", "dimension"})
+
+	// ClockSkew 服务端接收状态的时间与上报时间之差的绝对值（秒），包含传输与排队延迟
+	ClockSkew = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "pipeline",
+		Name:      "clock_skew_seconds",
+		Help:      "Absolute difference between server receive time and reported status time.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	})
+
+	// TelemetryQuarantined 未通过合理性校验而被隔离的状态点，一个点有多项原因时每项各计一次
+	TelemetryQuarantined = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "validation",
+		Name:      "quarantined_total",
+		Help:      "Status points quarantined by plausibility checks, by reason.",
+	}, []string{"reason"})
+
+	// QuarantineReviews 隔离记录的审核次数，action 为 release 或 discard
+	QuarantineReviews = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "validation",
+		Name:      "reviews_total",
+		Help:      "Quarantine reviews by action.",
+	}, []string{"action"})
+
+	// RedisLookups Redis 读取结果，result 为 hit / miss / error
+	RedisLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "lookups_total",
+		Help:      "Redis lookups by component and result.",
+	}, []string{"component", "result"})
+)
+
+// Handler 返回 Prometheus 抓取接口的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRedisLookup 按 Get 的返回结果记录一次 Redis 读取
+func ObserveRedisLookup(component string, value interface{}, err error) {
+	switch {
+	case err != nil:
+		RedisLookups.WithLabelValues(component, "error").Inc()
+	case value == nil:
+		RedisLookups.WithLabelValues(component, "miss").Inc()
+	default:
+		RedisLookups.WithLabelValues(component, "hit").Inc()
+	}
+}
@@ -0,0 +1,129 @@
+package metrics_service
+
+import (
+	"database/sql"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+// poolCollector 在抓取时读取已登记的 MySQL/Redis 连接池、Kafka 消费者与活跃任务数
+type poolCollector struct {
+	mutex       sync.RWMutex
+	mysqlPools  map[string]*sql.DB
+	redisPools  map[string]func() *redis.PoolStats
+	consumers   map[[2]string]func() int64
+	activeTasks func() (int, error)
+
+	mysqlOpen    *prometheus.Desc
+	mysqlInUse   *prometheus.Desc
+	mysqlIdle    *prometheus.Desc
+	mysqlWait    *prometheus.Desc
+	mysqlMaxOpen *prometheus.Desc
+	redisTotal   *prometheus.Desc
+	redisIdle    *prometheus.Desc
+	redisHits    *prometheus.Desc
+	redisMisses  *prometheus.Desc
+	redisTimeout *prometheus.Desc
+	consumerLag  *prometheus.Desc
+	tasks        *prometheus.Desc
+}
+
+var pools = newPoolCollector()
+
+func init() {
+	prometheus.MustRegister(pools)
+}
+
+func newPoolCollector() *poolCollector {
+	desc := func(subsystem, name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
+	}
+	return &poolCollector{
+		mysqlPools:   map[string]*sql.DB{},
+		redisPools:   map[string]func() *redis.PoolStats{},
+		consumers:    map[[2]string]func() int64{},
+		mysqlOpen:    desc("mysql", "pool_open_connections", "Open connections in the MySQL pool.", "pool"),
+		mysqlInUse:   desc("mysql", "pool_in_use_connections", "MySQL connections currently in use.", "pool"),
+		mysqlIdle:    desc("mysql", "pool_idle_connections", "Idle MySQL connections.", "pool"),
+		mysqlWait:    desc("mysql", "pool_wait_total", "Times a caller waited for a MySQL connection.", "pool"),
+		mysqlMaxOpen: desc("mysql", "pool_max_open_connections", "Configured maximum of open MySQL connections.", "pool"),
+		redisTotal:   desc("redis", "pool_total_connections", "Connections in the Redis pool.", "pool"),
+		redisIdle:    desc("redis", "pool_idle_connections", "Idle Redis connections.", "pool"),
+		redisHits:    desc("redis", "pool_hits_total", "Times a free Redis connection was found in the pool.", "pool"),
+		redisMisses:  desc("redis", "pool_misses_total", "Times no free Redis connection was found in the pool.", "pool"),
+		redisTimeout: desc("redis", "pool_timeouts_total", "Times waiting for a Redis connection timed out.", "pool"),
+		consumerLag:  desc("kafka", "consumer_lag", "Messages behind the partition high watermark.", "topic", "group"),
+		tasks:        desc("", "active_tasks", "Flight tasks that are currently running."),
+	}
+}
+
+// RegisterMySQLPool 登记需要上报统计的 MySQL 连接池，同名登记会覆盖
+func RegisterMySQLPool(name string, db *sql.DB) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+	pools.mysqlPools[name] = db
+}
+
+// RegisterRedisPool 登记需要上报统计的 Redis 连接池，同名登记会覆盖
+func RegisterRedisPool(name string, stats func() *redis.PoolStats) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+	pools.redisPools[name] = stats
+}
+
+// RegisterKafkaConsumer 登记消费者，抓取时通过 lag 读取其在 topic/group 上的积压
+func RegisterKafkaConsumer(topic, group string, lag func() int64) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+	pools.consumers[[2]string{topic, group}] = lag
+}
+
+// RegisterActiveTasks 登记活跃任务数的统计方法
+func RegisterActiveTasks(count func() (int, error)) {
+	pools.mutex.Lock()
+	defer pools.mutex.Unlock()
+	pools.activeTasks = count
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		p.mysqlOpen, p.mysqlInUse, p.mysqlIdle, p.mysqlWait, p.mysqlMaxOpen,
+		p.redisTotal, p.redisIdle, p.redisHits, p.redisMisses, p.redisTimeout,
+		p.consumerLag, p.tasks,
+	} {
+		ch <- desc
+	}
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	for name, db := range p.mysqlPools {
+		stats := db.Stats()
+		ch <- prometheus.MustNewConstMetric(p.mysqlOpen, prometheus.GaugeValue, float64(stats.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(p.mysqlInUse, prometheus.GaugeValue, float64(stats.InUse), name)
+		ch <- prometheus.MustNewConstMetric(p.mysqlIdle, prometheus.GaugeValue, float64(stats.Idle), name)
+		ch <- prometheus.MustNewConstMetric(p.mysqlWait, prometheus.CounterValue, float64(stats.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(p.mysqlMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), name)
+	}
+	for name, statsFunc := range p.redisPools {
+		stats := statsFunc()
+		if stats == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(p.redisTotal, prometheus.GaugeValue, float64(stats.TotalConns), name)
+		ch <- prometheus.MustNewConstMetric(p.redisIdle, prometheus.GaugeValue, float64(stats.IdleConns), name)
+		ch <- prometheus.MustNewConstMetric(p.redisHits, prometheus.CounterValue, float64(stats.Hits), name)
+		ch <- prometheus.MustNewConstMetric(p.redisMisses, prometheus.CounterValue, float64(stats.Misses), name)
+		ch <- prometheus.MustNewConstMetric(p.redisTimeout, prometheus.CounterValue, float64(stats.Timeouts), name)
+	}
+	for key, lag := range p.consumers {
+		ch <- prometheus.MustNewConstMetric(p.consumerLag, prometheus.GaugeValue, float64(lag()), key[0], key[1])
+	}
+	if p.activeTasks != nil {
+		if count, err := p.activeTasks(); err == nil {
+			ch <- prometheus.MustNewConstMetric(p.tasks, prometheus.GaugeValue, float64(count))
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package migration_service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLock/migrationLockTimeoutSec 多个实例同时迁移时互斥的 MySQL 命名锁及最长等待秒数
+const (
+	migrationLock           = "uam_migrations"
+	migrationLockTimeoutSec = 300
+)
+
+// Migration 一个版本的迁移，Up/Down 为已渲染的 SQL 语句列表
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// MigrationStatus 迁移的应用状态
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	MysqlService *dbservice.MySQLService
+	Migrations   []Migration
+	historyTable string
+}
+
+// NewMigrator 连接 MySQL（不指定默认库，以便在全新实例上建库）并加载内嵌的迁移脚本
+func NewMigrator(MySqlCfg *db_config_model.MySqlConfigModel) (*Migrator, error) {
+	migrations, err := LoadMigrations(MySqlCfg)
+	if err != nil {
+		return nil, err
+	}
+	MysqlService, err := dbservice.NewMySQLService(utils.MySqlDSN(MySqlCfg, ""))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		MysqlService: MysqlService,
+		Migrations:   migrations,
+		historyTable: fmt.Sprintf("`%s`.schema_migrations", MySqlCfg.DB),
+	}, nil
+}
+
+// LoadMigrations 读取内嵌迁移脚本，用库名渲染模板并按版本排序
+func LoadMigrations(MySqlCfg *db_config_model.MySqlConfigModel) ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		raw, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tpl, err := template.New(entry.Name()).Parse(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("parse migration %s: %w", entry.Name(), err)
+		}
+		var rendered bytes.Buffer
+		if err = tpl.Execute(&rendered, MySqlCfg); err != nil {
+			return nil, fmt.Errorf("render migration %s: %w", entry.Name(), err)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = splitStatements(rendered.String())
+		} else {
+			migration.Down = splitStatements(rendered.String())
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == nil {
+			return nil, fmt.Errorf("migration %d has no up script", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements 按行尾分号拆分 SQL 语句，忽略 -- 注释行
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+func (m *Migrator) ensureHistoryTable() error {
+	database := strings.Split(m.historyTable, ".")[0]
+	if _, err := m.MysqlService.ExecuteCmd("CREATE DATABASE IF NOT EXISTS " + database + " DEFAULT CHARACTER SET utf8mb4;"); err != nil {
+		return err
+	}
+	_, err := m.MysqlService.ExecuteCmd("CREATE TABLE IF NOT EXISTS " + m.historyTable + ` (
+    Version   INT          NOT NULL,
+    Name      VARCHAR(255) NOT NULL,
+    AppliedAt DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (Version)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;`)
+	return err
+}
+
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	if err := m.ensureHistoryTable(); err != nil {
+		return nil, err
+	}
+	applied := map[int]time.Time{}
+	err := m.MysqlService.QueryEach("SELECT Version, AppliedAt FROM "+m.historyTable+";", func(rows *sql.Rows) error {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return err
+		}
+		applied[version] = appliedAt
+		return nil
+	})
+	return applied, err
+}
+
+// withLock 在一条专用连接上持有 migrationLock 期间执行 fn。多个实例同时启动时依次迁移，
+// fn 须在取得锁之后重新读取已应用的迁移
+func (m *Migrator) withLock(fn func() (int, error)) (int, error) {
+	ctx := context.Background()
+	conn, err := m.MysqlService.DB().Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	var acquired sql.NullInt64
+	if err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?);", migrationLock, migrationLockTimeoutSec).Scan(&acquired); err != nil {
+		return 0, fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return 0, fmt.Errorf("timed out after %ds waiting for migration lock %s", migrationLockTimeoutSec, migrationLock)
+	}
+	defer func() {
+		var released sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?);", migrationLock).Scan(&released); err != nil {
+			utils.MsgError("        [Migrator]failed to release migration lock > " + err.Error())
+			// 丢弃该连接，会话结束时 MySQL 释放其持有的锁，避免锁随连接留在连接池中
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+	return fn()
+}
+
+// Up 依次应用全部未应用的迁移，返回本次应用的数量；多个实例同时调用时由 migrationLock 串行执行
+func (m *Migrator) Up() (int, error) {
+	return m.withLock(m.up)
+}
+
+func (m *Migrator) up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		for _, statement := range migration.Up {
+			if _, err = m.MysqlService.ExecuteCmd(statement); err != nil {
+				return count, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+		}
+		_, err = m.MysqlService.ExecuteCmd("INSERT INTO "+m.historyTable+" (Version, Name) VALUES (?, ?);",
+			migration.Version, migration.Name)
+		if err != nil {
+			return count, err
+		}
+		utils.MsgSuccess(fmt.Sprintf("        [Migrator]applied %04d_%s", migration.Version, migration.Name))
+		count++
+	}
+	return count, nil
+}
+
+// Down 按版本倒序回滚最近 steps 个已应用的迁移，返回回滚的数量；与 Up 共用 migrationLock
+func (m *Migrator) Down(steps int) (int, error) {
+	return m.withLock(func() (int, error) { return m.down(steps) })
+}
+
+func (m *Migrator) down(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		for _, statement := range migration.Down {
+			if _, err = m.MysqlService.ExecuteCmd(statement); err != nil {
+				return count, fmt.Errorf("rollback %04d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+		}
+		_, err = m.MysqlService.ExecuteCmd("DELETE FROM "+m.historyTable+" WHERE Version = ?;", migration.Version)
+		if err != nil {
+			return count, err
+		}
+		utils.MsgSuccess(fmt.Sprintf("        [Migrator]rolled back %04d_%s", migration.Version, migration.Name))
+		count++
+	}
+	return count, nil
+}
+
+// Status 返回每个迁移的应用状态
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Close 关闭迁移使用的数据库连接
+func (m *Migrator) Close() error {
+	return m.MysqlService.Close()
+}
+
+// RunCommand 执行命令行迁移指令：up / down / status
+func (m *Migrator) RunCommand(command string, steps int) error {
+	switch command {
+	case "up":
+		count, err := m.Up()
+		utils.MsgInfo(fmt.Sprintf("        [Migrator]%d migrations applied", count))
+		return err
+	case "down":
+		count, err := m.Down(steps)
+		utils.MsgInfo(fmt.Sprintf("        [Migrator]%d migrations rolled back", count))
+		return err
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			utils.MsgInfo(fmt.Sprintf("        [Migrator]%04d_%s %s", status.Version, status.Name, state))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q, expected up/down/status", command)
+}
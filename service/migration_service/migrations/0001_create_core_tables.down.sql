@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `{{.DB}}`.flight_task_table;
+DROP TABLE IF EXISTS `{{.DB}}`.aircraft_identity_table;
@@ -0,0 +1,32 @@
+-- 系统库、轨迹库、事件库；每个任务的轨迹表与事件表由 /aircraftTask/create 动态创建
+CREATE DATABASE IF NOT EXISTS `{{.FlightDB}}` DEFAULT CHARACTER SET utf8mb4;
+CREATE DATABASE IF NOT EXISTS `{{.EventDB}}` DEFAULT CHARACTER SET utf8mb4;
+
+-- 飞行器身份注册表，由 /aircraftID/create 写入，TimeStr 为创建时生成的批次标识
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.aircraft_identity_table (
+    AircraftID INT NOT NULL AUTO_INCREMENT,
+    Type       VARCHAR(64)  NOT NULL,
+    Company    VARCHAR(128) NOT NULL,
+    Name       VARCHAR(128) NOT NULL,
+    TimeStr    VARCHAR(32)  NOT NULL,
+    CreateTime DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (AircraftID),
+    KEY idx_aircraft_timestr (TimeStr)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 飞行任务表，TrackTable/EventTable 指向 FlightDB/EventDB 中该任务的数据表。
+-- 与原有表结构一致，AircraftID 不设外键，允许为未登记的飞行器创建任务
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.flight_task_table (
+    TaskID     INT NOT NULL AUTO_INCREMENT,
+    AircraftID INT NOT NULL,
+    LaneID     INT NOT NULL,
+    TrackTable VARCHAR(128) NOT NULL,
+    EventTable VARCHAR(128) NOT NULL,
+    TimeStr    VARCHAR(32)  NOT NULL,
+    CreateTime DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    EndTime    DATETIME(6)  NULL,
+    PRIMARY KEY (TaskID),
+    KEY idx_task_timestr (TimeStr),
+    KEY idx_task_aircraft (AircraftID, CreateTime),
+    KEY idx_task_window (CreateTime, EndTime)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
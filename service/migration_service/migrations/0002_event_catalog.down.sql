@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `{{.DB}}`.event_type_table;
@@ -0,0 +1,21 @@
+-- 事件类型目录：上传的事件须为目录中的类型，Payload 须符合 PayloadSchema（JSON Schema 子集）；
+-- 已有任务的事件表在首次写入时补齐 Severity、Payload 列
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.event_type_table (
+    Code          VARCHAR(64)  NOT NULL,
+    Severity      VARCHAR(16)  NOT NULL,
+    Description   VARCHAR(255) NOT NULL DEFAULT '',
+    PayloadSchema JSON         NULL,
+    UpdateTime    DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (Code),
+    KEY idx_event_type_severity (Severity)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 内置事件类型，与 event_catalog_model.BuiltinEventTypes 保持一致
+INSERT IGNORE INTO `{{.DB}}`.event_type_table (Code, Severity, Description, PayloadSchema) VALUES
+    ('LINK_LOST', 'warning', '飞行器超过链路超时时间未上报状态', NULL),
+    ('LINK_RESTORED', 'info', '失联的飞行器恢复上报', NULL),
+    ('TAKEOFF', 'info', '起飞', NULL),
+    ('LANDING', 'info', '降落', NULL),
+    ('LOW_BATTERY', 'warning', '电量低',
+     '{"type":"object","properties":{"Battery":{"type":"number","minimum":0,"maximum":100}},"required":["Battery"]}'),
+    ('EMERGENCY', 'critical', '紧急情况', NULL);
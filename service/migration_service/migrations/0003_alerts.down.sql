@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `{{.DB}}`.alert_audit_table;
+DROP TABLE IF EXISTS `{{.DB}}`.alert_table;
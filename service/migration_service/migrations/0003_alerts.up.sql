@@ -0,0 +1,35 @@
+-- 告警：由 AlertCfg 配置的事件生成，同一飞行器同一时间的同一事件只生成一条（重投的消息不会重复生成）
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.alert_table (
+    AlertID         INT NOT NULL AUTO_INCREMENT,
+    AircraftID      INT          NOT NULL,
+    Event           VARCHAR(64)  NOT NULL,
+    Severity        VARCHAR(16)  NOT NULL,
+    EventTime       DATETIME(6)  NOT NULL,
+    Payload         JSON         NULL,
+    Status          VARCHAR(16)  NOT NULL,
+    Assignee        VARCHAR(128) NOT NULL DEFAULT '',
+    EscalationLevel INT          NOT NULL DEFAULT 0,
+    CreateTime      DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UpdateTime      DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    AckTime         DATETIME(6)  NULL,
+    ResolveTime     DATETIME(6)  NULL,
+    PRIMARY KEY (AlertID),
+    UNIQUE KEY uk_alert_event (AircraftID, Event, EventTime),
+    KEY idx_alert_status (Status, CreateTime)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- 告警审计：每次状态变化（生成、确认、解决、升级）一条，与状态变化在同一事务中写入
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.alert_audit_table (
+    AuditID    INT NOT NULL AUTO_INCREMENT,
+    AlertID    INT           NOT NULL,
+    Action     VARCHAR(16)   NOT NULL,
+    FromStatus VARCHAR(16)   NOT NULL DEFAULT '',
+    ToStatus   VARCHAR(16)   NOT NULL,
+    Operator   VARCHAR(128)  NOT NULL,
+    Note       VARCHAR(1024) NOT NULL DEFAULT '',
+    CreateTime DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (AuditID),
+    KEY idx_audit_alert (AlertID, AuditID),
+    CONSTRAINT fk_audit_alert FOREIGN KEY (AlertID)
+        REFERENCES `{{.DB}}`.alert_table (AlertID)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
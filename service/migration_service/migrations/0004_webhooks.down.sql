@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `{{.DB}}`.webhook_delivery_table;
+DROP TABLE IF EXISTS `{{.DB}}`.webhook_subscription_table;
@@ -0,0 +1,33 @@
+-- webhook 订阅：EventTypes 为接收的通知类型（TASK_STARTED、TASK_ENDED 或事件类型代码），空数组表示全部
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.webhook_subscription_table (
+    SubscriptionID INT NOT NULL AUTO_INCREMENT,
+    URL            VARCHAR(2048) NOT NULL,
+    Secret         VARCHAR(256)  NOT NULL,
+    EventTypes     JSON          NOT NULL,
+    Description    VARCHAR(256)  NOT NULL DEFAULT '',
+    CreateTime     DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (SubscriptionID)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- webhook 投递记录：每条通知对每个订阅一条，失败后按退避时间更新 NextAttemptTime 重试；
+-- 同一订阅的同一通知只记录一次（重投的事件不会重复投递），取出待投递记录依赖 MySQL 8.0 的 SKIP LOCKED
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.webhook_delivery_table (
+    DeliveryID      BIGINT NOT NULL AUTO_INCREMENT,
+    SubscriptionID  INT           NOT NULL,
+    NotificationID  VARCHAR(191)  NOT NULL,
+    Type            VARCHAR(64)   NOT NULL,
+    Body            JSON          NOT NULL,
+    Status          VARCHAR(16)   NOT NULL,
+    Attempts        INT           NOT NULL DEFAULT 0,
+    NextAttemptTime DATETIME(6)   NOT NULL,
+    LastStatusCode  INT           NOT NULL DEFAULT 0,
+    LastError       VARCHAR(1024) NOT NULL DEFAULT '',
+    CreateTime      DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UpdateTime      DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+    DeliveredTime   DATETIME(6)   NULL,
+    PRIMARY KEY (DeliveryID),
+    UNIQUE KEY uk_delivery_notification (SubscriptionID, NotificationID),
+    KEY idx_delivery_due (Status, NextAttemptTime),
+    CONSTRAINT fk_delivery_subscription FOREIGN KEY (SubscriptionID)
+        REFERENCES `{{.DB}}`.webhook_subscription_table (SubscriptionID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `{{.DB}}`.telemetry_quarantine_table;
@@ -0,0 +1,17 @@
+-- 隔离区：未通过合理性校验的状态点不写入轨迹表，连同原因保存在此等待审核；
+-- released 的点已重新投递到状态 topic，discarded 的点不再处理
+CREATE TABLE IF NOT EXISTS `{{.DB}}`.telemetry_quarantine_table (
+    QuarantineID INT NOT NULL AUTO_INCREMENT,
+    AircraftID   INT           NOT NULL,
+    TimeString   VARCHAR(32)   NOT NULL,
+    Point        JSON          NOT NULL,
+    Reasons      JSON          NOT NULL,
+    Status       VARCHAR(16)   NOT NULL,
+    Reviewer     VARCHAR(128)  NOT NULL DEFAULT '',
+    Note         VARCHAR(1024) NOT NULL DEFAULT '',
+    CreateTime   DATETIME(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    ReviewTime   DATETIME(6)   NULL,
+    PRIMARY KEY (QuarantineID),
+    KEY idx_quarantine_status (Status, CreateTime),
+    KEY idx_quarantine_aircraft (AircraftID, CreateTime)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
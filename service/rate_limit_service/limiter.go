@@ -0,0 +1,204 @@
+package rate_limit_service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/rate_limit_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+// dateLayout 按天统计用量的日期格式（UTC）
+const dateLayout = "2006-01-02"
+
+// usagePrefix 全部 key 每日用量统计 HASH 的前缀，不带 hash tag，与各 key 的令牌桶分开更新
+const usagePrefix = "rl:usage:"
+
+// Subject 一次请求在某一维度上的 key，如飞行器 ID、凭证摘要或客户端 IP
+type Subject struct {
+	Dimension string
+	Key       string
+}
+
+// Decision 限流判断结果，拒绝时 Dimension 为受限的维度
+type Decision struct {
+	Allowed    bool
+	Dimension  string
+	RetryAfter time.Duration
+}
+
+// Limiter 基于 Redis 令牌桶的限流器，同一 key 在全部实例间共享限额
+type Limiter struct {
+	Store  *dbservice.RedisDict
+	Config *db_config_model.RateLimitConfigModel
+	// Now 当前时间，测试中可替换
+	Now func() time.Time
+}
+
+// NewLimiter 由带 RateLimitPrefix 前缀的 Redis 创建限流器，RateLimitCfg.Enable 为 false 时返回 nil
+func NewLimiter(store *dbservice.RedisDict, RateLimitConfig *db_config_model.RateLimitConfigModel) *Limiter {
+	if !RateLimitConfig.Enable {
+		return nil
+	}
+	return &Limiter{Store: store, Config: RateLimitConfig, Now: time.Now}
+}
+
+// CredentialKey 返回 API 凭证的摘要，Redis 与用量统计中只保存摘要
+func CredentialKey(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Rules 返回 scope 对应的规则，未知的 scope 返回 nil
+func (l *Limiter) Rules(scope string) *db_config_model.RateLimitScopeModel {
+	switch scope {
+	case rate_limit_model.ScopeUpload:
+		return &l.Config.Upload
+	case rate_limit_model.ScopeQuery:
+		return &l.Config.Query
+	}
+	return nil
+}
+
+func rule(rules *db_config_model.RateLimitScopeModel, dimension string) *db_config_model.RateLimitRule {
+	switch dimension {
+	case rate_limit_model.DimensionAircraft:
+		return &rules.Aircraft
+	case rate_limit_model.DimensionCredential:
+		return &rules.Credential
+	case rate_limit_model.DimensionIP:
+		return &rules.IP
+	}
+	return nil
+}
+
+func usageKey(day time.Time) string {
+	return usagePrefix + day.UTC().Format(dateLayout)
+}
+
+// requestTag 返回一次请求各维度的令牌桶与配额计数共用的 hash tag，由发起请求的客户端决定：
+// 带凭证时为凭证摘要，否则为客户端 IP。同一请求的 key 落在同一 slot 以便原子判断，不同客户端分散到各个 slot
+func requestTag(scope string, subjects []Subject) string {
+	var client Subject
+	for _, subject := range subjects {
+		if subject.Key == "" {
+			continue
+		}
+		if subject.Dimension == rate_limit_model.DimensionCredential ||
+			(subject.Dimension == rate_limit_model.DimensionIP && client.Dimension != rate_limit_model.DimensionCredential) ||
+			client.Key == "" {
+			client = subject
+		}
+	}
+	return "{rl:" + scope + "|" + client.Dimension + "|" + client.Key + "}"
+}
+
+// Allow 判断 scope 下的一次请求是否放行：subjects 中 key 非空且配置了规则的维度须同时满足，
+// 放行时各扣减一个令牌，拒绝时不扣减。令牌桶与配额按客户端（见 requestTag）分别计数，
+// 如同一飞行器经不同凭证上报时各凭证下的飞行器限额相互独立
+func (l *Limiter) Allow(scope string, subjects []Subject) (Decision, error) {
+	rules := l.Rules(scope)
+	if rules == nil {
+		return Decision{Allowed: true}, nil
+	}
+	now := l.Now()
+	tag := requestTag(scope, subjects)
+	var buckets []dbservice.TokenBucket
+	var dimensions []string
+	for _, subject := range subjects {
+		dimensionRule := rule(rules, subject.Dimension)
+		if subject.Key == "" || dimensionRule == nil || (dimensionRule.Rate <= 0 && dimensionRule.DailyQuota <= 0) {
+			continue
+		}
+		field := scope + "|" + subject.Dimension + "|" + subject.Key
+		buckets = append(buckets, dbservice.TokenBucket{
+			Key: tag + ":bucket:" + field, QuotaKey: tag + ":quota:" + now.UTC().Format(dateLayout) + ":" + field, UsageField: field,
+			Rate: dimensionRule.Rate, Burst: dimensionRule.Burst, DailyQuota: dimensionRule.DailyQuota,
+		})
+		dimensions = append(dimensions, subject.Dimension)
+	}
+	if len(buckets) == 0 {
+		return Decision{Allowed: true}, nil
+	}
+	midnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	result, err := l.Store.TakeTokens(buckets, now, midnight.Sub(now))
+	if err != nil {
+		return Decision{}, err
+	}
+	// 用量统计不影响限流判断，写入失败只记录日志
+	if err := l.Store.CountUsage(usageKey(now), time.Duration(l.Config.UsageRetentionDays)*24*time.Hour,
+		buckets, result.Limited < 0); err != nil {
+		utils.MsgError("        [Limiter]Failed to count usage > " + err.Error())
+	}
+	if result.Limited < 0 {
+		return Decision{Allowed: true}, nil
+	}
+	return Decision{Dimension: dimensions[result.Limited], RetryAfter: result.RetryAfter}, nil
+}
+
+// Usage 返回 request.Date 当天符合条件的各 key 用量，按请求数从多到少排列
+func (l *Limiter) Usage(request *rate_limit_model.UsageRequest) ([]rate_limit_model.UsageEntry, error) {
+	day := l.Now()
+	if request.Date != "" {
+		var err error
+		if day, err = time.Parse(dateLayout, request.Date); err != nil {
+			return nil, err
+		}
+	}
+	fields, err := l.Store.GetHash(usageKey(day))
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{request.Key: true}
+	if request.Key != "" && request.Dimension == rate_limit_model.DimensionCredential {
+		keys[CredentialKey(request.Key)] = true
+	}
+	entries := map[string]*rate_limit_model.UsageEntry{}
+	for field, value := range fields {
+		// 字段为 "<scope>|<dimension>|<key>|allowed" 或 "...|rejected"
+		separator := strings.LastIndex(field, "|")
+		if separator < 0 {
+			continue
+		}
+		parts := strings.SplitN(field[:separator], "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if (request.Scope != "" && parts[0] != request.Scope) ||
+			(request.Dimension != "" && parts[1] != request.Dimension) || (request.Key != "" && !keys[parts[2]]) {
+			continue
+		}
+		entry, ok := entries[field[:separator]]
+		if !ok {
+			entry = &rate_limit_model.UsageEntry{Scope: parts[0], Dimension: parts[1], Key: parts[2]}
+			entries[field[:separator]] = entry
+		}
+		count, _ := strconv.ParseInt(value, 10, 64)
+		switch field[separator+1:] {
+		case "allowed":
+			entry.Allowed = count
+		case "rejected":
+			entry.Rejected = count
+		}
+	}
+	usage := make([]rate_limit_model.UsageEntry, 0, len(entries))
+	for _, entry := range entries {
+		usage = append(usage, *entry)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		ti, tj := usage[i].Allowed+usage[i].Rejected, usage[j].Allowed+usage[j].Rejected
+		if ti != tj {
+			return ti > tj
+		}
+		return usage[i].Scope+usage[i].Dimension+usage[i].Key < usage[j].Scope+usage[j].Dimension+usage[j].Key
+	})
+	if request.Count > 0 && len(usage) > request.Count {
+		usage = usage[:request.Count]
+	}
+	return usage, nil
+}
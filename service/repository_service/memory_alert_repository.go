@@ -0,0 +1,102 @@
+package repository_service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"uam-power-backend/models/controller_models/alert_model"
+)
+
+func (d *MemoryDatabase) CreateAlert(_ context.Context, alert *alert_model.Alert) (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, existing := range d.alerts {
+		if existing.AircraftID == alert.AircraftID && existing.Event == alert.Event && existing.EventTime.Equal(alert.EventTime) {
+			return false, nil
+		}
+	}
+	now := time.Now()
+	alert.AlertID, alert.Status, alert.CreateTime, alert.UpdateTime = len(d.alerts)+1, alert_model.StatusOpen, now, now
+	d.alerts = append(d.alerts, *alert)
+	d.auditLocked(alert.AlertID, alert_model.ActionOpen, "", alert_model.StatusOpen, alert_model.SystemOperator, "")
+	return true, nil
+}
+
+func (d *MemoryDatabase) GetAlert(_ context.Context, AlertID int) (*alert_model.Alert, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if AlertID <= 0 || AlertID > len(d.alerts) {
+		return nil, ErrNotFound
+	}
+	alert := d.alerts[AlertID-1]
+	return &alert, nil
+}
+
+func (d *MemoryDatabase) ListAlerts(_ context.Context, filter *alert_model.ListAlertsRequest) ([]alert_model.Alert, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var alerts []alert_model.Alert
+	for i := len(d.alerts) - 1; i >= 0 && (filter.Count <= 0 || len(alerts) < filter.Count); i-- {
+		alert := d.alerts[i]
+		if (filter.Status == "" || alert.Status == filter.Status) &&
+			(filter.AircraftID == 0 || alert.AircraftID == filter.AircraftID) &&
+			(filter.Severity == "" || alert.Severity == filter.Severity) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+func (d *MemoryDatabase) TransitionAlert(
+	_ context.Context, AlertID int, from []string, change *alert_model.AlertChange,
+) (*alert_model.Alert, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if AlertID <= 0 || AlertID > len(d.alerts) {
+		return nil, ErrNotFound
+	}
+	alert := &d.alerts[AlertID-1]
+	if !containsStatus(from, alert.Status) {
+		return nil, fmt.Errorf("%w: alert %d is %s", ErrConflict, AlertID, alert.Status)
+	}
+	fromStatus := alert.Status
+	applyChange(alert, change, time.Now())
+	d.auditLocked(AlertID, change.Action, fromStatus, change.Status, change.Operator, change.Note)
+	updated := *alert
+	return &updated, nil
+}
+
+func (d *MemoryDatabase) EscalateAlert(_ context.Context, AlertID, level int) (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if AlertID <= 0 || AlertID > len(d.alerts) {
+		return false, nil
+	}
+	alert := &d.alerts[AlertID-1]
+	if alert.Status != alert_model.StatusOpen || alert.EscalationLevel != level {
+		return false, nil
+	}
+	alert.EscalationLevel++
+	d.auditLocked(AlertID, alert_model.ActionEscalate, alert_model.StatusOpen, alert_model.StatusOpen,
+		alert_model.SystemOperator, fmt.Sprintf("escalation level %d", level+1))
+	return true, nil
+}
+
+func (d *MemoryDatabase) ListAlertAudit(_ context.Context, AlertID int) ([]alert_model.AlertAudit, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var audit []alert_model.AlertAudit
+	for _, record := range d.alertAudit {
+		if record.AlertID == AlertID {
+			audit = append(audit, record)
+		}
+	}
+	return audit, nil
+}
+
+func (d *MemoryDatabase) auditLocked(AlertID int, action, from, to, operator, note string) {
+	d.alertAudit = append(d.alertAudit, alert_model.AlertAudit{
+		AuditID: len(d.alertAudit) + 1, AlertID: AlertID, Action: action, FromStatus: from, ToStatus: to,
+		Operator: operator, Note: note, CreateTime: time.Now(),
+	})
+}
@@ -0,0 +1,75 @@
+package repository_service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"uam-power-backend/models/controller_models/quarantine_model"
+)
+
+func (d *MemoryDatabase) AddQuarantine(_ context.Context, point *quarantine_model.QuarantinedPoint) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	point.QuarantineID, point.Status, point.CreateTime = len(d.quarantine)+1, quarantine_model.StatusPending, time.Now()
+	point.Reasons = append([]quarantine_model.Reason(nil), point.Reasons...)
+	d.quarantine = append(d.quarantine, *point)
+	return nil
+}
+
+func (d *MemoryDatabase) GetQuarantine(_ context.Context, QuarantineID int) (*quarantine_model.QuarantinedPoint, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if QuarantineID <= 0 || QuarantineID > len(d.quarantine) {
+		return nil, ErrNotFound
+	}
+	point := d.quarantine[QuarantineID-1]
+	return &point, nil
+}
+
+func (d *MemoryDatabase) ListQuarantine(
+	_ context.Context, filter *quarantine_model.ListQuarantineRequest,
+) ([]quarantine_model.QuarantinedPoint, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var points []quarantine_model.QuarantinedPoint
+	for i := len(d.quarantine) - 1; i >= 0 && (filter.Count <= 0 || len(points) < filter.Count); i-- {
+		point := d.quarantine[i]
+		if (filter.Status == "" || point.Status == filter.Status) &&
+			(filter.AircraftID == 0 || point.AircraftID == filter.AircraftID) &&
+			(filter.Reason == "" || hasReason(point.Reasons, filter.Reason)) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+func (d *MemoryDatabase) ReviewQuarantine(
+	_ context.Context, QuarantineID int, from string, review *quarantine_model.Review,
+) (*quarantine_model.QuarantinedPoint, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if QuarantineID <= 0 || QuarantineID > len(d.quarantine) {
+		return nil, ErrNotFound
+	}
+	point := &d.quarantine[QuarantineID-1]
+	if point.Status != from {
+		return nil, fmt.Errorf("%w: quarantine %d is %s", ErrConflict, QuarantineID, point.Status)
+	}
+	point.Status, point.Reviewer, point.Note, point.ReviewTime = review.Status, review.Reviewer, review.Note, nil
+	if review.Status != quarantine_model.StatusPending {
+		now := time.Now()
+		point.ReviewTime = &now
+	}
+	updated := *point
+	return &updated, nil
+}
+
+// hasReason 判断 reasons 中是否有代码为 code 的原因
+func hasReason(reasons []quarantine_model.Reason, code string) bool {
+	for _, reason := range reasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,435 @@
+package repository_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_id_model"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+// MemoryStore 进程内的 Redis 替身，实现 KVStore、LatestStore、EventStore、HashStore、LinkStore 与 SkewStore，
+// 值的编解码与 dbservice.RedisDict 一致；不实现过期
+type MemoryStore struct {
+	mutex  sync.Mutex
+	values map[string]string
+	// times SetIfNewer 写入的值的时间
+	times   map[string]time.Time
+	history map[string]map[string]float64
+	hashes  map[string]map[string]string
+	sets    map[string]map[string]bool
+}
+
+// NewMemoryStore 创建空的 MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values:  map[string]string{},
+		times:   map[string]time.Time{},
+		history: map[string]map[string]float64{},
+		hashes:  map[string]map[string]string{},
+		sets:    map[string]map[string]bool{},
+	}
+}
+
+func (m *MemoryStore) Get(key string) (interface{}, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return nil, nil
+	}
+	return dbservice.DecodeRedisValue(value), nil
+}
+
+func (m *MemoryStore) Set(key string, value interface{}) error {
+	stringValue, err := dbservice.EncodeRedisValue(value)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[key] = stringValue
+	return nil
+}
+
+func (m *MemoryStore) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *MemoryStore) Keys() ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	keys := make([]string, 0, len(m.values))
+	for key := range m.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// SetIfNewer 与 dbservice.RedisDict.SetIfNewer 相同：已存值的时间晚于 at 时不写入
+func (m *MemoryStore) SetIfNewer(key, value string, at time.Time, _ time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if stored, ok := m.times[key]; ok && stored.After(at) {
+		return false, nil
+	}
+	m.values[key], m.times[key] = value, at
+	return true, nil
+}
+
+func (m *MemoryStore) AddHistory(member string, score float64, _ time.Duration, keys ...dbservice.HistoryKey) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, history := range keys {
+		members := m.zsetLocked(history.Key)
+		members[member] = score
+		// 只保留 score 最大的 MaxEntries 条
+		for _, old := range m.sortedLocked(history.Key) {
+			if len(members) <= history.MaxEntries {
+				break
+			}
+			delete(members, old)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) RangeHistory(key string, since float64, count int) ([]interface{}, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sorted := m.sortedLocked(key)
+	records := make([]interface{}, 0, len(sorted))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if count > 0 && len(records) >= count {
+			break
+		}
+		if !math.IsInf(since, -1) && m.history[key][sorted[i]] < since {
+			break
+		}
+		var record interface{}
+		if err := json.Unmarshal([]byte(sorted[i]), &record); err != nil {
+			record = sorted[i]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// sortedLocked 按 score（相同时按成员）从小到大返回历史成员
+func (m *MemoryStore) sortedLocked(key string) []string {
+	members := m.history[key]
+	sorted := make([]string, 0, len(members))
+	for member := range members {
+		sorted = append(sorted, member)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if members[sorted[i]] != members[sorted[j]] {
+			return members[sorted[i]] < members[sorted[j]]
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+func (m *MemoryStore) GetHash(key string) (map[string]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	fields := map[string]string{}
+	for name, value := range m.hashes[key] {
+		fields[name] = value
+	}
+	return fields, nil
+}
+
+// SetHash 写入 HASH 字段，供测试准备链路质量等统计数据
+func (m *MemoryStore) SetHash(key string, fields map[string]string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.hashes[key] == nil {
+		m.hashes[key] = map[string]string{}
+	}
+	for name, value := range fields {
+		m.hashes[key][name] = value
+	}
+}
+
+// RecordClockSkew 与 dbservice.RedisDict.RecordClockSkew 相同
+func (m *MemoryStore) RecordClockSkew(statsKey string, skewMs int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.hashes[statsKey] == nil {
+		m.hashes[statsKey] = map[string]string{}
+	}
+	fields := m.hashes[statsKey]
+	parse := func(name string) (int64, bool) {
+		value, err := strconv.ParseInt(fields[name], 10, 64)
+		return value, err == nil
+	}
+	count, _ := parse("skew_count")
+	sum, _ := parse("skew_sum")
+	fields["skew_last"] = strconv.FormatInt(skewMs, 10)
+	fields["skew_count"] = strconv.FormatInt(count+1, 10)
+	fields["skew_sum"] = strconv.FormatInt(sum+skewMs, 10)
+	if min, ok := parse("skew_min"); !ok || skewMs < min {
+		fields["skew_min"] = strconv.FormatInt(skewMs, 10)
+	}
+	if max, ok := parse("skew_max"); !ok || skewMs > max {
+		fields["skew_max"] = strconv.FormatInt(skewMs, 10)
+	}
+	return nil
+}
+
+// TouchLink 与 dbservice.RedisDict.TouchLink 相同，seenKey 与 history 共用存储
+func (m *MemoryStore) TouchLink(seenKey, lostKey, member string, at time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.zsetLocked(seenKey)[member] = float64(at.UnixMilli())
+	restored := m.sets[lostKey][member]
+	delete(m.sets[lostKey], member)
+	return restored, nil
+}
+
+func (m *MemoryStore) LinkLastSeen(seenKey string, members []string, at time.Time) (map[string]time.Time, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	zset := m.zsetLocked(seenKey)
+	seen := make(map[string]time.Time, len(members))
+	for _, member := range members {
+		if _, ok := zset[member]; !ok {
+			zset[member] = float64(at.UnixMilli())
+		}
+		seen[member] = time.UnixMilli(int64(zset[member]))
+	}
+	return seen, nil
+}
+
+func (m *MemoryStore) LinkSeenAt(seenKey, member string) (time.Time, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	score, ok := m.history[seenKey][member]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	return time.UnixMilli(int64(score)), true, nil
+}
+
+func (m *MemoryStore) SetLinkLost(lostKey, member string, lost bool) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.sets[lostKey] == nil {
+		m.sets[lostKey] = map[string]bool{}
+	}
+	if m.sets[lostKey][member] == lost {
+		return false, nil
+	}
+	if lost {
+		m.sets[lostKey][member] = true
+	} else {
+		delete(m.sets[lostKey], member)
+	}
+	return true, nil
+}
+
+func (m *MemoryStore) PruneLinks(seenKey, lostKey string, keep []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	kept := make(map[string]bool, len(keep))
+	for _, member := range keep {
+		kept[member] = true
+	}
+	for member := range m.history[seenKey] {
+		if !kept[member] {
+			delete(m.history[seenKey], member)
+			delete(m.sets[lostKey], member)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) zsetLocked(key string) map[string]float64 {
+	if m.history[key] == nil {
+		m.history[key] = map[string]float64{}
+	}
+	return m.history[key]
+}
+
+// MemoryDatabase 进程内的 MySQL 替身，实现 AircraftRegistry、TaskRepository、TelemetryStore、EventCatalog、
+// AlertRepository、WebhookRepository 与 QuarantineRepository
+type MemoryDatabase struct {
+	mutex      sync.Mutex
+	aircraft   []aircraft_id_model.MysqlAircraftInfo
+	tasks      []aircraft_task_model.MysqlAircraftTask
+	statuses   map[string][]data_flow_model.AircraftStatus
+	events     map[string][]data_flow_model.AircraftEvent
+	eventTypes map[string]event_catalog_model.EventType
+	alerts     []alert_model.Alert
+	alertAudit []alert_model.AlertAudit
+	// 订阅可删除，ID 与 MySQL 的自增列一样不复用
+	subscriptions      []webhook_model.Subscription
+	deliveries         []webhook_model.Delivery
+	lastSubscriptionID int
+	lastDeliveryID     int
+	quarantine         []quarantine_model.QuarantinedPoint
+}
+
+// NewMemoryDatabase 创建 MemoryDatabase，事件目录与迁移后一样只含内置事件类型
+func NewMemoryDatabase() *MemoryDatabase {
+	eventTypes := map[string]event_catalog_model.EventType{}
+	for _, eventType := range event_catalog_model.BuiltinEventTypes {
+		eventTypes[eventType.Code] = eventType
+	}
+	return &MemoryDatabase{
+		statuses:   map[string][]data_flow_model.AircraftStatus{},
+		events:     map[string][]data_flow_model.AircraftEvent{},
+		eventTypes: eventTypes,
+	}
+}
+
+func (d *MemoryDatabase) GetAircraft(_ context.Context, AircraftID int) (*aircraft_id_model.MysqlAircraftInfo, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, info := range d.aircraft {
+		if info.AircraftID == AircraftID {
+			return &info, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (d *MemoryDatabase) CreateAircraft(
+	_ context.Context, request *aircraft_id_model.SetAircraftInfo,
+) (*aircraft_id_model.MysqlAircraftInfo, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	info := aircraft_id_model.MysqlAircraftInfo{
+		Company: request.Company, Name: request.Name, Type: request.Type,
+		TimeStr: utils.GetTimeStr(), CreateTime: time.Now(), AircraftID: len(d.aircraft) + 1,
+	}
+	d.aircraft = append(d.aircraft, info)
+	return &info, nil
+}
+
+func (d *MemoryDatabase) CreateTask(_ context.Context, AircraftID, LaneID int) (*aircraft_task_model.MysqlAircraftTask, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	// 与 flight_task_table 的外键约束一致，拒绝未注册的飞行器
+	if AircraftID <= 0 || AircraftID > len(d.aircraft) {
+		return nil, fmt.Errorf("insert task: aircraft %d not registered", AircraftID)
+	}
+	curStr := utils.GetTimeStr()
+	task := aircraft_task_model.MysqlAircraftTask{
+		TaskID: len(d.tasks) + 1, AircraftID: AircraftID, LaneID: LaneID, CreateTime: time.Now(),
+		TrackTable: fmt.Sprintf("%sFlight_AirID%d_Lane%d", curStr, AircraftID, LaneID),
+		EventTable: fmt.Sprintf("%sEvent_AirID%d_Lane%d", curStr, AircraftID, LaneID),
+		TimeStr:    curStr,
+	}
+	d.tasks = append(d.tasks, task)
+	return &task, nil
+}
+
+func (d *MemoryDatabase) GetTask(_ context.Context, TaskID int) (*aircraft_task_model.MysqlAircraftTask, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if TaskID <= 0 || TaskID > len(d.tasks) {
+		return nil, ErrNotFound
+	}
+	task := d.tasks[TaskID-1]
+	return &task, nil
+}
+
+func (d *MemoryDatabase) EndTask(_ context.Context, TaskID int, endTime time.Time) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if TaskID <= 0 || TaskID > len(d.tasks) {
+		return ErrNotFound
+	}
+	d.tasks[TaskID-1].EndTime = &endTime
+	return nil
+}
+
+func (d *MemoryDatabase) InsertStatus(
+	_ context.Context, task *aircraft_task_model.MysqlAircraftTask, status *data_flow_model.AircraftStatus,
+) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.statuses[task.TrackTable] = append(d.statuses[task.TrackTable], *status)
+	return nil
+}
+
+func (d *MemoryDatabase) InsertEvent(
+	_ context.Context, task *aircraft_task_model.MysqlAircraftTask, event *data_flow_model.AircraftEvent,
+) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.events[task.EventTable] = append(d.events[task.EventTable], *event)
+	return nil
+}
+
+func (d *MemoryDatabase) ListEventTypes(_ context.Context) ([]event_catalog_model.EventType, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	eventTypes := make([]event_catalog_model.EventType, 0, len(d.eventTypes))
+	for _, eventType := range d.eventTypes {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Slice(eventTypes, func(i, j int) bool { return eventTypes[i].Code < eventTypes[j].Code })
+	return eventTypes, nil
+}
+
+func (d *MemoryDatabase) UpsertEventType(_ context.Context, eventType *event_catalog_model.EventType) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.eventTypes[eventType.Code] = *eventType
+	return nil
+}
+
+func (d *MemoryDatabase) DeleteEventType(_ context.Context, code string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if _, ok := d.eventTypes[code]; !ok {
+		return ErrNotFound
+	}
+	delete(d.eventTypes, code)
+	return nil
+}
+
+// Statuses 返回写入任务轨迹表的状态点
+func (d *MemoryDatabase) Statuses(task *aircraft_task_model.MysqlAircraftTask) []data_flow_model.AircraftStatus {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]data_flow_model.AircraftStatus(nil), d.statuses[task.TrackTable]...)
+}
+
+// Events 返回写入任务事件表的事件
+func (d *MemoryDatabase) Events(task *aircraft_task_model.MysqlAircraftTask) []data_flow_model.AircraftEvent {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]data_flow_model.AircraftEvent(nil), d.events[task.EventTable]...)
+}
+
+var (
+	_ KVStore          = (*MemoryStore)(nil)
+	_ EventStore       = (*MemoryStore)(nil)
+	_ HashStore        = (*MemoryStore)(nil)
+	_ AircraftRegistry = (*MemoryDatabase)(nil)
+	_ TaskRepository   = (*MemoryDatabase)(nil)
+	_ TelemetryStore   = (*MemoryDatabase)(nil)
+	_ EventCatalog     = (*MemoryDatabase)(nil)
+	_ AlertRepository  = (*MemoryDatabase)(nil)
+)
@@ -0,0 +1,123 @@
+package repository_service
+
+import (
+	"context"
+	"time"
+	"uam-power-backend/models/controller_models/webhook_model"
+)
+
+func (d *MemoryDatabase) CreateSubscription(_ context.Context, subscription *webhook_model.Subscription) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.lastSubscriptionID++
+	subscription.SubscriptionID, subscription.CreateTime = d.lastSubscriptionID, time.Now()
+	d.subscriptions = append(d.subscriptions, *subscription)
+	return nil
+}
+
+func (d *MemoryDatabase) ListSubscriptions(_ context.Context) ([]webhook_model.Subscription, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]webhook_model.Subscription(nil), d.subscriptions...), nil
+}
+
+func (d *MemoryDatabase) DeleteSubscription(_ context.Context, SubscriptionID int) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for i, subscription := range d.subscriptions {
+		if subscription.SubscriptionID != SubscriptionID {
+			continue
+		}
+		d.subscriptions = append(d.subscriptions[:i], d.subscriptions[i+1:]...)
+		deliveries := d.deliveries[:0]
+		for _, delivery := range d.deliveries {
+			if delivery.SubscriptionID != SubscriptionID {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		d.deliveries = deliveries
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (d *MemoryDatabase) EnqueueDeliveries(_ context.Context, deliveries []webhook_model.Delivery) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	inserted := 0
+	for _, delivery := range deliveries {
+		duplicate := false
+		for _, existing := range d.deliveries {
+			if existing.SubscriptionID == delivery.SubscriptionID && existing.NotificationID == delivery.NotificationID {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		now := time.Now()
+		d.lastDeliveryID++
+		delivery.DeliveryID, delivery.Status, delivery.CreateTime, delivery.UpdateTime =
+			d.lastDeliveryID, webhook_model.DeliveryPending, now, now
+		d.deliveries = append(d.deliveries, delivery)
+		inserted++
+	}
+	return inserted, nil
+}
+
+func (d *MemoryDatabase) ClaimDeliveries(
+	_ context.Context, now time.Time, lease time.Duration, limit int,
+) ([]webhook_model.Delivery, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var claimed []webhook_model.Delivery
+	for i := range d.deliveries {
+		delivery := &d.deliveries[i]
+		if len(claimed) >= limit {
+			break
+		}
+		if delivery.Status != webhook_model.DeliveryPending || delivery.NextAttemptTime.After(now) {
+			continue
+		}
+		for _, subscription := range d.subscriptions {
+			if subscription.SubscriptionID == delivery.SubscriptionID {
+				delivery.URL, delivery.Secret = subscription.URL, subscription.Secret
+			}
+		}
+		delivery.NextAttemptTime = now.Add(lease)
+		claimed = append(claimed, *delivery)
+	}
+	return claimed, nil
+}
+
+func (d *MemoryDatabase) UpdateDelivery(_ context.Context, delivery *webhook_model.Delivery) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for i := range d.deliveries {
+		if d.deliveries[i].DeliveryID == delivery.DeliveryID {
+			stored := &d.deliveries[i]
+			stored.Status, stored.Attempts, stored.NextAttemptTime = delivery.Status, delivery.Attempts, delivery.NextAttemptTime
+			stored.LastStatusCode, stored.LastError, stored.DeliveredTime = delivery.LastStatusCode, delivery.LastError, delivery.DeliveredTime
+			stored.UpdateTime = time.Now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (d *MemoryDatabase) ListDeliveries(_ context.Context, filter *webhook_model.ListDeliveriesRequest) ([]webhook_model.Delivery, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	var deliveries []webhook_model.Delivery
+	for i := len(d.deliveries) - 1; i >= 0 && (filter.Count <= 0 || len(deliveries) < filter.Count); i-- {
+		delivery := d.deliveries[i]
+		if (filter.SubscriptionID == 0 || delivery.SubscriptionID == filter.SubscriptionID) &&
+			(filter.Status == "" || delivery.Status == filter.Status) &&
+			(filter.Type == "" || delivery.Type == filter.Type) {
+			delivery.URL, delivery.Secret = "", ""
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,230 @@
+package repository_service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+	"time"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/service/db_service"
+)
+
+// mysqlErrDupEntry MySQL 的 ER_DUP_ENTRY（唯一键冲突）
+const mysqlErrDupEntry = 1062
+
+const alertColumns = "AlertID, AircraftID, Event, Severity, EventTime, Payload, Status, Assignee, EscalationLevel, " +
+	"CreateTime, UpdateTime, AckTime, ResolveTime"
+
+type mysqlAlertRepository struct {
+	system *dbservice.MySQLService
+}
+
+// NewMySQLAlertRepository 以系统库中的 alert_table 与 alert_audit_table 实现 AlertRepository
+func NewMySQLAlertRepository(system *dbservice.MySQLService) AlertRepository {
+	return &mysqlAlertRepository{system: system}
+}
+
+// scanAlert 读取一行 alertColumns
+func scanAlert(scan func(dest ...interface{}) error) (*alert_model.Alert, error) {
+	var alert alert_model.Alert
+	var payload sql.NullString
+	var ackTime, resolveTime sql.NullTime
+	err := scan(&alert.AlertID, &alert.AircraftID, &alert.Event, &alert.Severity, &alert.EventTime, &payload,
+		&alert.Status, &alert.Assignee, &alert.EscalationLevel, &alert.CreateTime, &alert.UpdateTime, &ackTime, &resolveTime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if payload.Valid {
+		alert.Payload = json.RawMessage(payload.String)
+	}
+	if ackTime.Valid {
+		alert.AckTime = &ackTime.Time
+	}
+	if resolveTime.Valid {
+		alert.ResolveTime = &resolveTime.Time
+	}
+	return &alert, nil
+}
+
+// insertAudit 在事务中写入一条审计记录
+func insertAudit(ctx context.Context, tx *sql.Tx, AlertID int, action, from, to, operator, note string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO alert_audit_table(AlertID, Action, FromStatus, ToStatus, Operator, Note) VALUES (?, ?, ?, ?, ?, ?);",
+		AlertID, action, from, to, operator, note,
+	)
+	return err
+}
+
+// inTx 在事务中执行 fn，fn 返回错误时回滚
+func (r *mysqlAlertRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.system.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *mysqlAlertRepository) CreateAlert(ctx context.Context, alert *alert_model.Alert) (bool, error) {
+	created := false
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO alert_table(AircraftID, Event, Severity, EventTime, Payload, Status) VALUES (?, ?, ?, ?, ?, ?);",
+			alert.AircraftID, alert.Event, alert.Severity, alert.EventTime, nullableString(string(alert.Payload)),
+			alert_model.StatusOpen,
+		)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		alert.AlertID, alert.Status, created = int(id), alert_model.StatusOpen, true
+		return insertAudit(ctx, tx, alert.AlertID, alert_model.ActionOpen, "", alert_model.StatusOpen,
+			alert_model.SystemOperator, "")
+	})
+	return created, err
+}
+
+func (r *mysqlAlertRepository) GetAlert(ctx context.Context, AlertID int) (*alert_model.Alert, error) {
+	row := r.system.DB().QueryRowContext(ctx,
+		"SELECT "+alertColumns+" FROM alert_table WHERE AlertID = ?;", AlertID)
+	return scanAlert(row.Scan)
+}
+
+func (r *mysqlAlertRepository) ListAlerts(ctx context.Context, filter *alert_model.ListAlertsRequest) ([]alert_model.Alert, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions, args = append(conditions, "Status = ?"), append(args, filter.Status)
+	}
+	if filter.AircraftID != 0 {
+		conditions, args = append(conditions, "AircraftID = ?"), append(args, filter.AircraftID)
+	}
+	if filter.Severity != "" {
+		conditions, args = append(conditions, "Severity = ?"), append(args, filter.Severity)
+	}
+	query := "SELECT " + alertColumns + " FROM alert_table"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY CreateTime DESC, AlertID DESC"
+	if filter.Count > 0 {
+		query, args = query+" LIMIT ?", append(args, filter.Count)
+	}
+	var alerts []alert_model.Alert
+	err := r.system.QueryEach(query+";", func(rows *sql.Rows) error {
+		alert, err := scanAlert(rows.Scan)
+		if err != nil {
+			return err
+		}
+		alerts = append(alerts, *alert)
+		return nil
+	}, args...)
+	return alerts, err
+}
+
+func (r *mysqlAlertRepository) TransitionAlert(
+	ctx context.Context, AlertID int, from []string, change *alert_model.AlertChange,
+) (*alert_model.Alert, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx,
+			"SELECT "+alertColumns+" FROM alert_table WHERE AlertID = ? FOR UPDATE;", AlertID)
+		alert, err := scanAlert(row.Scan)
+		if err != nil {
+			return err
+		}
+		if !containsStatus(from, alert.Status) {
+			return fmt.Errorf("%w: alert %d is %s", ErrConflict, AlertID, alert.Status)
+		}
+		fromStatus := alert.Status
+		applyChange(alert, change, time.Now())
+		_, err = tx.ExecContext(ctx,
+			"UPDATE alert_table SET Status = ?, Assignee = ?, AckTime = ?, ResolveTime = ? WHERE AlertID = ?;",
+			alert.Status, alert.Assignee, alert.AckTime, alert.ResolveTime, AlertID,
+		)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, AlertID, change.Action, fromStatus, change.Status, change.Operator, change.Note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAlert(ctx, AlertID)
+}
+
+func (r *mysqlAlertRepository) EscalateAlert(ctx context.Context, AlertID, level int) (bool, error) {
+	escalated := false
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE alert_table SET EscalationLevel = EscalationLevel + 1 WHERE AlertID = ? AND Status = ? AND EscalationLevel = ?;",
+			AlertID, alert_model.StatusOpen, level,
+		)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return nil
+		}
+		escalated = true
+		return insertAudit(ctx, tx, AlertID, alert_model.ActionEscalate, alert_model.StatusOpen, alert_model.StatusOpen,
+			alert_model.SystemOperator, fmt.Sprintf("escalation level %d", level+1))
+	})
+	return escalated, err
+}
+
+func (r *mysqlAlertRepository) ListAlertAudit(ctx context.Context, AlertID int) ([]alert_model.AlertAudit, error) {
+	var audit []alert_model.AlertAudit
+	err := r.system.QueryEach(
+		"SELECT AuditID, AlertID, Action, FromStatus, ToStatus, Operator, Note, CreateTime FROM alert_audit_table "+
+			"WHERE AlertID = ? ORDER BY AuditID;",
+		func(rows *sql.Rows) error {
+			var record alert_model.AlertAudit
+			if err := rows.Scan(&record.AuditID, &record.AlertID, &record.Action, &record.FromStatus, &record.ToStatus,
+				&record.Operator, &record.Note, &record.CreateTime); err != nil {
+				return err
+			}
+			audit = append(audit, record)
+			return nil
+		}, AlertID)
+	return audit, err
+}
+
+// containsStatus 判断 status 是否在 statuses 中
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// applyChange 按 change 修改告警的状态、负责人与对应的时间
+func applyChange(alert *alert_model.Alert, change *alert_model.AlertChange, now time.Time) {
+	alert.Status = change.Status
+	if change.Assignee != "" {
+		alert.Assignee = change.Assignee
+	}
+	switch change.Status {
+	case alert_model.StatusAcknowledged:
+		alert.AckTime = &now
+	case alert_model.StatusResolved:
+		alert.ResolveTime = &now
+	}
+	alert.UpdateTime = now
+}
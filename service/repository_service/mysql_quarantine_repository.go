@@ -0,0 +1,139 @@
+package repository_service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/service/db_service"
+)
+
+const quarantineColumns = "QuarantineID, AircraftID, TimeString, Point, Reasons, Status, Reviewer, Note, CreateTime, ReviewTime"
+
+type mysqlQuarantineRepository struct {
+	system *dbservice.MySQLService
+}
+
+// NewMySQLQuarantineRepository 以系统库中的 telemetry_quarantine_table 实现 QuarantineRepository
+func NewMySQLQuarantineRepository(system *dbservice.MySQLService) QuarantineRepository {
+	return &mysqlQuarantineRepository{system: system}
+}
+
+// scanQuarantine 读取一行 quarantineColumns
+func scanQuarantine(scan func(dest ...interface{}) error) (*quarantine_model.QuarantinedPoint, error) {
+	var point quarantine_model.QuarantinedPoint
+	var raw, reasons string
+	var reviewTime sql.NullTime
+	err := scan(&point.QuarantineID, &point.AircraftID, &point.TimeString, &raw, &reasons, &point.Status,
+		&point.Reviewer, &point.Note, &point.CreateTime, &reviewTime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(raw), &point.Point); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(reasons), &point.Reasons); err != nil {
+		return nil, err
+	}
+	if reviewTime.Valid {
+		point.ReviewTime = &reviewTime.Time
+	}
+	return &point, nil
+}
+
+func (r *mysqlQuarantineRepository) AddQuarantine(ctx context.Context, point *quarantine_model.QuarantinedPoint) error {
+	raw, err := json.Marshal(point.Point)
+	if err != nil {
+		return err
+	}
+	reasons, err := json.Marshal(point.Reasons)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	result, err := r.system.DB().ExecContext(ctx,
+		"INSERT INTO telemetry_quarantine_table(AircraftID, TimeString, Point, Reasons, Status, CreateTime) "+
+			"VALUES (?, ?, ?, ?, ?, ?);",
+		point.AircraftID, point.TimeString, string(raw), string(reasons), quarantine_model.StatusPending, now,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	point.QuarantineID, point.Status, point.CreateTime = int(id), quarantine_model.StatusPending, now
+	return nil
+}
+
+func (r *mysqlQuarantineRepository) GetQuarantine(ctx context.Context, QuarantineID int) (*quarantine_model.QuarantinedPoint, error) {
+	row := r.system.DB().QueryRowContext(ctx,
+		"SELECT "+quarantineColumns+" FROM telemetry_quarantine_table WHERE QuarantineID = ?;", QuarantineID)
+	return scanQuarantine(row.Scan)
+}
+
+func (r *mysqlQuarantineRepository) ListQuarantine(
+	ctx context.Context, filter *quarantine_model.ListQuarantineRequest,
+) ([]quarantine_model.QuarantinedPoint, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions, args = append(conditions, "Status = ?"), append(args, filter.Status)
+	}
+	if filter.AircraftID != 0 {
+		conditions, args = append(conditions, "AircraftID = ?"), append(args, filter.AircraftID)
+	}
+	if filter.Reason != "" {
+		conditions, args = append(conditions, "JSON_CONTAINS(Reasons, JSON_OBJECT('Code', ?))"), append(args, filter.Reason)
+	}
+	query := "SELECT " + quarantineColumns + " FROM telemetry_quarantine_table"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY CreateTime DESC, QuarantineID DESC"
+	if filter.Count > 0 {
+		query, args = query+" LIMIT ?", append(args, filter.Count)
+	}
+	var points []quarantine_model.QuarantinedPoint
+	err := r.system.QueryEach(query+";", func(rows *sql.Rows) error {
+		point, err := scanQuarantine(rows.Scan)
+		if err != nil {
+			return err
+		}
+		points = append(points, *point)
+		return nil
+	}, args...)
+	return points, err
+}
+
+func (r *mysqlQuarantineRepository) ReviewQuarantine(
+	ctx context.Context, QuarantineID int, from string, review *quarantine_model.Review,
+) (*quarantine_model.QuarantinedPoint, error) {
+	var reviewTime interface{}
+	if review.Status != quarantine_model.StatusPending {
+		reviewTime = time.Now()
+	}
+	result, err := r.system.DB().ExecContext(ctx,
+		"UPDATE telemetry_quarantine_table SET Status = ?, Reviewer = ?, Note = ?, ReviewTime = ? "+
+			"WHERE QuarantineID = ? AND Status = ?;",
+		review.Status, review.Reviewer, review.Note, reviewTime, QuarantineID, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		point, err := r.GetQuarantine(ctx, QuarantineID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: quarantine %d is %s", ErrConflict, QuarantineID, point.Status)
+	}
+	return r.GetQuarantine(ctx, QuarantineID)
+}
@@ -0,0 +1,242 @@
+package repository_service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_id_model"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+// decodeRow 将 QueryRow 的结果转换为模型，无结果时返回 ErrNotFound
+func decodeRow(row map[string]interface{}, err error, out interface{}) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, out)
+}
+
+type mysqlAircraftRegistry struct {
+	system *dbservice.MySQLService
+}
+
+// NewMySQLAircraftRegistry 以系统库中的 aircraft_identity_table 实现 AircraftRegistry
+func NewMySQLAircraftRegistry(system *dbservice.MySQLService) AircraftRegistry {
+	return &mysqlAircraftRegistry{system: system}
+}
+
+func (r *mysqlAircraftRegistry) GetAircraft(_ context.Context, AircraftID int) (*aircraft_id_model.MysqlAircraftInfo, error) {
+	var info aircraft_id_model.MysqlAircraftInfo
+	row, err := r.system.QueryRow("Select * from aircraft_identity_table where AircraftID = ?;", AircraftID)
+	if err = decodeRow(row, err, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (r *mysqlAircraftRegistry) CreateAircraft(
+	_ context.Context, request *aircraft_id_model.SetAircraftInfo,
+) (*aircraft_id_model.MysqlAircraftInfo, error) {
+	curStr := utils.GetTimeStr()
+	_, err := r.system.ExecuteCmd(
+		"INSERT INTO aircraft_identity_table(Type, Company, Name, TimeStr) VALUES (?, ?, ?, ?)",
+		request.Type, request.Company, request.Name, curStr,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var info aircraft_id_model.MysqlAircraftInfo
+	row, err := r.system.QueryRow("Select * from aircraft_identity_table where TimeStr = ?;", curStr)
+	if err = decodeRow(row, err, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+type mysqlTaskRepository struct {
+	system *dbservice.MySQLService
+	flight *dbservice.MySQLService
+	event  *dbservice.MySQLService
+}
+
+// NewMySQLTaskRepository 以系统库中的 flight_task_table 实现 TaskRepository，
+// 任务的轨迹表与事件表分别建在 FlightDB 与 EventDB 中
+func NewMySQLTaskRepository(system, flight, event *dbservice.MySQLService) TaskRepository {
+	return &mysqlTaskRepository{system: system, flight: flight, event: event}
+}
+
+func (r *mysqlTaskRepository) CreateTask(_ context.Context, AircraftID, LaneID int) (*aircraft_task_model.MysqlAircraftTask, error) {
+	curStr := utils.GetTimeStr()
+	FlightTable := fmt.Sprintf("%sFlight_AirID%d_Lane%d", curStr, AircraftID, LaneID)
+	EventTable := fmt.Sprintf("%sEvent_AirID%d_Lane%d", curStr, AircraftID, LaneID)
+	_, err := r.flight.ExecuteCmd(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (Longitude DOUBLE(15, 12), Latitude DOUBLE(15, 12), Altitude DOUBLE(15, 12), Yaw DOUBLE(15, 12), DataTime DATETIME(6),  UploadTime DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6));",
+			FlightTable,
+		))
+	if err != nil {
+		return nil, fmt.Errorf("create status table: %w", err)
+	}
+	_, err = r.event.ExecuteCmd(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (DataTime DATETIME(6),  CreateTime DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6), Event VARCHAR(64) not NULL, Severity VARCHAR(16) NULL, Payload JSON NULL);",
+			EventTable,
+		))
+	if err != nil {
+		return nil, fmt.Errorf("create event table: %w", err)
+	}
+	_, err = r.system.ExecuteCmd(
+		"INSERT INTO flight_task_table(AircraftID, LaneID, TrackTable, EventTable, TimeStr) VALUES (?, ?, ?, ?, ?);",
+		AircraftID, LaneID, FlightTable, EventTable, curStr,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert task: %w", err)
+	}
+	var task aircraft_task_model.MysqlAircraftTask
+	row, err := r.system.QueryRow("Select * from flight_task_table where TimeStr = ?;", curStr)
+	if err = decodeRow(row, err, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *mysqlTaskRepository) GetTask(_ context.Context, TaskID int) (*aircraft_task_model.MysqlAircraftTask, error) {
+	var task aircraft_task_model.MysqlAircraftTask
+	row, err := r.system.QueryRow("SELECT * FROM flight_task_table WHERE TaskID = ?;", TaskID)
+	if err = decodeRow(row, err, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *mysqlTaskRepository) EndTask(_ context.Context, TaskID int, endTime time.Time) error {
+	_, err := r.system.ExecuteCmd(
+		"UPDATE flight_task_table SET EndTime = ? WHERE TaskID = ?;",
+		endTime.Format("2006-01-02 15:04:05.000000"), TaskID,
+	)
+	return err
+}
+
+type mysqlTelemetryStore struct {
+	flight *dbservice.MySQLService
+	event  *dbservice.MySQLService
+}
+
+// NewMySQLTelemetryStore 将轨迹点与事件写入任务在 FlightDB/EventDB 中的数据表
+func NewMySQLTelemetryStore(flight, event *dbservice.MySQLService) TelemetryStore {
+	return &mysqlTelemetryStore{flight: flight, event: event}
+}
+
+// InsertStatus 写入轨迹点，UploadTime 为服务端接收时间，消息未携带时取写入时间
+func (s *mysqlTelemetryStore) InsertStatus(
+	_ context.Context, task *aircraft_task_model.MysqlAircraftTask, status *data_flow_model.AircraftStatus,
+) error {
+	_, err := s.flight.ExecuteCmd(
+		fmt.Sprintf("INSERT INTO %s (Longitude, Latitude, Altitude, Yaw, DataTime, UploadTime) "+
+			"VALUES (?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP(6)));", task.TrackTable),
+		status.Longitude, status.Latitude, status.Altitude, status.Yaw, status.TimeString, nullableString(status.ReceiveTime),
+	)
+	return err
+}
+
+// InsertEvent 写入事件及其严重级别与 Payload；事件目录之前创建的事件表缺少这两列，
+// 首次写入时补齐后重试。多个消费者或实例可能同时补齐同一张表，后执行的 ALTER 因列已存在而失败，
+// 此时表已由先执行者补齐，直接重试写入
+func (s *mysqlTelemetryStore) InsertEvent(
+	_ context.Context, task *aircraft_task_model.MysqlAircraftTask, event *data_flow_model.AircraftEvent,
+) error {
+	insert := func() error {
+		_, err := s.event.ExecuteCmd(
+			fmt.Sprintf("INSERT INTO %s(DataTime, Event, Severity, Payload) VALUES (?, ?, ?, ?)", task.EventTable),
+			event.TimeString, event.Event, nullableString(event.Severity), nullableString(string(event.Payload)),
+		)
+		return err
+	}
+	err := insert()
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrBadField {
+		return err
+	}
+	_, err = s.event.ExecuteCmd(fmt.Sprintf(
+		"ALTER TABLE %s MODIFY Event VARCHAR(64) NOT NULL, ADD COLUMN Severity VARCHAR(16) NULL, ADD COLUMN Payload JSON NULL;",
+		task.EventTable,
+	))
+	if err != nil && !(errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupField) {
+		return fmt.Errorf("upgrade event table: %w", err)
+	}
+	return insert()
+}
+
+// mysqlErrBadField/mysqlErrDupField MySQL 的 ER_BAD_FIELD_ERROR（Unknown column）与 ER_DUP_FIELDNAME（Duplicate column name）
+const (
+	mysqlErrBadField = 1054
+	mysqlErrDupField = 1060
+)
+
+// nullableString 空串写入 NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+type mysqlEventCatalog struct {
+	system *dbservice.MySQLService
+}
+
+// NewMySQLEventCatalog 以系统库中的 event_type_table 实现 EventCatalog
+func NewMySQLEventCatalog(system *dbservice.MySQLService) EventCatalog {
+	return &mysqlEventCatalog{system: system}
+}
+
+func (c *mysqlEventCatalog) ListEventTypes(_ context.Context) ([]event_catalog_model.EventType, error) {
+	var eventTypes []event_catalog_model.EventType
+	err := c.system.QueryEach(
+		"SELECT Code, Severity, Description, PayloadSchema FROM event_type_table ORDER BY Code;",
+		func(rows *sql.Rows) error {
+			var eventType event_catalog_model.EventType
+			var schema sql.NullString
+			if err := rows.Scan(&eventType.Code, &eventType.Severity, &eventType.Description, &schema); err != nil {
+				return err
+			}
+			if schema.Valid {
+				eventType.PayloadSchema = json.RawMessage(schema.String)
+			}
+			eventTypes = append(eventTypes, eventType)
+			return nil
+		})
+	return eventTypes, err
+}
+
+func (c *mysqlEventCatalog) UpsertEventType(_ context.Context, eventType *event_catalog_model.EventType) error {
+	_, err := c.system.ExecuteCmd(
+		"INSERT INTO event_type_table(Code, Severity, Description, PayloadSchema) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE Severity = VALUES(Severity), Description = VALUES(Description), PayloadSchema = VALUES(PayloadSchema);",
+		eventType.Code, eventType.Severity, eventType.Description, nullableString(string(eventType.PayloadSchema)),
+	)
+	return err
+}
+
+func (c *mysqlEventCatalog) DeleteEventType(_ context.Context, code string) error {
+	affected, err := c.system.ExecuteCmd("DELETE FROM event_type_table WHERE Code = ?;", code)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package repository_service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/db_service"
+)
+
+const deliveryColumns = "d.DeliveryID, d.SubscriptionID, d.NotificationID, d.Type, d.Body, d.Status, d.Attempts, " +
+	"d.NextAttemptTime, d.LastStatusCode, d.LastError, d.CreateTime, d.UpdateTime, d.DeliveredTime"
+
+type mysqlWebhookRepository struct {
+	system *dbservice.MySQLService
+}
+
+// NewMySQLWebhookRepository 以系统库中的 webhook_subscription_table 与 webhook_delivery_table 实现 WebhookRepository
+func NewMySQLWebhookRepository(system *dbservice.MySQLService) WebhookRepository {
+	return &mysqlWebhookRepository{system: system}
+}
+
+// scanDelivery 读取一行 deliveryColumns，extra 为其后追加的列
+func scanDelivery(scan func(dest ...interface{}) error, extra ...interface{}) (*webhook_model.Delivery, error) {
+	var delivery webhook_model.Delivery
+	var body string
+	var deliveredTime sql.NullTime
+	dest := append([]interface{}{
+		&delivery.DeliveryID, &delivery.SubscriptionID, &delivery.NotificationID, &delivery.Type, &body, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptTime, &delivery.LastStatusCode, &delivery.LastError,
+		&delivery.CreateTime, &delivery.UpdateTime, &deliveredTime,
+	}, extra...)
+	if err := scan(dest...); err != nil {
+		return nil, err
+	}
+	delivery.Body = json.RawMessage(body)
+	if deliveredTime.Valid {
+		delivery.DeliveredTime = &deliveredTime.Time
+	}
+	return &delivery, nil
+}
+
+func (r *mysqlWebhookRepository) CreateSubscription(ctx context.Context, subscription *webhook_model.Subscription) error {
+	eventTypes, err := json.Marshal(subscription.EventTypes)
+	if err != nil {
+		return err
+	}
+	if subscription.EventTypes == nil {
+		eventTypes = []byte("[]")
+	}
+	subscription.CreateTime = time.Now()
+	result, err := r.system.DB().ExecContext(ctx,
+		"INSERT INTO webhook_subscription_table(URL, Secret, EventTypes, Description, CreateTime) VALUES (?, ?, ?, ?, ?);",
+		subscription.URL, subscription.Secret, string(eventTypes), subscription.Description, subscription.CreateTime,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	subscription.SubscriptionID = int(id)
+	return nil
+}
+
+func (r *mysqlWebhookRepository) ListSubscriptions(_ context.Context) ([]webhook_model.Subscription, error) {
+	var subscriptions []webhook_model.Subscription
+	err := r.system.QueryEach(
+		"SELECT SubscriptionID, URL, Secret, EventTypes, Description, CreateTime FROM webhook_subscription_table "+
+			"ORDER BY SubscriptionID;",
+		func(rows *sql.Rows) error {
+			var subscription webhook_model.Subscription
+			var eventTypes string
+			if err := rows.Scan(&subscription.SubscriptionID, &subscription.URL, &subscription.Secret, &eventTypes,
+				&subscription.Description, &subscription.CreateTime); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+				return err
+			}
+			subscriptions = append(subscriptions, subscription)
+			return nil
+		})
+	return subscriptions, err
+}
+
+func (r *mysqlWebhookRepository) DeleteSubscription(_ context.Context, SubscriptionID int) error {
+	affected, err := r.system.ExecuteCmd("DELETE FROM webhook_subscription_table WHERE SubscriptionID = ?;", SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mysqlWebhookRepository) EnqueueDeliveries(ctx context.Context, deliveries []webhook_model.Delivery) (int, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, 0, len(deliveries))
+	args := make([]interface{}, 0, len(deliveries)*6)
+	for _, delivery := range deliveries {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?)")
+		args = append(args, delivery.SubscriptionID, delivery.NotificationID, delivery.Type, string(delivery.Body),
+			webhook_model.DeliveryPending, delivery.NextAttemptTime)
+	}
+	// INSERT IGNORE 跳过 (SubscriptionID, NotificationID) 重复的记录，即重投的同一通知
+	result, err := r.system.DB().ExecContext(ctx,
+		"INSERT IGNORE INTO webhook_delivery_table(SubscriptionID, NotificationID, Type, Body, Status, NextAttemptTime) VALUES "+
+			strings.Join(placeholders, ", ")+";",
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *mysqlWebhookRepository) ClaimDeliveries(
+	ctx context.Context, now time.Time, lease time.Duration, limit int,
+) ([]webhook_model.Delivery, error) {
+	tx, err := r.system.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	// SKIP LOCKED（MySQL 8.0+）使多个实例同时取出时互不等待，也不会取到同一条记录
+	rows, err := tx.QueryContext(ctx,
+		"SELECT "+deliveryColumns+", s.URL, s.Secret FROM webhook_delivery_table d "+
+			"JOIN webhook_subscription_table s ON s.SubscriptionID = d.SubscriptionID "+
+			"WHERE d.Status = ? AND d.NextAttemptTime <= ? ORDER BY d.NextAttemptTime LIMIT ? FOR UPDATE OF d SKIP LOCKED;",
+		webhook_model.DeliveryPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []webhook_model.Delivery
+	var ids []interface{}
+	for rows.Next() {
+		var URL, secret string
+		delivery, err := scanDelivery(rows.Scan, &URL, &secret)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		delivery.URL, delivery.Secret = URL, secret
+		deliveries = append(deliveries, *delivery)
+		ids = append(ids, delivery.DeliveryID)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE webhook_delivery_table SET NextAttemptTime = ? WHERE DeliveryID IN (?"+
+			strings.Repeat(", ?", len(ids)-1)+");",
+		append([]interface{}{now.Add(lease)}, ids...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, tx.Commit()
+}
+
+func (r *mysqlWebhookRepository) UpdateDelivery(ctx context.Context, delivery *webhook_model.Delivery) error {
+	_, err := r.system.DB().ExecContext(ctx,
+		"UPDATE webhook_delivery_table SET Status = ?, Attempts = ?, NextAttemptTime = ?, LastStatusCode = ?, "+
+			"LastError = ?, DeliveredTime = ? WHERE DeliveryID = ?;",
+		delivery.Status, delivery.Attempts, delivery.NextAttemptTime, delivery.LastStatusCode, delivery.LastError,
+		delivery.DeliveredTime, delivery.DeliveryID,
+	)
+	return err
+}
+
+func (r *mysqlWebhookRepository) ListDeliveries(
+	_ context.Context, filter *webhook_model.ListDeliveriesRequest,
+) ([]webhook_model.Delivery, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.SubscriptionID != 0 {
+		conditions, args = append(conditions, "d.SubscriptionID = ?"), append(args, filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		conditions, args = append(conditions, "d.Status = ?"), append(args, filter.Status)
+	}
+	if filter.Type != "" {
+		conditions, args = append(conditions, "d.Type = ?"), append(args, filter.Type)
+	}
+	query := "SELECT " + deliveryColumns + " FROM webhook_delivery_table d"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY d.CreateTime DESC, d.DeliveryID DESC"
+	if filter.Count > 0 {
+		query, args = query+" LIMIT ?", append(args, filter.Count)
+	}
+	var deliveries []webhook_model.Delivery
+	err := r.system.QueryEach(query+";", func(rows *sql.Rows) error {
+		delivery, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, *delivery)
+		return nil
+	}, args...)
+	return deliveries, err
+}
@@ -0,0 +1,138 @@
+package repository_service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_id_model"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/db_service"
+)
+
+// ErrNotFound 查询的飞行器、任务、事件类型、告警、订阅或隔离记录不存在
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict 记录的当前状态不允许此次修改（如确认已解决的告警）
+var ErrConflict = errors.New("conflict")
+
+// AircraftRegistry 飞行器身份注册表
+type AircraftRegistry interface {
+	GetAircraft(ctx context.Context, AircraftID int) (*aircraft_id_model.MysqlAircraftInfo, error)
+	CreateAircraft(ctx context.Context, info *aircraft_id_model.SetAircraftInfo) (*aircraft_id_model.MysqlAircraftInfo, error)
+}
+
+// TaskRepository 飞行任务表，创建任务时同时创建该任务的轨迹表与事件表
+type TaskRepository interface {
+	CreateTask(ctx context.Context, AircraftID, LaneID int) (*aircraft_task_model.MysqlAircraftTask, error)
+	GetTask(ctx context.Context, TaskID int) (*aircraft_task_model.MysqlAircraftTask, error)
+	EndTask(ctx context.Context, TaskID int, endTime time.Time) error
+}
+
+// EventCatalog 事件类型目录，Code 为主键；删除不存在的类型时返回 ErrNotFound
+type EventCatalog interface {
+	ListEventTypes(ctx context.Context) ([]event_catalog_model.EventType, error)
+	UpsertEventType(ctx context.Context, eventType *event_catalog_model.EventType) error
+	DeleteEventType(ctx context.Context, code string) error
+}
+
+// AlertRepository 告警及其审计记录，每次状态变化与对应的审计记录在同一事务中写入
+type AlertRepository interface {
+	// CreateAlert 创建 open 状态的告警，同一飞行器同一时间的同一事件已有告警时返回 false
+	CreateAlert(ctx context.Context, alert *alert_model.Alert) (bool, error)
+	GetAlert(ctx context.Context, AlertID int) (*alert_model.Alert, error)
+	ListAlerts(ctx context.Context, filter *alert_model.ListAlertsRequest) ([]alert_model.Alert, error)
+	// TransitionAlert 告警处于 from 中某一状态时按 change 修改，否则返回 ErrConflict
+	TransitionAlert(ctx context.Context, AlertID int, from []string, change *alert_model.AlertChange) (*alert_model.Alert, error)
+	// EscalateAlert 将仍为 open 且升级次数为 level 的告警升级一次，多个实例同时升级时只有一个返回 true
+	EscalateAlert(ctx context.Context, AlertID, level int) (bool, error)
+	ListAlertAudit(ctx context.Context, AlertID int) ([]alert_model.AlertAudit, error)
+}
+
+// WebhookRepository webhook 订阅及其投递记录，删除订阅时一并删除其投递记录
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *webhook_model.Subscription) error
+	ListSubscriptions(ctx context.Context) ([]webhook_model.Subscription, error)
+	DeleteSubscription(ctx context.Context, SubscriptionID int) error
+	// EnqueueDeliveries 写入待投递记录，同一订阅已有同一 NotificationID 的记录时跳过，返回写入的条数
+	EnqueueDeliveries(ctx context.Context, deliveries []webhook_model.Delivery) (int, error)
+	// ClaimDeliveries 取出至多 limit 条 NextAttemptTime 不晚于 now 的 pending 记录，并将其 NextAttemptTime
+	// 推迟到 now+lease，投递期间其他实例不会重复取出
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]webhook_model.Delivery, error)
+	// UpdateDelivery 保存一次尝试后的状态、次数、下次尝试时间与结果
+	UpdateDelivery(ctx context.Context, delivery *webhook_model.Delivery) error
+	ListDeliveries(ctx context.Context, filter *webhook_model.ListDeliveriesRequest) ([]webhook_model.Delivery, error)
+}
+
+// QuarantineRepository 未通过合理性校验的状态点及其审核结果
+type QuarantineRepository interface {
+	// AddQuarantine 写入 pending 状态的隔离记录，并填写 QuarantineID 与 CreateTime
+	AddQuarantine(ctx context.Context, point *quarantine_model.QuarantinedPoint) error
+	GetQuarantine(ctx context.Context, QuarantineID int) (*quarantine_model.QuarantinedPoint, error)
+	ListQuarantine(ctx context.Context, filter *quarantine_model.ListQuarantineRequest) ([]quarantine_model.QuarantinedPoint, error)
+	// ReviewQuarantine 记录处于 from 状态时按 review 修改，否则返回 ErrConflict
+	ReviewQuarantine(ctx context.Context, QuarantineID int, from string, review *quarantine_model.Review) (*quarantine_model.QuarantinedPoint, error)
+}
+
+// TelemetryStore 按任务保存轨迹点与事件
+type TelemetryStore interface {
+	InsertStatus(ctx context.Context, task *aircraft_task_model.MysqlAircraftTask, status *data_flow_model.AircraftStatus) error
+	InsertEvent(ctx context.Context, task *aircraft_task_model.MysqlAircraftTask, event *data_flow_model.AircraftEvent) error
+}
+
+// KVStore 键值缓存（飞行器信息、进行中的任务），值的编解码与 dbservice.RedisDict 一致
+type KVStore interface {
+	Get(key string) (interface{}, error)
+	Set(key string, value interface{}) error
+	Delete(key string) error
+	Keys() ([]string, error)
+}
+
+// LatestStore 最新状态缓存，只保存时间不早于已存值的消息
+type LatestStore interface {
+	Get(key string) (interface{}, error)
+	SetIfNewer(key, value string, at time.Time, ttl time.Duration) (bool, error)
+}
+
+// HistoryStore 按 score 排序的有界历史记录
+type HistoryStore interface {
+	AddHistory(member string, score float64, ttl time.Duration, keys ...dbservice.HistoryKey) error
+	RangeHistory(key string, since float64, count int) ([]interface{}, error)
+}
+
+// EventStore 最新事件与事件历史缓存
+type EventStore interface {
+	LatestStore
+	HistoryStore
+}
+
+// HashStore 读取统计类 HASH（链路质量）
+type HashStore interface {
+	GetHash(key string) (map[string]string, error)
+}
+
+// SkewStore 在链路质量统计中累计各飞行器的时钟偏差
+type SkewStore interface {
+	RecordClockSkew(statsKey string, skewMs int64) error
+}
+
+// LinkStore 链路质量统计与链路监测状态：seenKey 为各飞行器最近接收时间，lostKey 为已判定失联的飞行器
+type LinkStore interface {
+	HashStore
+	TouchLink(seenKey, lostKey, member string, at time.Time) (bool, error)
+	LinkLastSeen(seenKey string, members []string, at time.Time) (map[string]time.Time, error)
+	LinkSeenAt(seenKey, member string) (time.Time, bool, error)
+	SetLinkLost(lostKey, member string, lost bool) (bool, error)
+	PruneLinks(seenKey, lostKey string, keep []string) error
+}
+
+// 各接口的 Redis 实现即 dbservice.RedisDict
+var (
+	_ KVStore    = (*dbservice.RedisDict)(nil)
+	_ EventStore = (*dbservice.RedisDict)(nil)
+	_ LinkStore  = (*dbservice.RedisDict)(nil)
+)
@@ -0,0 +1,172 @@
+package validation_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// earthRadius 地球平均半径（米）
+const earthRadius = 6371008.8
+
+// lastPoint 飞行器上一个通过校验的点，Time 为毫秒时间戳
+type lastPoint struct {
+	Time      int64   `json:"Time"`
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+	Altitude  float64 `json:"Altitude"`
+}
+
+// lastPointKey 返回飞行器上一个点在 Redis 中的 key（不含前缀），{AircraftID} 与去重窗口使用相同的 hash tag
+func lastPointKey(AircraftID int) string {
+	return "lastpoint:{" + strconv.Itoa(AircraftID) + "}"
+}
+
+// Validator 上传状态的合理性校验：范围、时间与相对上一个点的速度，未通过的点写入隔离区
+type Validator struct {
+	Config *db_config_model.ValidationConfigModel
+	// LastPoints 各飞行器上一个通过校验的点，多个实例共享，保留 SpeedWindowSec
+	LastPoints  repository_service.LatestStore
+	Quarantines repository_service.QuarantineRepository
+	// Now 当前时间，测试中可替换
+	Now func() time.Time
+}
+
+// NewValidator 由保存上一个点的缓存（带 DedupPrefix 前缀的 Redis）与隔离区创建校验器，
+// ValidationCfg.Enable 为 false 时返回 nil
+func NewValidator(
+	lastPoints repository_service.LatestStore, quarantines repository_service.QuarantineRepository,
+	ValidationConfig *db_config_model.ValidationConfigModel,
+) *Validator {
+	if !ValidationConfig.Enable {
+		return nil
+	}
+	return &Validator{Config: ValidationConfig, LastPoints: lastPoints, Quarantines: quarantines, Now: time.Now}
+}
+
+// NormalizeYaw 将偏航角归一化到 [0, 360)
+func NormalizeYaw(yaw float64) float64 {
+	yaw = math.Mod(yaw, 360)
+	if yaw < 0 {
+		yaw += 360
+	}
+	return yaw
+}
+
+// Distance 两点间的距离（米），由大圆距离与高度差合成
+func Distance(lat1, lon1, alt1, lat2, lon2, alt2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*toRad, (lon2-lon1)*toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	ground := 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+	return math.Hypot(ground, alt2-alt1)
+}
+
+// Validate 归一化 status.Yaw 并检查坐标、高度、时间与相对上一个点的速度，返回未通过的原因；
+// 全部通过时将其记为该飞行器的上一个点：只替换时间更早的点，多个实例并发或乱序上报时保留最新的点，
+// 保留 SpeedWindowSec 后过期，不与下一次飞行的点比较。
+// 读写上一个点失败时返回 error，reasons 中仍包含与上一个点无关的检查结果
+func (v *Validator) Validate(status *data_flow_model.AircraftStatus) ([]quarantine_model.Reason, error) {
+	cfg := v.Config
+	status.Yaw = NormalizeYaw(status.Yaw)
+	var reasons []quarantine_model.Reason
+	reject := func(code, format string, args ...interface{}) {
+		reasons = append(reasons, quarantine_model.Reason{Code: code, Detail: fmt.Sprintf(format, args...)})
+	}
+	if status.Latitude < -90 || status.Latitude > 90 {
+		reject(quarantine_model.ReasonLatitude, "latitude %g not in [-90, 90]", status.Latitude)
+	}
+	if status.Longitude < -180 || status.Longitude > 180 {
+		reject(quarantine_model.ReasonLongitude, "longitude %g not in [-180, 180]", status.Longitude)
+	}
+	if cfg.RejectNullIsland && status.Latitude == 0 && status.Longitude == 0 {
+		reject(quarantine_model.ReasonNullIsland, "latitude and longitude are both 0")
+	}
+	if status.Altitude < cfg.MinAltitude || status.Altitude > cfg.MaxAltitude {
+		reject(quarantine_model.ReasonAltitude, "altitude %g not in [%g, %g]", status.Altitude, cfg.MinAltitude, cfg.MaxAltitude)
+	}
+	pointTime, err := utils.ParseSqlTimeStr(status.TimeString)
+	if err != nil {
+		return reasons, err
+	}
+	now := v.Now()
+	if cfg.MaxFutureSec > 0 && pointTime.Sub(now) > time.Duration(cfg.MaxFutureSec)*time.Second {
+		reject(quarantine_model.ReasonFuture, "%s ahead of server time (max %ds)",
+			pointTime.Sub(now).Round(time.Millisecond), cfg.MaxFutureSec)
+	}
+	if cfg.MaxPastSec > 0 && now.Sub(pointTime) > time.Duration(cfg.MaxPastSec)*time.Second {
+		reject(quarantine_model.ReasonPast, "%s behind server time (max %ds)",
+			now.Sub(pointTime).Round(time.Millisecond), cfg.MaxPastSec)
+	}
+	// 位置或时间本身不合理时不再与上一个点比较
+	if len(reasons) > 0 || cfg.MaxSpeedMps <= 0 {
+		return reasons, nil
+	}
+
+	key := lastPointKey(status.AircraftID)
+	previous, err := v.lastPoint(key)
+	if err != nil {
+		return reasons, err
+	}
+	if previous != nil {
+		elapsed := pointTime.Sub(time.UnixMilli(previous.Time))
+		// 重发或乱序的点（时间不晚于上一个点）不检查速度，也不替换上一个点
+		if elapsed <= 0 {
+			return reasons, nil
+		}
+		if elapsed <= time.Duration(cfg.SpeedWindowSec)*time.Second {
+			distance := Distance(previous.Latitude, previous.Longitude, previous.Altitude,
+				status.Latitude, status.Longitude, status.Altitude)
+			if speed := distance / elapsed.Seconds(); speed > cfg.MaxSpeedMps {
+				reject(quarantine_model.ReasonSpeed, "%.1f m/s over %.0f m in %s (max %g m/s)",
+					speed, distance, elapsed, cfg.MaxSpeedMps)
+				return reasons, nil
+			}
+		}
+	}
+	value, _ := json.Marshal(&lastPoint{
+		Time: pointTime.UnixMilli(), Latitude: status.Latitude, Longitude: status.Longitude, Altitude: status.Altitude,
+	})
+	_, err = v.LastPoints.SetIfNewer(key, string(value), pointTime, time.Duration(cfg.SpeedWindowSec)*time.Second)
+	return reasons, err
+}
+
+// lastPoint 读取 key 对应的上一个点，没有时返回 nil
+func (v *Validator) lastPoint(key string) (*lastPoint, error) {
+	re, err := v.LastPoints.Get(key)
+	if err != nil || re == nil {
+		return nil, err
+	}
+	jsonData, _ := json.Marshal(re)
+	var point lastPoint
+	if err = json.Unmarshal(jsonData, &point); err != nil {
+		return nil, err
+	}
+	return &point, nil
+}
+
+// Quarantine 将未通过校验的点连同原因写入隔离区
+func (v *Validator) Quarantine(
+	ctx context.Context, status *data_flow_model.AircraftStatus, reasons []quarantine_model.Reason,
+) (*quarantine_model.QuarantinedPoint, error) {
+	point := &quarantine_model.QuarantinedPoint{
+		AircraftID: status.AircraftID, TimeString: status.TimeString, Point: *status, Reasons: reasons,
+	}
+	if err := v.Quarantines.AddQuarantine(ctx, point); err != nil {
+		return nil, err
+	}
+	for _, reason := range reasons {
+		metrics_service.TelemetryQuarantined.WithLabelValues(reason.Code).Inc()
+	}
+	return point, nil
+}
@@ -0,0 +1,245 @@
+package webhook_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/health_service"
+	"uam-power-backend/service/metrics_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// maxErrorLength 投递记录中保存的错误信息（含响应体）的最大长度，与 LastError 列一致
+const maxErrorLength = 1024
+
+// Dispatcher 将任务与事件通知写入各订阅的投递记录，并由 Run 循环投递到订阅的 URL。
+// 投递记录保存在 MySQL 中，失败的投递按指数退避重试，进程重启后继续；多个实例可同时运行 Run
+type Dispatcher struct {
+	Repo           repository_service.WebhookRepository
+	Client         *http.Client
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
+	Heartbeat      *health_service.Heartbeat
+	StopFlag       atomic.Bool
+	Done           chan bool
+	stop           chan struct{}
+}
+
+// NewDispatcher 由订阅存储与 WebhookCfg 创建投递服务，WebhookCfg.Enable 为 false 时返回 nil
+func NewDispatcher(repo repository_service.WebhookRepository, WebhookConfig *db_config_model.WebhookConfigModel) *Dispatcher {
+	if !WebhookConfig.Enable {
+		return nil
+	}
+	timeout := time.Duration(WebhookConfig.TimeoutMs) * time.Millisecond
+	pollInterval := time.Duration(WebhookConfig.PollIntervalSec) * time.Second
+	heartbeat := health_service.NewHeartbeat()
+	// 每批记录并发投递，一批至多耗时 timeout
+	health_service.RegisterLiveness("webhook:Dispatcher", heartbeat.Check(pollInterval+timeout+30*time.Second))
+	return &Dispatcher{
+		Repo:           repo,
+		Client:         &http.Client{Timeout: timeout},
+		MaxAttempts:    WebhookConfig.MaxAttempts,
+		InitialBackoff: time.Duration(WebhookConfig.InitialBackoffSec) * time.Second,
+		MaxBackoff:     time.Duration(WebhookConfig.MaxBackoffSec) * time.Second,
+		PollInterval:   pollInterval,
+		BatchSize:      WebhookConfig.BatchSize,
+		Heartbeat:      heartbeat,
+		Done:           make(chan bool),
+		stop:           make(chan struct{}),
+	}
+}
+
+// Sign 计算投递的签名：HMAC-SHA256(secret, timestamp + "." + body) 的十六进制。
+// 接收方以同样方式计算并与 X-UAM-Signature 中 "sha256=" 之后的部分比较，并拒绝时间戳过旧的请求
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff 返回第 attempts 次失败后的重试间隔：InitialBackoff 起每次翻倍，至多 MaxBackoff
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	backoff := d.InitialBackoff
+	for i := 1; i < attempts && backoff < d.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.MaxBackoff {
+		backoff = d.MaxBackoff
+	}
+	return backoff
+}
+
+// Notify 为接收 notificationType 的每个订阅写入一条投递记录。id 在同一通知的重投间不变，
+// 已有记录的订阅不会重复投递。d 为 nil（未开启 webhook）时不做任何事
+func (d *Dispatcher) Notify(ctx context.Context, notificationType, id string, data interface{}) error {
+	if d == nil {
+		return nil
+	}
+	subscriptions, err := d.Repo.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	var deliveries []webhook_model.Delivery
+	var body []byte
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(notificationType) {
+			continue
+		}
+		if body == nil {
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			body, err = json.Marshal(webhook_model.Notification{ID: id, Type: notificationType, Time: now, Data: payload})
+			if err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, webhook_model.Delivery{
+			SubscriptionID: subscription.SubscriptionID, NotificationID: id, Type: notificationType,
+			Body: body, NextAttemptTime: now,
+		})
+	}
+	_, err = d.Repo.EnqueueDeliveries(ctx, deliveries)
+	return err
+}
+
+// Run 每隔 PollInterval 投递一次到期的记录，直到 Stop；取满一批时立即继续取下一批
+func (d *Dispatcher) Run() {
+	logger := utils.ComponentLogger("WebhookDispatcher")
+	logger.Info("start WebhookDispatcher successfully!")
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+	for !d.StopFlag.Load() {
+		d.Heartbeat.Beat()
+		if d.DeliverDue(context.Background(), logger) >= d.BatchSize {
+			continue
+		}
+		select {
+		case <-ticker.C:
+		case <-d.stop:
+		}
+	}
+	d.Done <- true
+}
+
+// DeliverDue 取出一批到期的记录并发投递，返回取出的条数
+func (d *Dispatcher) DeliverDue(ctx context.Context, logger *slog.Logger) int {
+	// 取出的记录在投递期间（至多一个 HTTP 超时）不会被其他实例取出
+	deliveries, err := d.Repo.ClaimDeliveries(ctx, time.Now(), 2*d.Client.Timeout+time.Second, d.BatchSize)
+	if err != nil {
+		logger.Error("failed to claim deliveries", "error", err)
+		return 0
+	}
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *webhook_model.Delivery) {
+			defer wg.Done()
+			d.deliver(ctx, logger, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+	return len(deliveries)
+}
+
+// deliver 投递一条记录并保存结果：2xx 为成功，其余状态码与网络错误按退避时间重试，次数用尽后标记为 failed
+func (d *Dispatcher) deliver(ctx context.Context, logger *slog.Logger, delivery *webhook_model.Delivery) {
+	logger = logger.With("delivery_id", delivery.DeliveryID, "subscription_id", delivery.SubscriptionID,
+		"type", delivery.Type)
+	statusCode, err := d.post(ctx, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	result := "delivered"
+	if err == nil {
+		delivery.Status, delivery.LastError, delivery.DeliveredTime = webhook_model.DeliveryDelivered, "", &now
+	} else {
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+		if delivery.Attempts >= d.MaxAttempts {
+			delivery.Status, result = webhook_model.DeliveryFailed, "failed"
+			logger.Error("webhook delivery failed, giving up", "attempts", delivery.Attempts, "error", err)
+		} else {
+			delivery.NextAttemptTime, result = now.Add(d.Backoff(delivery.Attempts)), "retry"
+			logger.Warn("webhook delivery failed, will retry", "attempts", delivery.Attempts,
+				"next_attempt", delivery.NextAttemptTime, "error", err)
+		}
+	}
+	metrics_service.WebhookDeliveries.WithLabelValues(result).Inc()
+	if err = d.Repo.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Error("failed to save delivery result", "error", err)
+	}
+}
+
+// post 发送一次签名的 POST 请求，返回响应状态码；非 2xx 响应返回包含响应体的错误
+func (d *Dispatcher) post(ctx context.Context, delivery *webhook_model.Delivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhook_model.HeaderEvent, delivery.Type)
+	request.Header.Set(webhook_model.HeaderDelivery, strconv.Itoa(delivery.DeliveryID))
+	request.Header.Set(webhook_model.HeaderTimestamp, timestamp)
+	request.Header.Set(webhook_model.HeaderSignature, "sha256="+Sign(delivery.Secret, timestamp, delivery.Body))
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorLength))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("HTTP %d: %s", response.StatusCode, responseBody)
+	}
+	return response.StatusCode, nil
+}
+
+// truncate 截断到至多 length 字节，不截断多字节字符
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return strings.ToValidUTF8(value[:length], "")
+}
+
+// Start 启动投递循环；d 为 nil 时不做任何事
+func (d *Dispatcher) Start() {
+	if d == nil {
+		return
+	}
+	go d.Run()
+}
+
+// Stop 停止投递循环并等待当前一批投递结束；d 为 nil 时不做任何事
+func (d *Dispatcher) Stop() {
+	if d == nil {
+		return
+	}
+	d.StopFlag.Store(true)
+	close(d.stop)
+	<-d.Done
+}
@@ -0,0 +1,240 @@
+package alert_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"uam-power-backend/controller/alert_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/alert_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/webhook_service"
+	"uam-power-backend/utils"
+)
+
+func TestEventsOpenAlerts(t *testing.T) {
+	db := repository_service.NewMemoryDatabase()
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer("aircraft_event", "KafkaToAlert")
+	toAlert := data_transfer_service.NewKafkaToAlertFromStores(consumer, db, nil,
+		&db_config_model.AlertConfigModel{Enable: true, EventTypes: []string{"GEOFENCE_BREACH"}, MinSeverity: "critical"})
+	toAlert.Start()
+	producer := bus.Producer("aircraft_event")
+	for _, event := range []string{
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:01.000000","Event":"TAKEOFF","Severity":"info"}`,
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:02.000000","Event":"GEOFENCE_BREACH","Severity":"warning"}`,
+		// 重投的同一事件不重复生成告警
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:02.000000","Event":"GEOFENCE_BREACH","Severity":"warning"}`,
+		// 未携带 Severity 的内置事件按目录补齐
+		`{"AircraftID":2,"TimeString":"2024-05-01 10:00:03.000000","Event":"EMERGENCY","Payload":{"Reason":"motor"}}`,
+		`{"AircraftID":2,"TimeString":"2024-05-01 10:00:04.000000","Event":"LOW_BATTERY","Severity":"warning"}`,
+	} {
+		if err := producer.SendKeyedMessage(context.Background(), "1", event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 全部消息确认后停止，Stop 等待当前消息处理完毕
+	for deadline := time.Now().Add(5 * time.Second); consumer.Lag() > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for events to be consumed")
+		}
+	}
+	toAlert.Stop()
+	alerts, _ := db.ListAlerts(context.Background(), &alert_model.ListAlertsRequest{})
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %+v", alerts)
+	}
+	if alerts[0].Event != "EMERGENCY" || alerts[0].Severity != "critical" || string(alerts[0].Payload) != `{"Reason":"motor"}` {
+		t.Errorf("unexpected EMERGENCY alert %+v", alerts[0])
+	}
+	if alerts[1].Event != "GEOFENCE_BREACH" || alerts[1].Status != alert_model.StatusOpen {
+		t.Errorf("unexpected GEOFENCE_BREACH alert %+v", alerts[1])
+	}
+}
+
+func TestUnacknowledgedAlertsEscalate(t *testing.T) {
+	db := repository_service.NewMemoryDatabase()
+	ctx, logger := context.Background(), utils.ComponentLogger("test")
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{
+		URL: "http://127.0.0.1:1", Secret: "0123456789abcdef-secret", EventTypes: []string{webhook_model.TypeAlertEscalated},
+	})
+	webhooks := webhook_service.NewDispatcher(db, &db_config_model.WebhookConfigModel{
+		Enable: true, TimeoutMs: 1000, MaxAttempts: 3, InitialBackoffSec: 1, MaxBackoffSec: 1, PollIntervalSec: 1, BatchSize: 10,
+	})
+	toAlert := data_transfer_service.NewKafkaToAlertFromStores(bus_service.NewMemoryBus(1).Consumer("aircraft_event", "escalation"), db,
+		webhooks, &db_config_model.AlertConfigModel{Enable: true, EscalateAfterSec: 1, MaxEscalationLevel: 2, CheckIntervalSec: 1})
+	toAlert.EscalateAfter = 20 * time.Millisecond
+	for i := 1; i <= 2; i++ {
+		_, _ = db.CreateAlert(ctx, &alert_model.Alert{AircraftID: i, Event: "LINK_LOST", Severity: "warning", EventTime: time.Now()})
+	}
+	_, _ = db.TransitionAlert(ctx, 2, []string{alert_model.StatusOpen}, &alert_model.AlertChange{
+		Action: alert_model.ActionAcknowledge, Status: alert_model.StatusAcknowledged, Operator: "ops",
+	})
+
+	toAlert.CheckEscalation(ctx, logger)
+	if alert, _ := db.GetAlert(ctx, 1); alert.EscalationLevel != 0 {
+		t.Fatalf("fresh alert escalated: %+v", alert)
+	}
+	time.Sleep(70 * time.Millisecond)
+	toAlert.CheckEscalation(ctx, logger)
+	toAlert.CheckEscalation(ctx, logger)
+	if alert, _ := db.GetAlert(ctx, 1); alert.EscalationLevel != 2 {
+		t.Errorf("expected escalation capped at level 2, got %d", alert.EscalationLevel)
+	}
+	if alert, _ := db.GetAlert(ctx, 2); alert.EscalationLevel != 0 {
+		t.Errorf("acknowledged alert should not escalate, got level %d", alert.EscalationLevel)
+	}
+	audit, _ := db.ListAlertAudit(ctx, 1)
+	if len(audit) != 3 || audit[1].Action != alert_model.ActionEscalate || audit[2].Operator != alert_model.SystemOperator {
+		t.Errorf("unexpected escalation audit %+v", audit)
+	}
+	// 每次升级通知一次，重复检查不重复通知
+	deliveries, _ := db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{Type: webhook_model.TypeAlertEscalated})
+	if len(deliveries) != 2 {
+		t.Fatalf("expected 2 escalation notifications, got %+v", deliveries)
+	}
+	var notification webhook_model.Notification
+	var escalated alert_model.Alert
+	_ = json.Unmarshal(deliveries[0].Body, &notification)
+	_ = json.Unmarshal(notification.Data, &escalated)
+	if escalated.AlertID != 1 || escalated.EscalationLevel < 1 {
+		t.Errorf("unexpected escalation notification %s", deliveries[0].Body)
+	}
+}
+
+func TestAlertWorkflow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := repository_service.NewMemoryDatabase()
+	_, _ = db.CreateAlert(context.Background(), &alert_model.Alert{
+		AircraftID: 7, Event: "EMERGENCY", Severity: "critical", EventTime: time.Now(),
+	})
+	controller := alert_controller.NewAlertControllerFromStores(db)
+	r := gin.New()
+	r.POST("/alert/list", controller.ListAlerts)
+	r.POST("/alert/acknowledge", controller.AcknowledgeAlert)
+	r.POST("/alert/resolve", controller.ResolveAlert)
+	r.POST("/alert/audit", controller.AlertAudit)
+	post := func(path string, body interface{}) (int, json.RawMessage) {
+		payload, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload)))
+		var resp struct {
+			Data json.RawMessage `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Data
+	}
+
+	if code, _ := post("/alert/acknowledge", map[string]interface{}{"AlertID": 1}); code != 400 {
+		t.Errorf("missing Operator: expected 400, got %d", code)
+	}
+	if code, _ := post("/alert/acknowledge", map[string]interface{}{"AlertID": 9, "Operator": "ops"}); code != 404 {
+		t.Errorf("unknown alert: expected 404, got %d", code)
+	}
+	code, data := post("/alert/acknowledge", map[string]interface{}{"AlertID": 1, "Operator": "ops", "Note": "on it"})
+	var alert alert_model.Alert
+	_ = json.Unmarshal(data, &alert)
+	if code != 200 || alert.Status != alert_model.StatusAcknowledged || alert.Assignee != "ops" || alert.AckTime == nil {
+		t.Fatalf("acknowledge: %d %s", code, data)
+	}
+	if code, _ := post("/alert/acknowledge", map[string]interface{}{"AlertID": 1, "Operator": "ops"}); code != 409 {
+		t.Errorf("acknowledge twice: expected 409, got %d", code)
+	}
+	if code, data := post("/alert/list", map[string]string{"Status": "open"}); code != 200 || string(data) != "[]" {
+		t.Errorf("open alerts after acknowledge: %d %s", code, data)
+	}
+	code, data = post("/alert/resolve", map[string]interface{}{"AlertID": 1, "Operator": "lead", "Note": "landed safely"})
+	_ = json.Unmarshal(data, &alert)
+	if code != 200 || alert.Status != alert_model.StatusResolved || alert.Assignee != "ops" || alert.ResolveTime == nil {
+		t.Fatalf("resolve: %d %s", code, data)
+	}
+	if code, _ := post("/alert/resolve", map[string]interface{}{"AlertID": 1, "Operator": "lead"}); code != 409 {
+		t.Errorf("resolve twice: expected 409, got %d", code)
+	}
+
+	code, data = post("/alert/audit", map[string]int{"AlertID": 1})
+	var audit []alert_model.AlertAudit
+	_ = json.Unmarshal(data, &audit)
+	if code != 200 || len(audit) != 3 {
+		t.Fatalf("audit: %d %s", code, data)
+	}
+	for i, expected := range []alert_model.AlertAudit{
+		{Action: "open", FromStatus: "", ToStatus: "open", Operator: "system"},
+		{Action: "acknowledge", FromStatus: "open", ToStatus: "acknowledged", Operator: "ops", Note: "on it"},
+		{Action: "resolve", FromStatus: "acknowledged", ToStatus: "resolved", Operator: "lead", Note: "landed safely"},
+	} {
+		got := audit[i]
+		if got.Action != expected.Action || got.FromStatus != expected.FromStatus || got.ToStatus != expected.ToStatus ||
+			got.Operator != expected.Operator || got.Note != expected.Note {
+			t.Errorf("audit[%d]: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+// flakyAlerts 前 failures 次创建告警返回错误的告警存储，模拟 MySQL 暂时不可用
+type flakyAlerts struct {
+	*repository_service.MemoryDatabase
+	failures int
+}
+
+func (f *flakyAlerts) CreateAlert(ctx context.Context, alert *alert_model.Alert) (bool, error) {
+	if f.failures > 0 {
+		f.failures--
+		return false, errors.New("mysql unavailable")
+	}
+	return f.MemoryDatabase.CreateAlert(ctx, alert)
+}
+
+func TestFailedAlertIsNotCommitted(t *testing.T) {
+	db := repository_service.NewMemoryDatabase()
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer("aircraft_event", "KafkaToAlert")
+	toAlert := data_transfer_service.NewKafkaToAlertFromStores(consumer, &flakyAlerts{MemoryDatabase: db, failures: 2}, nil,
+		&db_config_model.AlertConfigModel{Enable: true, EventTypes: []string{"EMERGENCY"}})
+	toAlert.Start()
+	if err := bus.Producer("aircraft_event").SendKeyedMessage(context.Background(), "1",
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:03.000000","Event":"EMERGENCY"}`); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); consumer.Lag() > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event to be committed")
+		}
+	}
+	toAlert.Stop()
+	if alerts, _ := db.ListAlerts(context.Background(), &alert_model.ListAlertsRequest{}); len(alerts) != 1 {
+		t.Fatalf("expected the alert to be created after retries, got %+v", alerts)
+	}
+}
+
+func TestStopInterruptsRetryBackoff(t *testing.T) {
+	db := repository_service.NewMemoryDatabase()
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer("aircraft_event", "KafkaToAlert")
+	toAlert := data_transfer_service.NewKafkaToAlertFromStores(consumer, &flakyAlerts{MemoryDatabase: db, failures: 1 << 30}, nil,
+		&db_config_model.AlertConfigModel{Enable: true, EventTypes: []string{"EMERGENCY"}})
+	toAlert.Start()
+	if err := bus.Producer("aircraft_event").SendKeyedMessage(context.Background(), "1",
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:03.000000","Event":"EMERGENCY"}`); err != nil {
+		t.Fatal(err)
+	}
+	// 重试若干次后退避已达秒级，Stop 不等待退避结束
+	time.Sleep(1600 * time.Millisecond)
+	start := time.Now()
+	toAlert.Stop()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Stop waited %v for the retry backoff", elapsed)
+	}
+	if alerts, _ := db.ListAlerts(context.Background(), &alert_model.ListAlertsRequest{}); len(alerts) != 0 {
+		t.Fatalf("expected no alert while storage is failing, got %+v", alerts)
+	}
+}
@@ -0,0 +1,21 @@
+package app_test
+
+import (
+	"strings"
+	"testing"
+	"uam-power-backend/service/app_service"
+	"uam-power-backend/utils"
+)
+
+func TestContainerFailsFastNamingDependency(t *testing.T) {
+	cfg := utils.DefaultDBConfig()
+	cfg.MySqlCfg.Host, cfg.MySqlCfg.Port = "127.0.0.1", 1
+	cfg.MySqlCfg.Psw = "secret"
+	app, err := app_service.NewContainer(cfg)
+	if err == nil || app != nil {
+		t.Fatalf("expected startup to fail, got %v", app)
+	}
+	if !strings.HasPrefix(err.Error(), "mysql "+cfg.MySqlCfg.DB+"@127.0.0.1:1:") {
+		t.Errorf("error should name the failed dependency, got %q", err)
+	}
+}
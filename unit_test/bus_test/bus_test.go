@@ -0,0 +1,140 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newBus 返回按 backend 创建生产者与消费者的方法，各总线共用同一组语义测试
+func newBus(t *testing.T, backend string) (
+	func(topic string) bus_service.Producer, func(topic, group string) bus_service.Consumer,
+) {
+	busCfg := &db_config_model.BusConfigModel{Backend: backend, StreamPrefix: "stream:", ClaimIdleMs: 50}
+	var rdb *dbservice.RedisDict
+	if backend == "redis" {
+		server := miniredis.RunT(t)
+		port, _ := strconv.Atoi(server.Port())
+		rdb, _ = dbservice.NewRedisDictWithConfig(&db_config_model.RedisConfigModel{Host: server.Host(), Port: port}, "")
+	}
+	memoryBus := bus_service.NewMemoryBus(100)
+	producer := func(topic string) bus_service.Producer {
+		if backend == "memory" {
+			return memoryBus.Producer(topic)
+		}
+		p, err := bus_service.NewProducer(busCfg, &db_config_model.KafkaConfigModel{}, rdb, topic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	consumer := func(topic, group string) bus_service.Consumer {
+		if backend == "memory" {
+			return memoryBus.Consumer(topic, group)
+		}
+		c, err := bus_service.NewConsumer(busCfg, &db_config_model.KafkaConfigModel{}, rdb, topic, group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	return producer, consumer
+}
+
+func fetch(t *testing.T, consumer bus_service.Consumer) *bus_service.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg, err := consumer.FetchMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestBusSemantics(t *testing.T) {
+	for _, backend := range []string{"memory", "redis"} {
+		t.Run(backend, func(t *testing.T) {
+			newProducer, newConsumer := newBus(t, backend)
+			redisGroup := newConsumer("AircraftData", "KafkaToRedis")
+			mysqlGroup := newConsumer("AircraftData", "KafkaToMysql")
+			producer := newProducer("AircraftData")
+			if !producer.Sync() {
+				t.Error("producer should be synchronous")
+			}
+			ctx := utils.ContextWithRequestID(context.Background(), "req-1")
+			for i := 0; i < 3; i++ {
+				if err := producer.SendKeyedMessage(ctx, "7", "msg-"+strconv.Itoa(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 每个消费组都收到全部消息，且保持发送顺序
+			for _, consumer := range []bus_service.Consumer{redisGroup, mysqlGroup} {
+				for i := 0; i < 3; i++ {
+					msg := fetch(t, consumer)
+					if msg.Value != "msg-"+strconv.Itoa(i) || msg.Key != "7" || msg.Headers[utils.RequestIDHeader] != "req-1" {
+						t.Fatalf("unexpected message %+v", msg)
+					}
+					if i < 2 {
+						if err := consumer.CommitMessage(context.Background(), msg); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+			}
+
+			// 未确认的消息在消费者关闭后重新投递给同组消费者
+			if err := redisGroup.Close(); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			replacement := newConsumer("AircraftData", "KafkaToRedis")
+			msg := fetch(t, replacement)
+			if msg.Value != "msg-2" {
+				t.Fatalf("expected msg-2 to be redelivered, got %+v", msg)
+			}
+			if err := replacement.CommitMessage(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+
+			// miniredis 的 XINFO GROUPS 以 stream 长度作为 lag，只在 memory 总线上校验
+			if backend == "memory" {
+				if lag := mysqlGroup.Lag(); lag != 1 {
+					t.Errorf("expected lag 1 for the unacknowledged message, got %d", lag)
+				}
+				if lag := replacement.Lag(); lag != 0 {
+					t.Errorf("expected no lag, got %d", lag)
+				}
+			}
+
+			// 已确认的消息不再投递，空闲时按超时返回
+			time.Sleep(100 * time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			if msg, err := replacement.FetchMessage(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected timeout, got %+v %v", msg, err)
+			}
+		})
+	}
+}
+
+func TestMemoryBusBufferFull(t *testing.T) {
+	memoryBus := bus_service.NewMemoryBus(1)
+	memoryBus.Consumer("AircraftEvent", "KafkaToMysql")
+	producer := memoryBus.Producer("AircraftEvent")
+	if err := producer.SendKeyedMessage(context.Background(), "1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.SendKeyedMessage(context.Background(), "1", "b"); !errors.Is(err, bus_service.ErrMemoryBusFull) {
+		t.Fatalf("expected ErrMemoryBusFull, got %v", err)
+	}
+}
@@ -1,5 +1,22 @@
 package DBconfig
 
+import (
+	"os"
+	"strconv"
+	"testing"
+	"uam-power-backend/utils"
+)
+
+// Service 连接外部服务的集成测试所需的服务
+type Service string
+
+const (
+	MySQL Service = "mysql"
+	Redis Service = "redis"
+	Kafka Service = "kafka"
+	Mongo Service = "mongo"
+)
+
 type Config struct {
 	MySQLCfg struct {
 		Usr  string
@@ -22,24 +39,40 @@ type Config struct {
 	}
 }
 
-func NewConfig() *Config {
+// NewConfig 从与服务相同的 UAM_* 环境变量读取集成测试连接的外部服务（MySQL、Redis、Kafka 见 utils.LoadDBConfig，
+// Mongo 为 UAM_MONGOCFG_HOST/PORT/DB），service 的主机未设置时跳过测试，仓库中不保存任何地址与凭据
+func NewConfig(t testing.TB, service Service) *Config {
+	t.Helper()
+	dbConfig, err := utils.LoadDBConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	cfg := Config{
-		KafkaAddr:  "175.178.125.164:9092",
+		KafkaAddr:  dbConfig.KafkaCfg.Addr,
 		KafkaTopic: "goTest",
 	}
-	cfg.RedisCfg.Host = "119.29.181.98"
-	cfg.RedisCfg.Port = 6379
-	cfg.RedisCfg.DBno = 2
+	cfg.RedisCfg.Host = dbConfig.RedisCfg.Host
+	cfg.RedisCfg.Port = dbConfig.RedisCfg.Port
+	cfg.RedisCfg.DBno = dbConfig.RedisCfg.DB
 
-	cfg.MySQLCfg.Usr = "keane"
-	cfg.MySQLCfg.Psw = "Zhonglaoshizhen6!"
-	cfg.MySQLCfg.Host = "gz-cdb-1n3w64q3.sql.tencentcdb.com"
-	cfg.MySQLCfg.DB = "node_db"
-	cfg.MySQLCfg.Port = 25100
+	cfg.MySQLCfg.Usr = dbConfig.MySqlCfg.Usr
+	cfg.MySQLCfg.Psw = dbConfig.MySqlCfg.Psw
+	cfg.MySQLCfg.Host = dbConfig.MySqlCfg.Host
+	cfg.MySQLCfg.DB = dbConfig.MySqlCfg.DB
+	cfg.MySQLCfg.Port = dbConfig.MySqlCfg.Port
 
-	cfg.MongoCfg.Host = "175.178.125.164"
-	cfg.MongoCfg.Port = 27017
-	cfg.MongoCfg.DB = "test_go"
+	cfg.MongoCfg.Host = os.Getenv("UAM_MONGOCFG_HOST")
+	cfg.MongoCfg.Port, _ = strconv.Atoi(os.Getenv("UAM_MONGOCFG_PORT"))
+	if cfg.MongoCfg.Port == 0 {
+		cfg.MongoCfg.Port = 27017
+	}
+	cfg.MongoCfg.DB = os.Getenv("UAM_MONGOCFG_DB")
 
+	host := map[Service]string{
+		MySQL: cfg.MySQLCfg.Host, Redis: cfg.RedisCfg.Host, Kafka: cfg.KafkaAddr, Mongo: cfg.MongoCfg.Host,
+	}[service]
+	if host == "" {
+		t.Skipf("%s is not configured, set the UAM_* environment variables to run this test", service)
+	}
 	return &cfg
 }
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"github.com/segmentio/kafka-go"
+	"strings"
+	"testing"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+// rejects 返回配置校验是否报告了 field 的错误，用于检查配置校验与解析结果一致
+func rejects(cfg *db_config_model.DbConfigModel, field string) bool {
+	err := utils.ValidateDBConfig(cfg)
+	return err != nil && strings.Contains(err.Error(), field+" ")
+}
+
+func TestKafkaRequiredAcks(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		want    kafka.RequiredAcks
+		wantErr bool
+	}{
+		{"", kafka.RequireNone, false},
+		{"none", kafka.RequireNone, false},
+		{"0", kafka.RequireNone, false},
+		{"one", kafka.RequireOne, false},
+		{"ONE", kafka.RequireOne, false},
+		{"1", kafka.RequireOne, false},
+		{"all", kafka.RequireAll, false},
+		{"-1", kafka.RequireAll, false},
+		{"quorum", kafka.RequireNone, true},
+		{"2", kafka.RequireNone, true},
+	} {
+		got, err := dbservice.KafkaRequiredAcks(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("KafkaRequiredAcks(%q) = %v, %v", tc.name, got, err)
+		}
+		// 解析通过的值配置校验也应通过，反之亦然
+		cfg := utils.DefaultDBConfig()
+		cfg.KafkaCfg.RequiredAcks, cfg.KafkaCfg.EventDelivery.RequiredAcks = tc.name, tc.name
+		if rejects(cfg, "KafkaCfg.RequiredAcks") != tc.wantErr || rejects(cfg, "KafkaCfg.EventDelivery.RequiredAcks") != tc.wantErr {
+			t.Errorf("ValidateDBConfig disagrees with KafkaRequiredAcks on %q", tc.name)
+		}
+	}
+}
+
+func TestKafkaCompression(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		want    kafka.Compression
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"none", 0, false},
+		{"gzip", kafka.Gzip, false},
+		{"Snappy", kafka.Snappy, false},
+		{"lz4", kafka.Lz4, false},
+		{"zstd", kafka.Zstd, false},
+		{"brotli", 0, true},
+	} {
+		got, err := dbservice.KafkaCompression(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("KafkaCompression(%q) = %v, %v", tc.name, got, err)
+		}
+		cfg := utils.DefaultDBConfig()
+		cfg.KafkaCfg.Compression = tc.name
+		if rejects(cfg, "KafkaCfg.Compression") != tc.wantErr {
+			t.Errorf("ValidateDBConfig disagrees with KafkaCompression on %q", tc.name)
+		}
+	}
+}
+
+func TestKafkaSASLMechanism(t *testing.T) {
+	for _, tc := range []struct {
+		mechanism string
+		want      string
+		wantErr   bool
+	}{
+		{"", "", false},
+		{"PLAIN", "PLAIN", false},
+		{"plain", "PLAIN", false},
+		{"SCRAM-SHA-256", "SCRAM-SHA-256", false},
+		{"scram-sha-512", "SCRAM-SHA-512", false},
+		{"GSSAPI", "", true},
+	} {
+		sasl := db_config_model.KafkaSASLConfigModel{Mechanism: tc.mechanism, Username: "usr", Password: "psw"}
+		got, err := dbservice.KafkaSASLMechanism(&sasl)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("KafkaSASLMechanism(%q) error = %v", tc.mechanism, err)
+			continue
+		}
+		if name := ""; got != nil {
+			name = got.Name()
+			if name != tc.want {
+				t.Errorf("KafkaSASLMechanism(%q) = %s, want %s", tc.mechanism, name, tc.want)
+			}
+		} else if tc.want != "" {
+			t.Errorf("KafkaSASLMechanism(%q) = nil, want %s", tc.mechanism, tc.want)
+		}
+		cfg := utils.DefaultDBConfig()
+		cfg.KafkaCfg.SASL = sasl
+		if rejects(cfg, "KafkaCfg.SASL.Mechanism") != tc.wantErr {
+			t.Errorf("ValidateDBConfig disagrees with KafkaSASLMechanism on %q", tc.mechanism)
+		}
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func TestKafkaConsumerRec(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Kafka)
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
 	// 初始化服务
 	KafkaConsumerService := dbservice.NewKafkaConsumer(cfg.KafkaAddr, cfg.KafkaTopic, "1")
@@ -22,7 +22,7 @@ func TestKafkaConsumerRec(t *testing.T) {
 }
 
 func TestKafkaProducerPro(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Kafka)
 	// 初始化服务
 	KafkaProducerService := dbservice.NewKafkaProducer(cfg.KafkaAddr, cfg.KafkaTopic)
 	t.Log(KafkaProducerService.SendMessage("go_test"))
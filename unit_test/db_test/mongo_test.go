@@ -10,7 +10,7 @@ import (
 )
 
 func TestMongoAddCollection(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Mongo)
 	// 初始化服务
 	mongoLink := fmt.Sprintf(
 		"mongodb://%s:%d",
@@ -27,7 +27,7 @@ func TestMongoAddCollection(t *testing.T) {
 }
 
 func TestMongoAddData(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Mongo)
 	// 初始化服务
 	mongoLink := fmt.Sprintf(
 		"mongodb://%s:%d",
@@ -46,7 +46,7 @@ func TestMongoAddData(t *testing.T) {
 }
 
 func TestMongoFindOneData(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Mongo)
 	// 初始化服务
 	mongoLink := fmt.Sprintf(
 		"mongodb://%s:%d",
@@ -67,7 +67,7 @@ func TestMongoFindOneData(t *testing.T) {
 }
 
 func TestMongoFindAllData(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Mongo)
 	// 初始化服务
 	mongoLink := fmt.Sprintf(
 		"mongodb://%s:%d",
@@ -88,7 +88,7 @@ func TestMongoFindAllData(t *testing.T) {
 }
 
 func TestMongoDropCollection(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Mongo)
 	// 初始化服务
 	mongoLink := fmt.Sprintf(
 		"mongodb://%s:%d",
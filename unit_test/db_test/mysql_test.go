@@ -9,7 +9,7 @@ import (
 )
 
 func TestMySqlAddTable(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.MySQL)
 	// 初始化服务
 	mysqlLink := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
@@ -27,7 +27,7 @@ func TestMySqlAddTable(t *testing.T) {
 }
 
 func TestMySqlDropTable(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.MySQL)
 	mysqlLink := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.MySQLCfg.Usr, cfg.MySQLCfg.Psw, cfg.MySQLCfg.Host, cfg.MySQLCfg.Port,
@@ -44,7 +44,7 @@ func TestMySqlDropTable(t *testing.T) {
 }
 
 func TestMySqlAddData(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.MySQL)
 	mysqlLink := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.MySQLCfg.Usr, cfg.MySQLCfg.Psw, cfg.MySQLCfg.Host, cfg.MySQLCfg.Port,
@@ -62,7 +62,7 @@ func TestMySqlAddData(t *testing.T) {
 }
 
 func TestMySqlQueryRow(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.MySQL)
 	mysqlLink := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.MySQLCfg.Usr, cfg.MySQLCfg.Psw, cfg.MySQLCfg.Host, cfg.MySQLCfg.Port,
@@ -80,7 +80,7 @@ func TestMySqlQueryRow(t *testing.T) {
 }
 
 func TestMySqlQueryRows(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.MySQL)
 	mysqlLink := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.MySQLCfg.Usr, cfg.MySQLCfg.Psw, cfg.MySQLCfg.Host, cfg.MySQLCfg.Port,
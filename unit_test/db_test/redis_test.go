@@ -8,13 +8,13 @@ import (
 )
 
 func TestRedisConnect(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	_ = dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 }
 
 func TestRedisSet(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	redisFun := dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 	err := redisFun.Set("test_go_str", "test_string")
@@ -61,7 +61,7 @@ func TestRedisSet(t *testing.T) {
 }
 
 func TestRedisGet(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	redisFun := dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 	re, err := redisFun.Get("test_go_str")
@@ -107,7 +107,7 @@ func TestRedisGet(t *testing.T) {
 }
 
 func TestRedisDel(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	redisFun := dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 	err := redisFun.Delete("test_go_str")
@@ -117,7 +117,7 @@ func TestRedisDel(t *testing.T) {
 }
 
 func TestRedisExist(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	redisFun := dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 	re, err := redisFun.Exists("test_go_str")
@@ -133,7 +133,7 @@ func TestRedisExist(t *testing.T) {
 }
 
 func TestRedisKeys(t *testing.T) {
-	cfg := DBconfig.NewConfig()
+	cfg := DBconfig.NewConfig(t, DBconfig.Redis)
 	// 初始化服务
 	redisFun := dbservice.NewRedisDict(cfg.RedisCfg.Host, cfg.RedisCfg.Port, cfg.RedisCfg.DBno)
 	re, err := redisFun.Keys()
@@ -0,0 +1,92 @@
+package event_catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/event_catalog_model"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/repository_service"
+)
+
+func TestCatalogValidatesEvents(t *testing.T) {
+	ctx := context.Background()
+	catalog := event_catalog_service.NewCatalog(repository_service.NewMemoryDatabase(), time.Hour)
+
+	// 内置类型：LOW_BATTERY 要求 Payload，TAKEOFF 不接受 Payload
+	event := data_flow_model.AircraftEvent{Event: "LOW_BATTERY", Severity: "info", Payload: json.RawMessage(`{"Battery":8}`)}
+	if err := catalog.Validate(ctx, &event); err != nil || event.Severity != event_catalog_model.SeverityWarning {
+		t.Fatalf("valid LOW_BATTERY: %v severity=%q", err, event.Severity)
+	}
+	for _, event := range []data_flow_model.AircraftEvent{
+		{Event: "LOW_BATTERY"},
+		{Event: "LOW_BATTERY", Payload: json.RawMessage(`{"Battery":-1}`)},
+		{Event: "TAKEOFF", Payload: json.RawMessage(`{"Runway":1}`)},
+	} {
+		if err := catalog.Validate(ctx, &event); !errors.Is(err, event_catalog_service.ErrInvalidPayload) {
+			t.Errorf("%s %s: expected ErrInvalidPayload, got %v", event.Event, event.Payload, err)
+		}
+	}
+	event = data_flow_model.AircraftEvent{Event: "TAKEOFF", Payload: json.RawMessage(`null`)}
+	if err := catalog.Validate(ctx, &event); err != nil || event.Payload != nil {
+		t.Errorf("null payload should be dropped: %v %s", err, event.Payload)
+	}
+	if err := catalog.Validate(ctx, &data_flow_model.AircraftEvent{Event: "takeoff"}); !errors.Is(err, event_catalog_service.ErrUnknownEventType) {
+		t.Errorf("codes are case sensitive, got %v", err)
+	}
+}
+
+func TestCatalogManagement(t *testing.T) {
+	ctx := context.Background()
+	db := repository_service.NewMemoryDatabase()
+	catalog := event_catalog_service.NewCatalog(db, time.Hour)
+	if err := catalog.Validate(ctx, &data_flow_model.AircraftEvent{Event: "GEOFENCE_BREACH"}); err == nil {
+		t.Fatal("unregistered event should be rejected")
+	}
+
+	for _, invalid := range []event_catalog_model.EventType{
+		{Code: "1BAD", Severity: "info"},
+		{Code: "GEOFENCE_BREACH", Severity: "fatal"},
+		{Code: "GEOFENCE_BREACH", Severity: "critical", PayloadSchema: json.RawMessage(`{"type":"object","oneOf":[]}`)},
+	} {
+		if err := catalog.Upsert(ctx, &invalid); !errors.Is(err, event_catalog_service.ErrInvalidEventType) {
+			t.Errorf("%+v: expected ErrInvalidEventType, got %v", invalid, err)
+		}
+	}
+	// 本实例的修改立即生效，不等待缓存过期
+	if err := catalog.Upsert(ctx, &event_catalog_model.EventType{Code: "GEOFENCE_BREACH", Severity: "critical"}); err != nil {
+		t.Fatal(err)
+	}
+	event := data_flow_model.AircraftEvent{Event: "GEOFENCE_BREACH"}
+	if err := catalog.Validate(ctx, &event); err != nil || event.Severity != "critical" {
+		t.Fatalf("registered event: %v severity=%q", err, event.Severity)
+	}
+
+	if err := catalog.Delete(ctx, "LINK_LOST"); !errors.Is(err, event_catalog_service.ErrSystemEventType) {
+		t.Errorf("LINK_LOST must not be deletable, got %v", err)
+	}
+	if err := catalog.Delete(ctx, "GEOFENCE_BREACH"); err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.Delete(ctx, "GEOFENCE_BREACH"); !errors.Is(err, repository_service.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := catalog.Validate(ctx, &data_flow_model.AircraftEvent{Event: "GEOFENCE_BREACH"}); err == nil {
+		t.Error("deleted event type should be rejected")
+	}
+
+	// 其他实例的修改在缓存过期后生效
+	cached := event_catalog_service.NewCatalog(db, 50*time.Millisecond)
+	_ = cached.Validate(ctx, &data_flow_model.AircraftEvent{Event: "TAKEOFF"})
+	_ = db.UpsertEventType(ctx, &event_catalog_model.EventType{Code: "HOVER", Severity: "info"})
+	if err := cached.Validate(ctx, &data_flow_model.AircraftEvent{Event: "HOVER"}); err == nil {
+		t.Error("catalog should serve cached types until refresh")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := cached.Validate(ctx, &data_flow_model.AircraftEvent{Event: "HOVER"}); err != nil {
+		t.Errorf("catalog should reload after refresh: %v", err)
+	}
+}
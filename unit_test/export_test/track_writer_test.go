@@ -0,0 +1,193 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/export_model"
+	"uam-power-backend/service/export_service"
+)
+
+// sourceOf 返回逐点遍历 points 的数据源
+func sourceOf(points []export_model.TrackPoint) export_service.PointSource {
+	return func(handle func(point export_model.TrackPoint) error) error {
+		for _, p := range points {
+			if err := handle(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func testTrack() (*aircraft_task_model.MysqlAircraftTask, []export_model.TrackPoint, []export_model.TrackEvent) {
+	start := time.Date(2024, 11, 16, 12, 0, 0, 0, time.UTC)
+	points := []export_model.TrackPoint{
+		{Longitude: 113.1, Latitude: 22.1, Altitude: 50, Yaw: 90, DataTime: start},
+		{Longitude: 113.2, Latitude: 22.2, Altitude: 60, Yaw: 95, DataTime: start.Add(time.Second)},
+	}
+	events := []export_model.TrackEvent{
+		{Event: "LOW_BATT <5%>", DataTime: start.Add(time.Second)},
+	}
+	task := &aircraft_task_model.MysqlAircraftTask{TaskID: 7, AircraftID: 3, LaneID: 1, CreateTime: start}
+	return task, points, events
+}
+
+func TestExportGeoJSON(t *testing.T) {
+	task, points, events := testTrack()
+	var buf bytes.Buffer
+	if err := export_service.WriteGeoJSON(&buf, task, sourceOf(points), events); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type string `json:"type"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("invalid GeoJSON: %s\n%s", err, buf.String())
+	}
+	// 一条 LineString + 两个轨迹点 + 一个事件点
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 4 {
+		t.Errorf("unexpected collection: %s", buf.String())
+	}
+	if collection.Features[0].Geometry.Type != "LineString" {
+		t.Errorf("first feature should be the track LineString")
+	}
+}
+
+func TestExportXMLFormats(t *testing.T) {
+	task, points, events := testTrack()
+	for _, format := range []string{export_service.FormatKML, export_service.FormatGPX} {
+		var buf bytes.Buffer
+		if err := export_service.WriteTrack(&buf, format, task, sourceOf(points), events, "relativeToGround"); err != nil {
+			t.Fatal(err)
+		}
+		decoder := xml.NewDecoder(&buf)
+		for {
+			_, err := decoder.Token()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("%s is not well-formed: %s", format, err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestKMLTrackElementCountsMatch(t *testing.T) {
+	task, points, events := testTrack()
+	var buf bytes.Buffer
+	if err := export_service.WriteKML(&buf, task, sourceOf(points), events, ""); err != nil {
+		t.Fatal(err)
+	}
+	kml := buf.String()
+	// gx:Track 的 when、gx:coord 与 gx:angles 须一一对应（事件的 TimeStamp 另含一个 when）
+	when, coord, angles := strings.Count(kml, "<when>"), strings.Count(kml, "<gx:coord>"), strings.Count(kml, "<gx:angles>")
+	if when != len(points)+len(events) || coord != len(points) || angles != len(points) {
+		t.Errorf("when/coord/angles = %d/%d/%d for %d points", when, coord, angles, len(points))
+	}
+}
+
+func TestKMLRejectsTrackChangedBetweenPasses(t *testing.T) {
+	task, points, events := testTrack()
+	passes := 0
+	growing := func(handle func(point export_model.TrackPoint) error) error {
+		passes++
+		if passes > 1 {
+			// 进行中的任务在第一次遍历之后又写入了一个点
+			return sourceOf(append(points, points[1]))(handle)
+		}
+		return sourceOf(points)(handle)
+	}
+	if err := export_service.WriteKML(io.Discard, task, growing, events, ""); err == nil {
+		t.Fatal("expected an error when the passes return different points")
+	}
+}
+
+func TestEventsAreLocatedWhileStreaming(t *testing.T) {
+	task, points, _ := testTrack()
+	start := points[0].DataTime
+	events := []export_model.TrackEvent{
+		{Event: "before", DataTime: start.Add(-time.Second)},
+		{Event: "between", DataTime: start.Add(500 * time.Millisecond)},
+		{Event: "at", DataTime: start.Add(time.Second)},
+		{Event: "after", DataTime: start.Add(time.Minute)},
+	}
+	var buf bytes.Buffer
+	if err := export_service.WriteGeoJSON(&buf, task, sourceOf(points), events); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Features []struct {
+			Geometry *struct {
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"before": 113.1, "between": 113.1, "at": 113.2, "after": 113.2}
+	located := 0
+	for _, feature := range collection.Features {
+		if feature.Properties["Kind"] != "event" {
+			continue
+		}
+		name := feature.Properties["Event"].(string)
+		var coordinates []float64
+		if feature.Geometry != nil {
+			_ = json.Unmarshal(feature.Geometry.Coordinates, &coordinates)
+		}
+		if len(coordinates) != 3 || coordinates[0] != want[name] {
+			t.Errorf("event %s located at %v", name, coordinates)
+		}
+		located++
+	}
+	if located != len(events) {
+		t.Errorf("expected %d events, got %d", len(events), located)
+	}
+	if events[0].Point != nil {
+		t.Error("the caller's events should not be modified")
+	}
+}
+
+func TestExportCZML(t *testing.T) {
+	task, points, events := testTrack()
+	track := export_model.SceneTrack{Task: *task, Points: points, Events: events}
+	start := track.Points[0].DataTime
+	var buf bytes.Buffer
+	if err := export_service.WriteCZML(&buf, start, start.Add(time.Minute), []export_model.SceneTrack{track}); err != nil {
+		t.Fatal(err)
+	}
+	var packets []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &packets); err != nil {
+		t.Fatalf("invalid CZML: %s", err)
+	}
+	// document + 轨迹 + 事件标签
+	if len(packets) != 3 || packets[0]["id"] != "document" {
+		t.Fatalf("unexpected packets: %s", buf.String())
+	}
+	samples := packets[1]["orientation"].(map[string]interface{})["unitQuaternion"].([]interface{})
+	for i := 0; i < len(samples); i += 5 {
+		norm := 0.0
+		for _, v := range samples[i+1 : i+5] {
+			norm += v.(float64) * v.(float64)
+		}
+		if math.Abs(norm-1) > 1e-9 {
+			t.Errorf("quaternion %d is not unit: %f", i/5, norm)
+		}
+	}
+}
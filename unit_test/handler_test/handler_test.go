@@ -0,0 +1,234 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"uam-power-backend/controller/aircraft_id_controller"
+	"uam-power-backend/controller/aircraft_task_controller"
+	"uam-power-backend/controller/data_controller"
+	"uam-power-backend/controller/event_catalog_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/repository_service"
+)
+
+const (
+	statusTopic = "aircraft_data"
+	eventTopic  = "aircraft_event"
+)
+
+// response 接口统一的返回格式
+type response struct {
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func post(t *testing.T, r *gin.Engine, path string, body interface{}) (int, response) {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload)))
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: invalid response %q", path, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+// waitFor 轮询直到 cond 成立，转发服务异步消费总线消息
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAircraftLifecycleWithMemoryStores(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := repository_service.NewMemoryDatabase()
+	aircraftCache, taskCache := repository_service.NewMemoryStore(), repository_service.NewMemoryStore()
+	statusStore, eventStore := repository_service.NewMemoryStore(), repository_service.NewMemoryStore()
+	linkStore := repository_service.NewMemoryStore()
+	pipelineCfg := &db_config_model.PipelineConfigModel{
+		EventHistorySize: 10, EventGlobalHistorySize: 10, EventHistoryTTLSec: 60,
+	}
+	bus := bus_service.NewMemoryBus(100)
+
+	// 消费组只收到创建之后的消息，转发服务需先于上传创建
+	toRedis := data_transfer_service.NewKafkaToRedisFromStores(
+		bus.Consumer(statusTopic, "KafkaToRedis"), bus.Consumer(eventTopic, "KafkaToRedis"),
+		statusStore, eventStore, nil, data_transfer_service.NewEventHistory(eventStore, pipelineCfg), nil,
+		data_transfer_service.NewClockSkew(linkStore), 0,
+	)
+	toMysql := data_transfer_service.NewKafkaToMysqlFromStores(
+		bus.Consumer(statusTopic, "KafkaToMysql"), bus.Consumer(eventTopic, "KafkaToMysql"),
+		db, taskCache, nil,
+	)
+	toRedis.Start()
+	toMysql.Start()
+
+	idController := aircraft_id_controller.NewAircraftIdControllerFromStores(db, aircraftCache)
+	taskController := aircraft_task_controller.NewAircraftTaskModelFromStores(db, taskCache, nil)
+	catalog := event_catalog_service.NewCatalog(db, time.Minute)
+	catalogController := event_catalog_controller.NewEventCatalogControllerFromCatalog(catalog)
+	uploadController := data_controller.NewUploadAircraftControllerFromProducers(
+		bus.Producer(statusTopic), bus.Producer(eventTopic), catalog, nil, nil)
+	receiveController := data_controller.NewReceiveAircraftFromStores(statusStore, eventStore, linkStore, pipelineCfg)
+	r := gin.New()
+	r.POST("/aircraftID/create", idController.CreateUser)
+	r.POST("/aircraftID/info", idController.GetAircraftInfo)
+	r.POST("/aircraftTask/create", taskController.CreateTask)
+	r.POST("/aircraftTask/end", taskController.EndTask)
+	r.POST("/upload/aircraftData", uploadController.UploadData)
+	r.POST("/upload/aircraftEvent", uploadController.UploadEvent)
+	r.POST("/request/aircraftData", receiveController.RequestAircraftStatus)
+	r.POST("/request/aircraftEvent", receiveController.RequestAircraftEvent)
+	r.POST("/request/recentEvents", receiveController.RequestRecentEvents)
+	// 与上传接口相同，查询参数中的旧格式时间按 LegacyTimeZone 解释
+	zonedController := data_controller.NewReceiveAircraftFromStores(statusStore, eventStore, linkStore,
+		&db_config_model.PipelineConfigModel{EventHistorySize: 10, EventGlobalHistorySize: 10, LegacyTimeZone: "Asia/Shanghai"})
+	r.POST("/zoned/recentEvents", zonedController.RequestRecentEvents)
+	r.POST("/eventCatalog/upsert", catalogController.UpsertEventType)
+
+	code, resp := post(t, r, "/aircraftID/create", map[string]string{"Company": "uam", "Name": "a1", "Type": "quad"})
+	if code != 200 {
+		t.Fatalf("create aircraft: %d %s", code, resp.Msg)
+	}
+	var aircraft struct{ AircraftID int }
+	_ = json.Unmarshal(resp.Data, &aircraft)
+	if code, _ := post(t, r, "/aircraftID/info", map[string]int{"AircraftID": aircraft.AircraftID}); code != 200 {
+		t.Fatalf("aircraft info: %d", code)
+	}
+	if code, _ := post(t, r, "/aircraftID/info", map[string]int{"AircraftID": aircraft.AircraftID + 1}); code != 404 {
+		t.Fatalf("unknown aircraft info: expected 404, got %d", code)
+	}
+	if code, _ := post(t, r, "/aircraftTask/create", map[string]int{"AircraftID": aircraft.AircraftID + 1}); code != 403 {
+		t.Fatalf("task for unknown aircraft: expected 403, got %d", code)
+	}
+
+	code, resp = post(t, r, "/aircraftTask/create", map[string]int{"AircraftID": aircraft.AircraftID, "LaneID": 3})
+	if code != 200 {
+		t.Fatalf("create task: %d %s", code, resp.Msg)
+	}
+	var task aircraft_task_model.MysqlAircraftTask
+	_ = json.Unmarshal(resp.Data, &task)
+
+	for _, status := range []map[string]interface{}{
+		{"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:01.000000", "Latitude": 30.1},
+		{"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:02.000000", "Latitude": 30.2},
+	} {
+		if code, resp := post(t, r, "/upload/aircraftData", status); code != 200 {
+			t.Fatalf("upload status: %d %s", code, resp.Msg)
+		}
+	}
+	// 上传的事件须先登记到事件目录
+	if code, _ := post(t, r, "/upload/aircraftEvent", map[string]interface{}{
+		"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:01.000000", "Event": "takeoff",
+	}); code != 400 {
+		t.Fatalf("unregistered event: expected 400, got %d", code)
+	}
+	for _, eventType := range []map[string]interface{}{
+		{"Code": "takeoff", "Severity": "info"},
+		{"Code": "battery_low", "Severity": "warning", "PayloadSchema": map[string]interface{}{
+			"type": "object", "required": []string{"Battery"},
+			"properties": map[string]interface{}{"Battery": map[string]interface{}{"type": "number", "maximum": 100}},
+		}},
+	} {
+		if code, resp := post(t, r, "/eventCatalog/upsert", eventType); code != 200 {
+			t.Fatalf("upsert event type: %d %s", code, resp.Msg)
+		}
+	}
+	if code, _ := post(t, r, "/upload/aircraftEvent", map[string]interface{}{
+		"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:02.000000", "Event": "battery_low",
+		"Payload": map[string]interface{}{"Battery": 120},
+	}); code != 400 {
+		t.Fatalf("invalid payload: expected 400, got %d", code)
+	}
+	for _, event := range []map[string]interface{}{
+		{"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:01.000000", "Event": "takeoff"},
+		{"AircraftID": aircraft.AircraftID, "TimeString": "2024-05-01 10:00:02.000000", "Event": "battery_low",
+			"Payload": map[string]interface{}{"Battery": 12.5}},
+	} {
+		if code, resp := post(t, r, "/upload/aircraftEvent", event); code != 200 {
+			t.Fatalf("upload event: %d %s", code, resp.Msg)
+		}
+	}
+
+	waitFor(t, "telemetry in database", func() bool {
+		return len(db.Statuses(&task)) == 2 && len(db.Events(&task)) == 2
+	})
+	waitFor(t, "recent events", func() bool {
+		records, _ := eventStore.RangeHistory("history:all", 0, 10)
+		return len(records) == 2
+	})
+	toRedis.Stop()
+	toMysql.Stop()
+
+	code, resp = post(t, r, "/request/aircraftData", map[string]int{"AircraftID": aircraft.AircraftID})
+	var latest struct{ Latitude float64 }
+	_ = json.Unmarshal(resp.Data, &latest)
+	if code != 200 || latest.Latitude != 30.2 {
+		t.Fatalf("latest status: %d %s", code, resp.Data)
+	}
+	code, resp = post(t, r, "/request/aircraftEvent", map[string]int{"AircraftID": aircraft.AircraftID})
+	var event struct{ Event string }
+	_ = json.Unmarshal(resp.Data, &event)
+	if code != 200 || event.Event != "battery_low" {
+		t.Fatalf("latest event: %d %s", code, resp.Data)
+	}
+	code, resp = post(t, r, "/request/recentEvents", map[string]int{"Count": 10})
+	var recent []struct{ Event string }
+	_ = json.Unmarshal(resp.Data, &recent)
+	if code != 200 || len(recent) != 2 || recent[0].Event != "battery_low" || recent[1].Event != "takeoff" {
+		t.Fatalf("recent events: %d %s", code, resp.Data)
+	}
+	code, resp = post(t, r, "/request/recentEvents", map[string]string{"Severity": "warning"})
+	var warnings []data_flow_model.AircraftEvent
+	_ = json.Unmarshal(resp.Data, &warnings)
+	if code != 200 || len(warnings) != 1 || warnings[0].Event != "battery_low" || string(warnings[0].Payload) != `{"Battery":12.5}` {
+		t.Fatalf("warning events: %d %s", code, resp.Data)
+	}
+	for path, since := range map[string]string{
+		"/request/recentEvents": "2024-05-01 10:00:02.000000", "/zoned/recentEvents": "2024-05-01 18:00:02.000000",
+	} {
+		code, resp = post(t, r, path, map[string]string{"Since": since})
+		_ = json.Unmarshal(resp.Data, &recent)
+		if code != 200 || len(recent) != 1 || recent[0].Event != "battery_low" {
+			t.Fatalf("events since %s on %s: %d %s", since, path, code, resp.Data)
+		}
+	}
+	if code, _ := post(t, r, "/request/recentEvents", map[string]string{"Since": "May 1"}); code != 400 {
+		t.Fatalf("invalid Since: expected 400, got %d", code)
+	}
+	if stored := db.Events(&task); stored[1].Severity != "warning" || stored[0].Severity != "info" {
+		t.Fatalf("severity not stored with events: %+v", stored)
+	}
+	if code, _ := post(t, r, "/request/aircraftData", map[string]int{"AircraftID": aircraft.AircraftID + 1}); code != 404 {
+		t.Fatalf("unknown aircraft status: expected 404, got %d", code)
+	}
+
+	if code, resp := post(t, r, "/aircraftTask/end", map[string]int{"AircraftID": aircraft.AircraftID}); code != 200 {
+		t.Fatalf("end task: %d %s", code, resp.Msg)
+	}
+	if code, _ := post(t, r, "/aircraftTask/end", map[string]int{"AircraftID": aircraft.AircraftID}); code != 404 {
+		t.Fatalf("end task twice: expected 404, got %d", code)
+	}
+	ended, err := db.GetTask(context.Background(), task.TaskID)
+	if err != nil || ended.EndTime == nil {
+		t.Fatalf("task not ended: %+v %v", ended, err)
+	}
+}
@@ -0,0 +1,76 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"testing"
+	"time"
+	"uam-power-backend/controller/data_controller"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+func TestUploadNormalizesTimestampsToUTC(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := bus_service.NewMemoryBus(10)
+	statusConsumer, eventConsumer := bus.Consumer(statusTopic, "test"), bus.Consumer(eventTopic, "test")
+	catalog := event_catalog_service.NewCatalog(repository_service.NewMemoryDatabase(), time.Minute)
+	legacyZone := time.FixedZone("CST", 8*3600)
+	uploadController := data_controller.NewUploadAircraftControllerFromProducers(
+		bus.Producer(statusTopic), bus.Producer(eventTopic), catalog, nil, legacyZone)
+	r := gin.New()
+	r.POST("/upload/aircraftData", uploadController.UploadData)
+	r.POST("/upload/aircraftEvent", uploadController.UploadEvent)
+	fetch := func(consumer bus_service.Consumer) string {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		msg, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = consumer.CommitMessage(ctx, msg)
+		return msg.Value
+	}
+
+	for _, timeString := range []interface{}{
+		"2024-05-01T10:00:00.5+08:00", int64(1714528800500), "1714528800500", "2024-05-01 10:00:00.500000",
+	} {
+		before := time.Now()
+		if code, resp := post(t, r, "/upload/aircraftData", map[string]interface{}{
+			"AircraftID": 1, "TimeString": timeString, "ReceiveTime": "2000-01-01 00:00:00.000000",
+		}); code != 200 {
+			t.Fatalf("%v: expected 200, got %d %s", timeString, code, resp.Msg)
+		}
+		var status data_flow_model.AircraftStatus
+		_ = json.Unmarshal([]byte(fetch(statusConsumer)), &status)
+		if status.TimeString != "2024-05-01 02:00:00.500000" {
+			t.Errorf("%v: normalized to %q", timeString, status.TimeString)
+		}
+		// 接收时间由服务端填写，覆盖上传的值
+		receiveTime, err := utils.ParseSqlTimeStr(status.ReceiveTime)
+		if err != nil || receiveTime.Before(before.Add(-time.Millisecond)) || receiveTime.After(time.Now()) {
+			t.Errorf("%v: unexpected ReceiveTime %q", timeString, status.ReceiveTime)
+		}
+	}
+
+	if code, _ := post(t, r, "/upload/aircraftEvent", map[string]interface{}{
+		"AircraftID": 1, "TimeString": "2024-05-01T02:00:01Z", "Event": "LINK_LOST",
+	}); code != 200 {
+		t.Fatalf("event: expected 200, got %d", code)
+	}
+	var event data_flow_model.AircraftEvent
+	_ = json.Unmarshal([]byte(fetch(eventConsumer)), &event)
+	if event.TimeString != "2024-05-01 02:00:01.000000" || event.ReceiveTime == "" {
+		t.Errorf("event not normalized: %+v", event)
+	}
+
+	for _, timeString := range []interface{}{"2024-05-01 10:00:00", 1.5, "May 1", true} {
+		if code, _ := post(t, r, "/upload/aircraftData", map[string]interface{}{"AircraftID": 1, "TimeString": timeString}); code != 403 && code != 400 {
+			t.Errorf("%v: expected 400 or 403, got %d", timeString, code)
+		}
+	}
+}
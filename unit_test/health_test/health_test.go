@@ -0,0 +1,65 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"uam-power-backend/controller/health_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/health_service"
+)
+
+func request(r *gin.Engine, path string) (int, health_service.Report) {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report health_service.Report
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec.Code, report
+}
+
+func TestLiveAndReady(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller := health_controller.NewHealthController(&db_config_model.BusConfigModel{}, &db_config_model.KafkaConfigModel{Addr: "127.0.0.1:1"})
+	controller.Timeout = 500 * time.Millisecond
+	r := gin.New()
+	r.GET("/health/live", controller.Live)
+	r.GET("/health/ready", controller.Ready)
+
+	heartbeat := health_service.NewHeartbeat()
+	health_service.RegisterLiveness("transfer:test", heartbeat.Check(time.Minute))
+	health_service.RegisterReadiness("mysql:test", func(ctx context.Context) error { return nil })
+	health_service.RegisterReadiness("redis:test", func(ctx context.Context) error { return errors.New("connection refused") })
+	health_service.RegisterReadiness("redis:hang", func(ctx context.Context) error { select {} })
+
+	code, report := request(r, "/health/live")
+	if code != 200 || report.Status != health_service.StatusUp || len(report.Components) != 1 {
+		t.Fatalf("live: unexpected %d %+v", code, report)
+	}
+
+	code, report = request(r, "/health/ready")
+	if code != 503 || report.Status != health_service.StatusDown {
+		t.Fatalf("ready: unexpected %d %+v", code, report)
+	}
+	expected := map[string]string{
+		"transfer:test": health_service.StatusUp,
+		"mysql:test":    health_service.StatusUp,
+		"redis:test":    health_service.StatusDown,
+		"redis:hang":    health_service.StatusDown,
+		"kafka:brokers": health_service.StatusDown,
+	}
+	for name, status := range expected {
+		if report.Components[name].Status != status {
+			t.Errorf("%s: expected %s, got %+v", name, status, report.Components[name])
+		}
+	}
+
+	health_service.RegisterLiveness("transfer:test", heartbeat.Check(0))
+	if code, _ = request(r, "/health/live"); code != 503 {
+		t.Errorf("live with stale heartbeat: expected 503, got %d", code)
+	}
+}
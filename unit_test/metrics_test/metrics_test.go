@@ -0,0 +1,50 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"uam-power-backend/middleware"
+	"uam-power-backend/service/metrics_service"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/ping/:id", func(c *gin.Context) { c.Status(204) })
+	r.GET("/metrics", gin.WrapH(metrics_service.Handler()))
+
+	// 未实际连接的连接池也能上报统计
+	db, err := sql.Open("mysql", "user:psw@tcp(127.0.0.1:1)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	metrics_service.RegisterMySQLPool("test/flightdb", db)
+	metrics_service.RegisterKafkaConsumer("AircraftData", "test", func() int64 { return 42 })
+	metrics_service.RegisterActiveTasks(func() (int, error) { return 3, nil })
+	metrics_service.ObserveRedisLookup("test", nil, nil)
+	metrics_service.UploadTotal.WithLabelValues("status", "rejected", "invalid_time").Inc()
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping/1", nil))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`uam_http_request_duration_seconds_count{method="GET",route="/ping/:id",status="204"} 1`,
+		`uam_mysql_pool_max_open_connections{pool="test/flightdb"} 0`,
+		`uam_kafka_consumer_lag{group="test",topic="AircraftData"} 42`,
+		`uam_active_tasks 3`,
+		`uam_redis_lookups_total{component="test",result="miss"} 1`,
+		`uam_upload_total{kind="status",reason="invalid_time",result="rejected"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package migration_test
+
+import (
+	"strings"
+	"testing"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/migration_service"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	cfg := &db_config_model.MySqlConfigModel{DB: "sys_x", FlightDB: "flight_x", EventDB: "event_x"}
+	migrations, err := migration_service.LoadMigrations(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("unexpected migrations: %+v", migrations)
+	}
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migrations not sorted at %d", migration.Version)
+		}
+		if len(migration.Down) == 0 {
+			t.Errorf("migration %d has no down script", migration.Version)
+		}
+		for _, statement := range append(migration.Up, migration.Down...) {
+			if strings.Contains(statement, "{{") {
+				t.Errorf("unrendered template in %d: %s", migration.Version, statement)
+			}
+			if !strings.HasSuffix(statement, ";") {
+				t.Errorf("statement not terminated in %d: %s", migration.Version, statement)
+			}
+		}
+	}
+	joined := strings.Join(migrations[0].Up, "\n")
+	for _, want := range []string{"`sys_x`.aircraft_identity_table", "`sys_x`.flight_task_table", "`flight_x`", "`event_x`"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("initial migration should reference %s", want)
+		}
+	}
+	// 基线迁移与原有表结构一致，不增加约束
+	if strings.Contains(joined, "FOREIGN KEY") {
+		t.Error("initial migration should not add foreign keys")
+	}
+}
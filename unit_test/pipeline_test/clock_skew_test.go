@@ -0,0 +1,38 @@
+package pipeline_test
+
+import (
+	"testing"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+func TestClockSkewRecordedInLinkQuality(t *testing.T) {
+	for name, store := range map[string]interface {
+		repository_service.SkewStore
+		repository_service.HashStore
+	}{"redis": newDedupRedis(t), "memory": repository_service.NewMemoryStore()} {
+		skew := data_transfer_service.NewClockSkew(store)
+		logger := utils.ComponentLogger("ClockSkewTest")
+		for _, item := range []struct{ point, receive string }{
+			{"2024-05-01 10:00:00.000000", "2024-05-01 10:00:01.500000"},
+			{"2024-05-01 10:00:05.000000", "2024-05-01 10:00:04.800000"},
+			{"2024-05-01 10:00:06.000000", "2024-05-01 10:00:06.300000"},
+		} {
+			skew.Record(logger, &data_flow_model.AircraftStatus{AircraftID: 7, TimeString: item.point, ReceiveTime: item.receive})
+		}
+		// 升级前上传、未携带接收时间的消息不计入
+		skew.Record(logger, &data_flow_model.AircraftStatus{AircraftID: 7, TimeString: "2024-05-01 10:00:07.000000"})
+
+		fields, err := store.GetHash(data_flow_model.LinkStatsKey(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		quality := data_flow_model.NewLinkQuality(7, fields)
+		if quality.ClockSkewSamples != 3 || quality.ClockSkewMs != 300 || quality.MinClockSkewMs != -200 ||
+			quality.MaxClockSkewMs != 1500 || quality.AvgClockSkewMs != 1600.0/3 {
+			t.Errorf("%s: unexpected clock skew %+v", name, quality)
+		}
+	}
+}
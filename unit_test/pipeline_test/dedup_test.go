@@ -0,0 +1,101 @@
+package pipeline_test
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"strconv"
+	"testing"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/db_service"
+)
+
+func newRedisConfig(t *testing.T) *db_config_model.RedisConfigModel {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	return &db_config_model.RedisConfigModel{Host: server.Host(), Port: port, DedupPrefix: "dedup:"}
+}
+
+// newDedupRedis 返回去重窗口所在的带 DedupPrefix 前缀的 Redis
+func newDedupRedis(t *testing.T) *dbservice.RedisDict {
+	redisCfg := newRedisConfig(t)
+	rdb, err := dbservice.NewRedisDictWithConfig(redisCfg, redisCfg.DedupPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rdb
+}
+
+func seq(n int64) *int64 {
+	return &n
+}
+
+func TestDedupBySequenceAndLinkStats(t *testing.T) {
+	redisDedup := newDedupRedis(t)
+	pipelineCfg := &db_config_model.PipelineConfigModel{DedupWindowSec: 60, DedupMaxEntries: 100}
+	dedup := data_transfer_service.NewDeduplicator("KafkaToRedis", redisDedup, pipelineCfg, true)
+	ctx := context.Background()
+	for _, item := range []struct {
+		seq       int64
+		duplicate bool
+	}{
+		{1, false}, {2, false}, {2, true}, {5, false}, {1, true}, {4, false},
+	} {
+		status := data_flow_model.AircraftStatus{AircraftID: 7, Seq: seq(item.seq)}
+		duplicate, err := dedup.Seen(ctx, "status", status.AircraftID, status.DedupKey(), status.Seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if duplicate != item.duplicate {
+			t.Errorf("seq %d: expected duplicate=%v", item.seq, item.duplicate)
+		}
+	}
+
+	// 另一个转发服务使用独立窗口，同一消息仍需处理
+	other := data_transfer_service.NewDeduplicator("KafkaToMysql", redisDedup, pipelineCfg, false)
+	if duplicate, _ := other.Seen(ctx, "status", 7, "seq:1", seq(1)); duplicate {
+		t.Error("windows of different services must be independent")
+	}
+
+	fields, err := redisDedup.GetHash(data_flow_model.LinkStatsKey(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quality := data_flow_model.NewLinkQuality(7, fields)
+	// 收到 1、2、5、4，5 到达时检测到 3、4 缺失，4 乱序晚到后扣回，重复 2 条
+	if quality.Received != 4 || quality.Missing != 1 || quality.Duplicates != 2 {
+		t.Errorf("unexpected link quality %+v", quality)
+	}
+}
+
+func TestDedupWindowIsBounded(t *testing.T) {
+	pipelineCfg := &db_config_model.PipelineConfigModel{DedupWindowSec: 60, DedupMaxEntries: 2}
+	dedup := data_transfer_service.NewDeduplicator("KafkaToMysql", newDedupRedis(t), pipelineCfg, false)
+	ctx := context.Background()
+	for _, timeStr := range []string{"2024-01-01 00:00:01", "2024-01-01 00:00:02", "2024-01-01 00:00:03"} {
+		status := data_flow_model.AircraftStatus{AircraftID: 3, TimeString: timeStr}
+		if duplicate, err := dedup.Seen(ctx, "status", 3, status.DedupKey(), nil); err != nil || duplicate {
+			t.Fatalf("%s: unexpected duplicate=%v err=%v", timeStr, duplicate, err)
+		}
+	}
+	// 最早的一条已被挤出窗口
+	oldest := data_flow_model.AircraftStatus{AircraftID: 3, TimeString: "2024-01-01 00:00:01"}
+	if duplicate, _ := dedup.Seen(ctx, "status", 3, oldest.DedupKey(), nil); duplicate {
+		t.Error("entry beyond DedupMaxEntries should have been evicted")
+	}
+	latest := data_flow_model.AircraftStatus{AircraftID: 3, TimeString: "2024-01-01 00:00:03"}
+	if duplicate, _ := dedup.Seen(ctx, "status", 3, latest.DedupKey(), nil); !duplicate {
+		t.Error("recent entry should still be in the window")
+	}
+}
+
+func TestDedupDisabled(t *testing.T) {
+	dedup := data_transfer_service.NewDeduplicator("KafkaToMysql", nil, &db_config_model.PipelineConfigModel{}, false)
+	if dedup != nil {
+		t.Fatalf("expected nil deduplicator, got %v", dedup)
+	}
+	if duplicate, err := dedup.Seen(context.Background(), "status", 1, "seq:1", seq(1)); duplicate || err != nil {
+		t.Error("nil deduplicator must not drop messages")
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+func TestEventHistoryKeepsRecentEventsInOrder(t *testing.T) {
+	redisCfg := newRedisConfig(t)
+	eventRedis, err := dbservice.NewRedisDictWithConfig(redisCfg, "event:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := data_transfer_service.NewEventHistory(eventRedis, &db_config_model.PipelineConfigModel{
+		EventHistorySize: 2, EventGlobalHistorySize: 3, EventHistoryTTLSec: 60,
+	})
+	for _, event := range []data_flow_model.AircraftEvent{
+		{AircraftID: 1, TimeString: "2024-05-01 10:00:01.000000", Event: "takeoff"},
+		{AircraftID: 1, TimeString: "2024-05-01 10:00:03.000000", Event: "rth"},
+		// 乱序晚到的事件按事件时间排序
+		{AircraftID: 1, TimeString: "2024-05-01 10:00:02.000000", Event: "battery_low"},
+		{AircraftID: 2, TimeString: "2024-05-01 10:00:04.000000", Event: "takeoff"},
+	} {
+		value, _ := json.Marshal(event)
+		if err := history.Add(context.Background(), &event, string(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertEvents := func(key string, since float64, count int, want ...string) {
+		t.Helper()
+		records, err := eventRedis.RangeHistory(key, since, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, record := range records {
+			got = append(got, record.(map[string]interface{})["Event"].(string))
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", key, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", key, got, want)
+			}
+		}
+	}
+	assertEvents(data_flow_model.EventHistoryKey(1), math.Inf(-1), 10, "rth", "battery_low")
+	assertEvents(data_flow_model.GlobalEventHistoryKey, math.Inf(-1), 10, "takeoff", "rth", "battery_low")
+	assertEvents(data_flow_model.GlobalEventHistoryKey, math.Inf(-1), 1, "takeoff")
+	since, _ := utils.ParseSqlTimeStr("2024-05-01 10:00:03.000000")
+	assertEvents(data_flow_model.GlobalEventHistoryKey, float64(since.UnixMilli()), 10, "takeoff", "rth")
+}
+
+func TestEventHistoryDisabled(t *testing.T) {
+	if history := data_transfer_service.NewEventHistory(nil, &db_config_model.PipelineConfigModel{}); history != nil {
+		t.Fatal("expected nil history when EventHistorySize is 0")
+	}
+	var history *data_transfer_service.EventHistory
+	if err := history.Add(context.Background(), &data_flow_model.AircraftEvent{}, "{}"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,81 @@
+package pipeline_test
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"strconv"
+	"testing"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+func TestSetIfNewerIgnoresOlderPoints(t *testing.T) {
+	redisCfg := newRedisConfig(t)
+	statusRedis, err := dbservice.NewRedisDictWithConfig(redisCfg, "status:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []struct {
+		time   string
+		stored bool
+	}{
+		{"2024-05-01 10:00:02.000000", true},
+		{"2024-05-01 10:00:01.500000", false},
+		{"2024-05-01 10:00:02.000000", true},
+		{"2024-05-01 10:00:03.250000", true},
+	} {
+		value := `{"AircraftID":1,"TimeString":"` + item.time + `"}`
+		stored, err := statusRedis.SetIfNewer("1", value, at(t, item.time), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != item.stored {
+			t.Errorf("%s: expected stored=%v", item.time, item.stored)
+		}
+	}
+	latest, _ := statusRedis.Get("1")
+	if latest.(map[string]interface{})["TimeString"] != "2024-05-01 10:00:03.250000" {
+		t.Errorf("unexpected latest value %v", latest)
+	}
+
+	// 升级前写入的值（本地时间，没有已存时间）直接覆盖，之后按 UTC 时间比较
+	_ = statusRedis.Set("2", map[string]string{"TimeString": "2024-05-01 18:00:00.000000"})
+	if stored, err := statusRedis.SetIfNewer("2", `{"TimeString":"2024-05-01 10:00:01.000000"}`, at(t, "2024-05-01 10:00:01.000000"), 0); err != nil || !stored {
+		t.Errorf("expected legacy value to be replaced, stored=%v err=%v", stored, err)
+	}
+	if stored, _ := statusRedis.SetIfNewer("2", `{"TimeString":"2024-05-01 10:00:00.000000"}`, at(t, "2024-05-01 10:00:00.000000"), 0); stored {
+		t.Error("older point stored after the legacy value was replaced")
+	}
+}
+
+// at 按 UTC 解析 SqlTimeLayout 格式的时间
+func at(t *testing.T, timeStr string) time.Time {
+	t.Helper()
+	parsed, err := utils.ParseSqlTimeStr(timeStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestSetIfNewerExpiresStaleStatus(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	statusRedis, err := dbservice.NewRedisDictWithConfig(&db_config_model.RedisConfigModel{Host: server.Host(), Port: port}, "status:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := `{"AircraftID":1,"TimeString":"2024-05-01 10:00:00.000000"}`
+	if _, err = statusRedis.SetIfNewer("1", value, at(t, "2024-05-01 10:00:00.000000"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(30 * time.Second)
+	if latest, _ := statusRedis.Get("1"); latest == nil {
+		t.Fatal("status expired before its TTL")
+	}
+	server.FastForward(31 * time.Second)
+	if latest, _ := statusRedis.Get("1"); latest != nil {
+		t.Errorf("status should expire without new telemetry, got %v", latest)
+	}
+}
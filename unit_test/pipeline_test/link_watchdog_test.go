@@ -0,0 +1,158 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"uam-power-backend/controller/data_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/utils"
+)
+
+// linkEvents 取出事件 topic 中已发出的全部链路事件，格式为 "AircraftID:Event"
+func linkEvents(t *testing.T, consumer bus_service.Consumer) []string {
+	t.Helper()
+	var events []string
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		msg, err := consumer.FetchMessage(ctx)
+		cancel()
+		if err != nil {
+			return events
+		}
+		var event data_flow_model.AircraftEvent
+		if err = json.Unmarshal([]byte(msg.Value), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.TimeString == "" || msg.Key != strconv.Itoa(event.AircraftID) {
+			t.Fatalf("unexpected link event message %q %+v", msg.Key, event)
+		}
+		events = append(events, msg.Key+":"+event.Event)
+		_ = consumer.CommitMessage(context.Background(), msg)
+	}
+}
+
+func TestLinkWatchdogRaisesLostAndRestored(t *testing.T) {
+	redisLinks, err := dbservice.NewRedisDictWithConfig(newRedisConfig(t), "dedup:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, links := range map[string]repository_service.LinkStore{
+		"memory": repository_service.NewMemoryStore(),
+		"redis":  redisLinks,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tasks := repository_service.NewMemoryStore()
+			_ = tasks.Set("7", map[string]int{"TaskID": 1})
+			_ = tasks.Set("8", map[string]int{"TaskID": 2})
+			bus := bus_service.NewMemoryBus(10)
+			consumer := bus.Consumer("aircraft_event", "test")
+			watchdog := data_transfer_service.NewLinkWatchdog(tasks, links, bus.Producer("aircraft_event"),
+				&db_config_model.PipelineConfigModel{LinkTimeoutSec: 1, LinkCheckIntervalSec: 1})
+			watchdog.Timeout = 100 * time.Millisecond
+			ctx, logger := context.Background(), utils.ComponentLogger("test")
+
+			watchdog.Received(ctx, logger, 7)
+			// 8 尚未上报，从首次检查开始计时
+			watchdog.Check(ctx, logger)
+			if events := linkEvents(t, consumer); len(events) != 0 {
+				t.Fatalf("no link should be lost yet, got %v", events)
+			}
+
+			time.Sleep(150 * time.Millisecond)
+			watchdog.Received(ctx, logger, 8)
+			watchdog.Check(ctx, logger)
+			watchdog.Check(ctx, logger)
+			if events := linkEvents(t, consumer); len(events) != 1 || events[0] != "7:LINK_LOST" {
+				t.Fatalf("expected a single LINK_LOST for 7, got %v", events)
+			}
+
+			watchdog.Received(ctx, logger, 7)
+			watchdog.Received(ctx, logger, 7)
+			if events := linkEvents(t, consumer); len(events) != 1 || events[0] != "7:LINK_RESTORED" {
+				t.Fatalf("expected a single LINK_RESTORED for 7, got %v", events)
+			}
+
+			// 任务结束后不再监测，记录被清除
+			_ = tasks.Delete("8")
+			watchdog.Check(ctx, logger)
+			if _, ok, _ := links.LinkSeenAt(data_flow_model.LinkSeenKey, "8"); ok {
+				t.Error("link of an ended task should be pruned")
+			}
+		})
+	}
+}
+
+func TestLinkWatchdogDisabled(t *testing.T) {
+	watchdog := data_transfer_service.NewLinkWatchdog(nil, nil, nil, &db_config_model.PipelineConfigModel{})
+	if watchdog != nil {
+		t.Fatalf("expected nil watchdog, got %v", watchdog)
+	}
+	// nil 监测器不记录也不发出事件
+	watchdog.Received(context.Background(), utils.ComponentLogger("test"), 1)
+}
+
+func TestLatestStatusCarriesAge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	status, links := repository_service.NewMemoryStore(), repository_service.NewMemoryStore()
+	receiver := data_controller.NewReceiveAircraftFromStores(status, repository_service.NewMemoryStore(), links,
+		&db_config_model.PipelineConfigModel{LinkTimeoutSec: 15})
+	r := gin.New()
+	r.POST("/request/aircraftData", receiver.RequestAircraftStatus)
+	request := func(AircraftID string) (ageMs int64, stale bool) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/request/aircraftData",
+			strings.NewReader(`{"AircraftID":`+AircraftID+`}`)))
+		var resp struct {
+			AgeMs int64 `json:"ageMs"`
+			Stale bool  `json:"stale"`
+		}
+		if rec.Code != 200 || json.Unmarshal(rec.Body.Bytes(), &resp) != nil {
+			t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+		}
+		return resp.AgeMs, resp.Stale
+	}
+
+	// 有接收记录时按服务端接收时间计算
+	_, _ = status.SetIfNewer("1", `{"AircraftID":1,"TimeString":"2024-05-01 10:00:00.000000"}`, time.Time{}, 0)
+	_, _ = links.TouchLink(data_flow_model.LinkSeenKey, data_flow_model.LinkLostKey, "1", time.Now().Add(-time.Second))
+	if ageMs, stale := request("1"); ageMs < 1000 || ageMs > 5000 || stale {
+		t.Errorf("fresh status: ageMs=%d stale=%v", ageMs, stale)
+	}
+	_, _ = links.TouchLink(data_flow_model.LinkSeenKey, data_flow_model.LinkLostKey, "1", time.Now().Add(-time.Minute))
+	if _, stale := request("1"); !stale {
+		t.Error("status not received within LinkTimeoutSec should be stale")
+	}
+
+	// 无接收记录时按状态自带的 TimeString 计算
+	statusTime := time.Now().Add(-20 * time.Second)
+	timeStr := utils.FormatSqlTime(statusTime)
+	_, _ = status.SetIfNewer("2", `{"AircraftID":2,"TimeString":"`+timeStr+`"}`, statusTime, 0)
+	if ageMs, stale := request("2"); ageMs < 20000 || !stale {
+		t.Errorf("status by TimeString: ageMs=%d stale=%v", ageMs, stale)
+	}
+}
+
+func TestLinkWatchdogStopsWithoutWaitingForInterval(t *testing.T) {
+	watchdog := data_transfer_service.NewLinkWatchdog(repository_service.NewMemoryStore(), repository_service.NewMemoryStore(),
+		bus_service.NewMemoryBus(10).Producer("aircraft_event"),
+		&db_config_model.PipelineConfigModel{LinkTimeoutSec: 60, LinkCheckIntervalSec: 60})
+	go watchdog.Run()
+	time.Sleep(50 * time.Millisecond)
+	stopped := time.Now()
+	watchdog.Stop()
+	if elapsed := time.Since(stopped); elapsed > time.Second {
+		t.Fatalf("Stop waited %s for the check interval", elapsed)
+	}
+}
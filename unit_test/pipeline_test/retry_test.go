@@ -0,0 +1,112 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/aircraft_task_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/repository_service"
+)
+
+// flakyTelemetry 前 failures 次写入返回错误的轨迹存储，模拟 MySQL 暂时不可用
+type flakyTelemetry struct {
+	mutex    sync.Mutex
+	failures int
+	statuses []data_flow_model.AircraftStatus
+}
+
+func (f *flakyTelemetry) InsertStatus(
+	_ context.Context, _ *aircraft_task_model.MysqlAircraftTask, status *data_flow_model.AircraftStatus,
+) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.failures != 0 {
+		f.failures--
+		return errors.New("mysql unavailable")
+	}
+	f.statuses = append(f.statuses, *status)
+	return nil
+}
+
+func (f *flakyTelemetry) InsertEvent(
+	context.Context, *aircraft_task_model.MysqlAircraftTask, *data_flow_model.AircraftEvent,
+) error {
+	return nil
+}
+
+func (f *flakyTelemetry) inserted() []data_flow_model.AircraftStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]data_flow_model.AircraftStatus(nil), f.statuses...)
+}
+
+// newToMysql 创建只消费状态 topic 的 KafkaToMysql，飞行器 7 有进行中的任务
+func newToMysql(t *testing.T, bus *bus_service.MemoryBus, telemetry *flakyTelemetry) (*data_transfer_service.KafkaToMysql, bus_service.Consumer) {
+	tasks := repository_service.NewMemoryStore()
+	if err := tasks.Set("7", &aircraft_task_model.MysqlAircraftTask{TaskID: 1, AircraftID: 7, TrackTable: "track_1"}); err != nil {
+		t.Fatal(err)
+	}
+	consumer := bus.Consumer("AircraftData", "KafkaToMysql")
+	dedup := data_transfer_service.NewDeduplicator("KafkaToMysql", newDedupRedis(t),
+		&db_config_model.PipelineConfigModel{DedupWindowSec: 60, DedupMaxEntries: 100}, false)
+	return data_transfer_service.NewKafkaToMysqlFromStores(consumer, bus.Consumer("AircraftEvent", "KafkaToMysql"),
+		telemetry, tasks, dedup), consumer
+}
+
+func TestTransientFailureIsRetriedBeforeCommit(t *testing.T) {
+	bus := bus_service.NewMemoryBus(10)
+	telemetry := &flakyTelemetry{failures: 2}
+	toMysql, consumer := newToMysql(t, bus, telemetry)
+	toMysql.Start()
+	producer := bus.Producer("AircraftData")
+	for _, value := range []string{
+		`{"AircraftID":7,"Seq":1,"TimeString":"2024-05-01 10:00:01.000000"}`,
+		// 无法解析的消息重试也不会成功，直接确认
+		`not json`,
+		`{"AircraftID":7,"Seq":2,"TimeString":"2024-05-01 10:00:02.000000"}`,
+	} {
+		if err := producer.SendKeyedMessage(context.Background(), "7", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for deadline := time.Now().Add(5 * time.Second); consumer.Lag() > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for messages to be committed")
+		}
+	}
+	toMysql.Stop()
+	// 重试的消息没有被去重窗口当作重复丢弃，且后续消息等待其成功
+	statuses := telemetry.inserted()
+	if len(statuses) != 2 || *statuses[0].Seq != 1 || *statuses[1].Seq != 2 {
+		t.Fatalf("expected seq 1 and 2 in order, got %+v", statuses)
+	}
+}
+
+func TestStopLeavesFailedMessageUnacknowledged(t *testing.T) {
+	bus := bus_service.NewMemoryBus(10)
+	toMysql, consumer := newToMysql(t, bus, &flakyTelemetry{failures: -1})
+	toMysql.Start()
+	if err := bus.Producer("AircraftData").SendKeyedMessage(context.Background(), "7",
+		`{"AircraftID":7,"Seq":1,"TimeString":"2024-05-01 10:00:01.000000"}`); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	toMysql.Stop()
+	if lag := consumer.Lag(); lag != 1 {
+		t.Fatalf("expected the failed message to stay unacknowledged, got lag %d", lag)
+	}
+	// 消费者关闭后消息放回队列，由同组消费者重新处理
+	_ = consumer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := bus.Consumer("AircraftData", "KafkaToMysql").FetchMessage(ctx)
+	if err != nil || msg.Key != "7" {
+		t.Fatalf("expected redelivery, got %+v %v", msg, err)
+	}
+}
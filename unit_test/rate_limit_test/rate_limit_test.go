@@ -0,0 +1,245 @@
+package rate_limit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"uam-power-backend/controller/rate_limit_controller"
+	"uam-power-backend/middleware"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/rate_limit_model"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/rate_limit_service"
+)
+
+// clock 测试中手动推进的时间
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newLimiter(t *testing.T, cfg *db_config_model.RateLimitConfigModel) (*rate_limit_service.Limiter, *clock) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	store, err := dbservice.NewRedisDictWithConfig(&db_config_model.RedisConfigModel{Host: server.Host(), Port: port}, "ratelimit:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Enable, cfg.UsageRetentionDays = true, 7
+	limiter := rate_limit_service.NewLimiter(store, cfg)
+	now := &clock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	limiter.Now = now.Now
+	return limiter, now
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	limiter, now := newLimiter(t, &db_config_model.RateLimitConfigModel{
+		Upload: db_config_model.RateLimitScopeModel{
+			Aircraft: db_config_model.RateLimitRule{Rate: 2, Burst: 3},
+			IP:       db_config_model.RateLimitRule{Rate: 100, Burst: 100},
+		},
+	})
+	subjects := func(aircraft string) []rate_limit_service.Subject {
+		return []rate_limit_service.Subject{{Dimension: "ip", Key: "10.0.0.1"}, {Dimension: "aircraft", Key: aircraft}}
+	}
+	for i := 0; i < 3; i++ {
+		if decision, err := limiter.Allow("upload", subjects("7")); err != nil || !decision.Allowed {
+			t.Fatalf("request %d within burst rejected: %+v %v", i, decision, err)
+		}
+	}
+	decision, _ := limiter.Allow("upload", subjects("7"))
+	if decision.Allowed || decision.Dimension != "aircraft" || decision.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected aircraft limit with 500ms retry, got %+v", decision)
+	}
+	// 其他飞行器不受影响
+	if decision, _ := limiter.Allow("upload", subjects("8")); !decision.Allowed {
+		t.Fatalf("another aircraft was limited: %+v", decision)
+	}
+	// 查询接口使用独立的规则（此处未配置，不限流）
+	if decision, _ := limiter.Allow("query", subjects("7")); !decision.Allowed {
+		t.Fatalf("query scope was limited: %+v", decision)
+	}
+	now.now = now.now.Add(500 * time.Millisecond)
+	if decision, _ := limiter.Allow("upload", subjects("7")); !decision.Allowed {
+		t.Fatalf("token not refilled after 500ms: %+v", decision)
+	}
+	if decision, _ := limiter.Allow("upload", subjects("7")); decision.Allowed {
+		t.Fatal("only one token should have been refilled")
+	}
+}
+
+func TestRejectionDoesNotSpendOtherDimensions(t *testing.T) {
+	limiter, _ := newLimiter(t, &db_config_model.RateLimitConfigModel{
+		Upload: db_config_model.RateLimitScopeModel{
+			Aircraft: db_config_model.RateLimitRule{Rate: 1, Burst: 2},
+			IP:       db_config_model.RateLimitRule{Rate: 1, Burst: 1},
+		},
+	})
+	_, _ = limiter.Allow("upload", []rate_limit_service.Subject{{Dimension: "ip", Key: "a"}, {Dimension: "aircraft", Key: "1"}})
+	decision, _ := limiter.Allow("upload", []rate_limit_service.Subject{{Dimension: "ip", Key: "a"}, {Dimension: "aircraft", Key: "1"}})
+	if decision.Allowed || decision.Dimension != "ip" {
+		t.Fatalf("expected ip limit, got %+v", decision)
+	}
+	// 被 IP 拒绝的请求没有消耗飞行器的令牌
+	if decision, _ := limiter.Allow("upload", []rate_limit_service.Subject{{Dimension: "ip", Key: "b"}, {Dimension: "aircraft", Key: "1"}}); !decision.Allowed {
+		t.Fatalf("aircraft token was spent by a rejected request: %+v", decision)
+	}
+}
+
+func TestRequestKeysShareClientHashTag(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	store, err := dbservice.NewRedisDictWithConfig(&db_config_model.RedisConfigModel{Host: server.Host(), Port: port}, "ratelimit:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := rate_limit_service.NewLimiter(store, &db_config_model.RateLimitConfigModel{
+		Enable: true, UsageRetentionDays: 7,
+		Upload: db_config_model.RateLimitScopeModel{
+			Aircraft:   db_config_model.RateLimitRule{Rate: 1, Burst: 1, DailyQuota: 10},
+			Credential: db_config_model.RateLimitRule{Rate: 1, Burst: 1},
+			IP:         db_config_model.RateLimitRule{Rate: 1, Burst: 1},
+		},
+	})
+	_, _ = limiter.Allow("upload", []rate_limit_service.Subject{
+		{Dimension: "ip", Key: "10.0.0.1"}, {Dimension: "aircraft", Key: "7"}, {Dimension: "credential", Key: "c1"},
+	})
+	_, _ = limiter.Allow("upload", []rate_limit_service.Subject{{Dimension: "ip", Key: "10.0.0.2"}, {Dimension: "aircraft", Key: "8"}})
+	tags := map[string]int{}
+	for _, key := range server.Keys() {
+		if strings.HasPrefix(key, "ratelimit:rl:usage:") {
+			continue
+		}
+		tags[key[strings.Index(key, "{"):strings.Index(key, "}")+1]]++
+	}
+	// 带凭证的请求 3 个令牌桶与 1 个配额计数共用凭证的 tag，另一客户端的 2 个令牌桶与 1 个配额计数使用其 IP 的 tag
+	if len(tags) != 2 || tags["{rl:upload|credential|c1}"] != 4 || tags["{rl:upload|ip|10.0.0.2}"] != 3 {
+		t.Fatalf("unexpected hash tags %v in %v", tags, server.Keys())
+	}
+}
+
+func TestDailyQuotaResetsAtMidnightUTC(t *testing.T) {
+	limiter, now := newLimiter(t, &db_config_model.RateLimitConfigModel{
+		Query: db_config_model.RateLimitScopeModel{Credential: db_config_model.RateLimitRule{DailyQuota: 2}},
+	})
+	credential := []rate_limit_service.Subject{{Dimension: "credential", Key: rate_limit_service.CredentialKey("k1")}}
+	now.now = time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if decision, _ := limiter.Allow("query", credential); !decision.Allowed {
+			t.Fatalf("request %d within quota rejected", i)
+		}
+	}
+	decision, _ := limiter.Allow("query", credential)
+	if decision.Allowed || decision.Dimension != "credential" || decision.RetryAfter != time.Hour {
+		t.Fatalf("expected quota exhausted until midnight, got %+v", decision)
+	}
+	now.now = now.now.Add(time.Hour)
+	if decision, _ := limiter.Allow("query", credential); !decision.Allowed {
+		t.Fatalf("quota not reset on the next day: %+v", decision)
+	}
+
+	usage, err := limiter.Usage(&rate_limit_model.UsageRequest{Date: "2024-05-01", Dimension: "credential", Key: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 || usage[0].Allowed != 2 || usage[0].Rejected != 1 || usage[0].Key != rate_limit_service.CredentialKey("k1") {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestMiddlewareReturns429AndReportsUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, _ := newLimiter(t, &db_config_model.RateLimitConfigModel{
+		CredentialHeader: "X-API-Key",
+		FailOpen:         true,
+		Upload: db_config_model.RateLimitScopeModel{
+			Aircraft:   db_config_model.RateLimitRule{Rate: 1, Burst: 2},
+			Credential: db_config_model.RateLimitRule{Rate: 1, Burst: 100},
+		},
+	})
+	r := gin.New()
+	var received []string
+	r.POST("/upload/aircraftData", middleware.RateLimit(limiter, "upload"), func(c *gin.Context) {
+		// 限流读取请求体后，处理函数仍能读取完整的请求体
+		body, _ := io.ReadAll(c.Request.Body)
+		received = append(received, string(body))
+		c.JSON(200, gin.H{"msg": "ok"})
+	})
+	controller := rate_limit_controller.NewRateLimitControllerFromLimiter(limiter)
+	r.POST("/rateLimit/usage", controller.Usage)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		request.Header.Set("X-API-Key", "partner-key")
+		r.ServeHTTP(rec, request)
+		return rec
+	}
+
+	body := `{"AircraftID":7,"TimeString":"2024-05-01 10:00:01.000000"}`
+	for i := 0; i < 2; i++ {
+		if rec := post("/upload/aircraftData", body); rec.Code != 200 {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	rec := post("/upload/aircraftData", body)
+	if rec.Code != 429 || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After 1, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if len(received) != 2 || received[0] != body {
+		t.Fatalf("handler received %v", received)
+	}
+	if rec := post("/upload/aircraftData", `{"AircraftID":8}`); rec.Code != 200 {
+		t.Fatalf("another aircraft: expected 200, got %d", rec.Code)
+	}
+
+	if rec := post("/rateLimit/usage", `{"Dimension":"fleet"}`); rec.Code != 400 {
+		t.Errorf("invalid dimension: expected 400, got %d", rec.Code)
+	}
+	if rec := post("/rateLimit/usage", `{"Date":"May 1"}`); rec.Code != 400 {
+		t.Errorf("invalid date: expected 400, got %d", rec.Code)
+	}
+	rec = post("/rateLimit/usage", `{"Scope":"upload","Dimension":"aircraft"}`)
+	var resp struct {
+		Data []rate_limit_model.UsageEntry `json:"data"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != 200 || len(resp.Data) != 2 || resp.Data[0].Key != "7" || resp.Data[0].Allowed != 2 ||
+		resp.Data[0].Rejected != 1 || resp.Data[1].Key != "8" {
+		t.Fatalf("usage: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = post("/rateLimit/usage", `{"Dimension":"credential","Key":"partner-key"}`)
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Allowed != 3 || resp.Data[0].Rejected != 1 {
+		t.Fatalf("credential usage: %s", rec.Body.String())
+	}
+}
+
+func TestMiddlewareRejectsOversizedBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, _ := newLimiter(t, &db_config_model.RateLimitConfigModel{
+		Upload: db_config_model.RateLimitScopeModel{Aircraft: db_config_model.RateLimitRule{Rate: 1, Burst: 2}},
+	})
+	r := gin.New()
+	handled := false
+	r.POST("/upload/aircraftData", middleware.RateLimit(limiter, "upload"), func(c *gin.Context) {
+		handled = true
+		c.JSON(200, gin.H{"msg": "ok"})
+	})
+	body := `{"AircraftID":7,"Padding":"` + strings.Repeat("x", 64<<10) + `"}`
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload/aircraftData", strings.NewReader(body)))
+	if rec.Code != 413 || handled {
+		t.Fatalf("expected 413 without reaching the handler, got %d", rec.Code)
+	}
+}
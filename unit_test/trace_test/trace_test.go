@@ -0,0 +1,59 @@
+package trace_test
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"uam-power-backend/middleware"
+	"uam-power-backend/service/trace_service"
+)
+
+func TestTraceFromHandlerToConsumer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	// 模拟上传接口：在 handler 中把 trace context 写入 Kafka 消息头
+	headers := map[string]string{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Tracing())
+	r.POST("/upload/aircraftData", func(c *gin.Context) {
+		trace_service.InjectHeaders(c.Request.Context(), headers)
+		c.Status(200)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/upload/aircraftData", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if headers["traceparent"] == "" {
+		t.Fatal("trace context was not injected into message headers")
+	}
+	// 模拟消费端：从消息头恢复 trace context 并开启 consumer span
+	ctx := trace_service.ExtractHeaders(context.Background(), headers)
+	_, span := trace_service.StartSpan(ctx, "KafkaToMysql status", trace.SpanKindConsumer)
+	trace_service.EndSpan(span, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	server, consumer := spans[0], spans[1]
+	if server.Name() != "POST /upload/aircraftData" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("unexpected server span %s %s", server.Name(), server.SpanKind())
+	}
+	if server.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server span did not continue the incoming trace")
+	}
+	if consumer.SpanContext().TraceID() != server.SpanContext().TraceID() ||
+		consumer.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("consumer span is not a child of the server span")
+	}
+}
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/utils"
+)
+
+func TestLoadConfigWithEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "mysql_psw")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UAM_MYSQLCFG_HOST", "mysql.internal")
+	t.Setenv("UAM_MYSQLCFG_PSW_FILE", secret)
+	t.Setenv("UAM_SERVERCFG_PORT", "8080")
+
+	cfg, err := utils.LoadDBConfig("../../config/db_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MySqlCfg.Host != "mysql.internal" || cfg.MySqlCfg.Psw != "s3cret" || cfg.ServerCfg.Port != 8080 {
+		t.Errorf("env overrides not applied: %+v", cfg)
+	}
+	if err = utils.ValidateDBConfig(cfg); err != nil {
+		t.Errorf("config should be valid: %s", err)
+	}
+	dump := utils.DumpDBConfig(cfg)
+	if strings.Contains(dump, "s3cret") {
+		t.Errorf("secret leaked in dump:\n%s", dump)
+	}
+}
+
+func TestValidateConfigReportsAllErrors(t *testing.T) {
+	cfg := utils.DefaultDBConfig()
+	cfg.ServerCfg.Mode = "prod"
+	cfg.KafkaCfg.Partitions = 0
+	cfg.KafkaCfg.EventDelivery.Mode = "fire"
+	err := utils.ValidateDBConfig(cfg)
+	if err == nil {
+		t.Fatal("empty config should be invalid")
+	}
+	for _, field := range []string{"ServerCfg.Mode", "KafkaCfg.Addr", "KafkaCfg.Partitions", "KafkaCfg.EventDelivery.Mode", "RedisCfg.Host", "MySqlCfg.Psw"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("missing error for %s in: %s", field, err)
+		}
+	}
+}
+
+func TestLoadConfigRejectsBadEnvValue(t *testing.T) {
+	t.Setenv("UAM_REDISCFG_PORT", "not-a-port")
+	if _, err := utils.LoadDBConfig(""); err == nil || !strings.Contains(err.Error(), "UAM_REDISCFG_PORT") {
+		t.Errorf("expected error naming UAM_REDISCFG_PORT, got %v", err)
+	}
+}
+
+func TestKafkaDeliveryPerTopic(t *testing.T) {
+	cfg, err := utils.LoadDBConfig("../../config/db_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kafkaCfg := &cfg.KafkaCfg
+	for topic, sync := range map[string]bool{kafkaCfg.AircraftDataTopic: false, kafkaCfg.AircraftEventTopic: true, "other": false} {
+		producer, err := dbservice.NewKafkaProducerWithConfig(kafkaCfg, topic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if producer.Sync() != sync {
+			t.Errorf("topic %s: sync = %v, want %v", topic, producer.Sync(), sync)
+		}
+		_ = producer.Close()
+	}
+}
+
+func TestValidateConfigWithoutKafka(t *testing.T) {
+	cfg, err := utils.LoadDBConfig("../../config/db_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MySqlCfg.Psw = "s3cret"
+	cfg.KafkaCfg.Addr = ""
+	if err = utils.ValidateDBConfig(cfg); err == nil || !strings.Contains(err.Error(), "KafkaCfg.Addr") {
+		t.Errorf("kafka bus should require broker address, got %v", err)
+	}
+	for _, backend := range []string{"redis", "memory"} {
+		cfg.BusCfg.Backend = backend
+		if err = utils.ValidateDBConfig(cfg); err != nil {
+			t.Errorf("%s bus should not require kafka: %s", backend, err)
+		}
+	}
+}
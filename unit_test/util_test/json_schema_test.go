@@ -0,0 +1,54 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"uam-power-backend/utils"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"required": ["Battery"],
+		"additionalProperties": false,
+		"properties": {
+			"Battery": {"type": "number", "minimum": 0, "maximum": 100},
+			"Cells":   {"type": "array", "items": {"type": "integer"}},
+			"Mode":    {"type": ["string", "null"], "enum": ["eco", "boost", null], "maxLength": 5}
+		}
+	}`)
+	for document, valid := range map[string]bool{
+		`{"Battery": 42.5}`:                             true,
+		`{"Battery": 0, "Cells": [3, 4], "Mode": null}`: true,
+		`{"Battery": 10, "Mode": "eco"}`:                true,
+		`{}`:                                            false,
+		`{"Battery": "full"}`:                           false,
+		`{"Battery": 101}`:                              false,
+		`{"Battery": 50, "Cells": [3.5]}`:               false,
+		`{"Battery": 50, "Mode": "turbo"}`:              false,
+		`{"Battery": 50, "Extra": true}`:                false,
+		`null`:                                          false,
+		`{"Battery": `:                                  false,
+	} {
+		if err := utils.ValidateJSONSchema(schema, json.RawMessage(document)); (err == nil) != valid {
+			t.Errorf("%s: expected valid=%v, got %v", document, valid, err)
+		}
+	}
+}
+
+func TestCompileJSONSchemaRejectsUnsupportedKeywords(t *testing.T) {
+	for _, schema := range []string{
+		`{"type": "object", "pattern": "^a"}`,
+		`{"type": "decimal"}`,
+		`{"properties": {"a": {"type": 1}}}`,
+		`{"minLength": -1}`,
+		`[]`,
+	} {
+		if err := utils.CompileJSONSchema(json.RawMessage(schema)); err == nil {
+			t.Errorf("%s: expected schema error", schema)
+		}
+	}
+	if err := utils.CompileJSONSchema(json.RawMessage(`{"$schema": "x", "title": "t", "type": "object"}`)); err != nil {
+		t.Errorf("annotations should be accepted: %v", err)
+	}
+}
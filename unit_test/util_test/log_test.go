@@ -0,0 +1,59 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/utils"
+)
+
+func TestJSONLogWithRequestID(t *testing.T) {
+	dir := t.TempDir()
+	err := utils.InitLogWithConfig(&db_config_model.LogConfigModel{
+		Level: "debug", Format: "json", ToFile: true, Dir: dir, MaxSizeMB: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer utils.InitLog()
+
+	ctx := utils.ContextWithRequestID(context.Background(), "req-1")
+	utils.LoggerFromContext(ctx).Info("hello", "aircraft_id", 7)
+	utils.MsgSuccess("        [Tester]done")
+	utils.CloseLog()
+
+	raw, err := os.ReadFile(filepath.Join(dir, "log-uam-power-backend.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d", len(lines))
+	}
+	var first, second map[string]any
+	if err = json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first["request_id"] != "req-1" || first["aircraft_id"] != float64(7) || first["level"] != "INFO" {
+		t.Errorf("unexpected record %v", first)
+	}
+	if err = json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if second["component"] != "Tester" || second["msg"] != "done" || second["status"] != "success" {
+		t.Errorf("unexpected record %v", second)
+	}
+}
+
+func TestInvalidLogConfig(t *testing.T) {
+	if err := utils.InitLogWithConfig(&db_config_model.LogConfigModel{Level: "verbose"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if err := utils.InitLogWithConfig(&db_config_model.LogConfigModel{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"testing"
+	"time"
 	"uam-power-backend/utils"
 )
 
@@ -9,3 +10,45 @@ func TestStrToMysqlTimeString(t *testing.T) {
 	t.Log(utils.IsValidSqlTimeFormat("20060102150405000000"))
 	t.Log(utils.IsValidSqlTimeFormat("2024-11-16 12:17:00.123456"))
 }
+
+func TestParseFlexibleTimeNormalizesToUTC(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	expected := "2024-05-01 02:00:00.250000"
+	for input, legacy := range map[string]*time.Location{
+		"2024-05-01T10:00:00.25+08:00":  nil,
+		"2024-05-01T02:00:00.250Z":      shanghai, // 带时区的时间不受 legacy 影响
+		"1714528800250":                 nil,
+		"2024-05-01 10:00:00.250000":    shanghai,
+		"2024-05-01 02:00:00.250000":    nil,
+		"2024-05-01T04:00:00.25+02:00":  time.UTC,
+		"2024-05-01T02:00:00.250000Z":   nil,
+		"2024-05-01T02:00:00.25000000Z": nil,
+	} {
+		parsed, err := utils.ParseFlexibleTime(input, legacy)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if got := utils.FormatSqlTime(parsed); got != expected || parsed.Location() != time.UTC {
+			t.Errorf("%s: expected %s UTC, got %s %s", input, expected, got, parsed.Location())
+		}
+	}
+	for _, input := range []string{"", "2024-05-01 10:00:00", "2024/05/01 10:00:00.000000", "-1714528800250", "yesterday"} {
+		if _, err := utils.ParseFlexibleTime(input, nil); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestGetTimeStrHasMicroseconds(t *testing.T) {
+	first := utils.GetTimeStr()
+	time.Sleep(time.Millisecond)
+	second := utils.GetTimeStr()
+	if len(first) != 20 || first == second {
+		t.Fatalf("expected distinct 20-digit ids, got %s and %s", first, second)
+	}
+	parsed, err := time.Parse("20060102150405", first[:14])
+	if err != nil || time.Since(parsed) > time.Minute || time.Since(parsed) < -time.Minute {
+		t.Fatalf("id %s does not start with the current UTC time: %v", first, err)
+	}
+}
@@ -0,0 +1,268 @@
+package validation_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	"uam-power-backend/controller/data_controller"
+	"uam-power-backend/controller/quarantine_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/data_flow_model"
+	"uam-power-backend/models/controller_models/quarantine_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/db_service"
+	"uam-power-backend/service/event_catalog_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/validation_service"
+)
+
+const statusTopic = "AircraftData"
+
+// serverTime 测试中校验器使用的当前时间
+var serverTime = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func newValidator(db *repository_service.MemoryDatabase) *validation_service.Validator {
+	validator := validation_service.NewValidator(repository_service.NewMemoryStore(), db, &db_config_model.ValidationConfigModel{
+		Enable: true, MinAltitude: -500, MaxAltitude: 10000, RejectNullIsland: true,
+		MaxSpeedMps: 150, SpeedWindowSec: 300, MaxFutureSec: 30, MaxPastSec: 86400,
+	})
+	validator.Now = func() time.Time { return serverTime }
+	return validator
+}
+
+// at 返回 serverTime 偏移 offset 后的 TimeString
+func at(offset time.Duration) string {
+	return serverTime.Add(offset).Format("2006-01-02 15:04:05.000000")
+}
+
+func codes(reasons []quarantine_model.Reason) []string {
+	var result []string
+	for _, reason := range reasons {
+		result = append(result, reason.Code)
+	}
+	return result
+}
+
+func TestValidateRangesTimeAndYaw(t *testing.T) {
+	validator := newValidator(repository_service.NewMemoryDatabase())
+	for _, tc := range []struct {
+		name     string
+		status   data_flow_model.AircraftStatus
+		expected []string
+	}{
+		{"valid", data_flow_model.AircraftStatus{Latitude: 31.2, Longitude: 121.5, Altitude: 120}, nil},
+		{"null island", data_flow_model.AircraftStatus{Altitude: 120}, []string{quarantine_model.ReasonNullIsland}},
+		{"altitude", data_flow_model.AircraftStatus{Latitude: 31.2, Longitude: 121.5, Altitude: -9999},
+			[]string{quarantine_model.ReasonAltitude}},
+		{"coordinates", data_flow_model.AircraftStatus{Latitude: 95, Longitude: -181, Altitude: 120},
+			[]string{quarantine_model.ReasonLatitude, quarantine_model.ReasonLongitude}},
+	} {
+		tc.status.AircraftID, tc.status.TimeString = len(tc.name), at(0)
+		reasons, err := validator.Validate(&tc.status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := codes(reasons); len(got) != len(tc.expected) || (len(got) > 0 && got[0] != tc.expected[0]) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, reasons)
+		}
+	}
+
+	future := data_flow_model.AircraftStatus{AircraftID: 9, TimeString: at(time.Minute), Latitude: 31.2, Longitude: 121.5}
+	if reasons, _ := validator.Validate(&future); len(reasons) != 1 || reasons[0].Code != quarantine_model.ReasonFuture {
+		t.Errorf("future point: %v", reasons)
+	}
+	past := data_flow_model.AircraftStatus{AircraftID: 9, TimeString: at(-48 * time.Hour), Latitude: 31.2, Longitude: 121.5}
+	if reasons, _ := validator.Validate(&past); len(reasons) != 1 || reasons[0].Code != quarantine_model.ReasonPast {
+		t.Errorf("old point: %v", reasons)
+	}
+
+	for yaw, expected := range map[float64]float64{370: 10, -90: 270, 360: 0, 45: 45} {
+		status := data_flow_model.AircraftStatus{AircraftID: 10, TimeString: at(0), Latitude: 31.2, Longitude: 121.5, Yaw: yaw}
+		if _, _ = validator.Validate(&status); status.Yaw != expected {
+			t.Errorf("yaw %g normalized to %g, expected %g", yaw, status.Yaw, expected)
+		}
+	}
+}
+
+func TestImpliedSpeedAgainstPreviousPoint(t *testing.T) {
+	validator := newValidator(repository_service.NewMemoryDatabase())
+	point := func(offset time.Duration, latitude float64) []string {
+		status := data_flow_model.AircraftStatus{AircraftID: 7, TimeString: at(offset), Latitude: latitude, Longitude: 121.5, Altitude: 100}
+		reasons, err := validator.Validate(&status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return codes(reasons)
+	}
+	if got := point(-400*time.Second, 31.2); got != nil {
+		t.Fatalf("first point rejected: %v", got)
+	}
+	// 0.45 度纬度约 50 km，1 秒内到达不合理
+	if got := point(-399*time.Second, 31.65); len(got) != 1 || got[0] != quarantine_model.ReasonSpeed {
+		t.Fatalf("expected implied speed, got %v", got)
+	}
+	// 被隔离的点不替换上一个点：约 110 m / 2 s 相对第一个点合理
+	if got := point(-398*time.Second, 31.201); got != nil {
+		t.Fatalf("plausible point rejected: %v", got)
+	}
+	// 重发的旧点不检查速度
+	if got := point(-400*time.Second, 31.2); got != nil {
+		t.Fatalf("retransmitted point rejected: %v", got)
+	}
+	// 超过 SpeedWindowSec 后不再与上一个点比较
+	if got := point(-398*time.Second+301*time.Second, 35); got != nil {
+		t.Fatalf("point after speed window rejected: %v", got)
+	}
+	if distance := validation_service.Distance(0, 0, 0, 1, 0, 0); distance < 111190 || distance > 111200 {
+		t.Errorf("one degree of latitude is %g m", distance)
+	}
+}
+
+func TestLastPointExpiresAndKeepsNewest(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	store, err := dbservice.NewRedisDictWithConfig(&db_config_model.RedisConfigModel{Host: server.Host(), Port: port}, "dedup:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := validation_service.NewValidator(store, repository_service.NewMemoryDatabase(), &db_config_model.ValidationConfigModel{
+		Enable: true, MinAltitude: -500, MaxAltitude: 10000, MaxSpeedMps: 150, SpeedWindowSec: 300, MaxPastSec: 86400,
+	})
+	validator.Now = func() time.Time { return serverTime }
+	point := func(offset time.Duration, latitude float64) []string {
+		status := data_flow_model.AircraftStatus{AircraftID: 7, TimeString: at(offset), Latitude: latitude, Longitude: 121.5, Altitude: 100}
+		reasons, err := validator.Validate(&status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return codes(reasons)
+	}
+	if got := point(-10*time.Second, 31.2); got != nil {
+		t.Fatalf("first point rejected: %v", got)
+	}
+	if ttl := server.TTL("dedup:lastpoint:{7}"); ttl <= 0 || ttl > 300*time.Second {
+		t.Fatalf("last point should expire after SpeedWindowSec, ttl %s", ttl)
+	}
+	// 乱序到达的旧点不替换上一个点：下一个点仍与 -10s 的点比较，约 110 m / 2 s 合理
+	if got := point(-20*time.Second, 31.3); got != nil {
+		t.Fatalf("out-of-order point rejected: %v", got)
+	}
+	if got := point(-8*time.Second, 31.201); got != nil {
+		t.Fatalf("point compared with an older out-of-order point: %v", got)
+	}
+	// 上一个点过期后不再与下一次飞行的点比较
+	server.FastForward(301 * time.Second)
+	if got := point(-7*time.Second, 35); got != nil {
+		t.Fatalf("point compared with an expired last point: %v", got)
+	}
+}
+
+// failingProducer 投递总是失败的生产者
+type failingProducer struct{}
+
+func (failingProducer) SendKeyedMessage(context.Context, string, string) error {
+	return errors.New("broker unavailable")
+}
+func (failingProducer) Sync() bool   { return true }
+func (failingProducer) Close() error { return nil }
+
+func post(r *gin.Engine, path string, body interface{}) (int, map[string]json.RawMessage) {
+	data, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+	var resp map[string]json.RawMessage
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestUploadQuarantineAndReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := repository_service.NewMemoryDatabase()
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer(statusTopic, "test")
+	upload := data_controller.NewUploadAircraftControllerFromProducers(bus.Producer(statusTopic), bus.Producer("AircraftEvent"),
+		event_catalog_service.NewCatalog(db, time.Minute), newValidator(db), nil)
+	quarantine := quarantine_controller.NewQuarantineControllerFromStores(db, bus.Producer(statusTopic))
+	r := gin.New()
+	r.POST("/upload/aircraftData", upload.UploadData)
+	r.POST("/quarantine/list", quarantine.ListQuarantine)
+	r.POST("/quarantine/review", quarantine.ReviewQuarantine)
+	fetch := func() *data_flow_model.AircraftStatus {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		msg, err := consumer.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = consumer.CommitMessage(ctx, msg)
+		var status data_flow_model.AircraftStatus
+		_ = json.Unmarshal([]byte(msg.Value), &status)
+		return &status
+	}
+
+	good := data_flow_model.AircraftStatus{AircraftID: 3, TimeString: at(0), Latitude: 31.2, Longitude: 121.5, Altitude: 80, Yaw: -10}
+	if code, _ := post(r, "/upload/aircraftData", good); code != 200 {
+		t.Fatalf("valid point: expected 200, got %d", code)
+	}
+	if status := fetch(); status.Yaw != 350 {
+		t.Fatalf("yaw not normalized before sending: %+v", status)
+	}
+	bad := data_flow_model.AircraftStatus{AircraftID: 3, TimeString: at(time.Second), Altitude: -9999}
+	code, resp := post(r, "/upload/aircraftData", bad)
+	var quarantineID int
+	_ = json.Unmarshal(resp["QuarantineID"], &quarantineID)
+	if code != 422 || quarantineID != 1 {
+		t.Fatalf("implausible point: expected 422 with QuarantineID, got %d %v", code, resp)
+	}
+	_, _ = post(r, "/upload/aircraftData", data_flow_model.AircraftStatus{AircraftID: 4, TimeString: at(0), Latitude: 91, Longitude: 1})
+
+	_, resp = post(r, "/quarantine/list", quarantine_model.ListQuarantineRequest{Reason: quarantine_model.ReasonAltitude})
+	var points []quarantine_model.QuarantinedPoint
+	_ = json.Unmarshal(resp["data"], &points)
+	if len(points) != 1 || points[0].AircraftID != 3 || points[0].Status != quarantine_model.StatusPending ||
+		codes(points[0].Reasons)[0] != quarantine_model.ReasonNullIsland {
+		t.Fatalf("unexpected quarantine list %+v", points)
+	}
+
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 1, Action: "keep", Reviewer: "ops"}); code != 400 {
+		t.Errorf("invalid action: expected 400, got %d", code)
+	}
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 1, Action: "release"}); code != 400 {
+		t.Errorf("missing reviewer: expected 400, got %d", code)
+	}
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 99, Action: "release", Reviewer: "ops"}); code != 404 {
+		t.Errorf("unknown record: expected 404, got %d", code)
+	}
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 1, Action: "release", Reviewer: "ops"}); code != 200 {
+		t.Fatalf("release: expected 200, got %d", code)
+	}
+	if status := fetch(); status.AircraftID != 3 || status.Altitude != -9999 {
+		t.Fatalf("released point not sent: %+v", status)
+	}
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 1, Action: "discard", Reviewer: "ops"}); code != 409 {
+		t.Errorf("second review: expected 409, got %d", code)
+	}
+
+	// 投递失败时记录恢复为 pending，可以再次审核
+	failing := quarantine_controller.NewQuarantineControllerFromStores(db, failingProducer{})
+	r.POST("/failing/review", failing.ReviewQuarantine)
+	if code, _ := post(r, "/failing/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 2, Action: "release", Reviewer: "ops"}); code != 503 {
+		t.Fatalf("release with broken bus: expected 503, got %d", code)
+	}
+	if code, _ := post(r, "/quarantine/review", quarantine_model.ReviewQuarantineRequest{QuarantineID: 2, Action: "discard", Reviewer: "ops", Note: "gps glitch"}); code != 200 {
+		t.Fatalf("discard after failed release: expected 200, got %d", code)
+	}
+	_, resp = post(r, "/quarantine/list", quarantine_model.ListQuarantineRequest{Status: quarantine_model.StatusDiscarded})
+	_ = json.Unmarshal(resp["data"], &points)
+	if len(points) != 1 || points[0].QuarantineID != 2 || points[0].Reviewer != "ops" || points[0].ReviewTime == nil {
+		t.Fatalf("unexpected discarded list %+v", points)
+	}
+}
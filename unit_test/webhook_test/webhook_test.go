@@ -0,0 +1,306 @@
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"uam-power-backend/controller/aircraft_task_controller"
+	"uam-power-backend/controller/webhook_controller"
+	"uam-power-backend/models/config_models/db_config_model"
+	"uam-power-backend/models/controller_models/aircraft_id_model"
+	"uam-power-backend/models/controller_models/webhook_model"
+	"uam-power-backend/service/bus_service"
+	"uam-power-backend/service/data_transfer_service"
+	"uam-power-backend/service/repository_service"
+	"uam-power-backend/service/webhook_service"
+	"uam-power-backend/utils"
+)
+
+const secret = "0123456789abcdef-secret"
+
+// receiver 记录收到的请求并按 statuses 依次响应，用尽后返回 200
+type receiver struct {
+	mutex    sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	rc.requests, rc.bodies = append(rc.requests, r), append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("status " + http.StatusText(status)))
+}
+
+func newDispatcher(db *repository_service.MemoryDatabase) *webhook_service.Dispatcher {
+	dispatcher := webhook_service.NewDispatcher(db, &db_config_model.WebhookConfigModel{
+		Enable: true, TimeoutMs: 1000, MaxAttempts: 3, InitialBackoffSec: 1, MaxBackoffSec: 1, PollIntervalSec: 1, BatchSize: 10,
+	})
+	dispatcher.InitialBackoff, dispatcher.MaxBackoff = 20*time.Millisecond, 30*time.Millisecond
+	return dispatcher
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	dispatcher := &webhook_service.Dispatcher{InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+	for attempts, expected := range map[int]time.Duration{
+		1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 4: time.Minute, 20: time.Minute,
+	} {
+		if got := dispatcher.Backoff(attempts); got != expected {
+			t.Errorf("Backoff(%d): expected %v, got %v", attempts, expected, got)
+		}
+	}
+}
+
+func TestSignedDeliveryIsRetriedUntilAccepted(t *testing.T) {
+	ctx, logger := context.Background(), utils.ComponentLogger("test")
+	db := repository_service.NewMemoryDatabase()
+	rc := &receiver{statuses: []int{500, 503}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	other := &receiver{}
+	otherServer := httptest.NewServer(other)
+	defer otherServer.Close()
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{URL: server.URL, Secret: secret, EventTypes: []string{"LOW_BATTERY"}})
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{URL: otherServer.URL, Secret: secret, EventTypes: []string{"TASK_ENDED"}})
+	dispatcher := newDispatcher(db)
+
+	if err := dispatcher.Notify(ctx, "LOW_BATTERY", "event:1", map[string]int{"Battery": 12}); err != nil {
+		t.Fatal(err)
+	}
+	// 同一通知重复写入时不重复投递
+	_ = dispatcher.Notify(ctx, "LOW_BATTERY", "event:1", map[string]int{"Battery": 12})
+
+	if n := dispatcher.DeliverDue(ctx, logger); n != 1 {
+		t.Fatalf("expected 1 due delivery, got %d", n)
+	}
+	// 退避期间不重试
+	if n := dispatcher.DeliverDue(ctx, logger); n != 0 {
+		t.Fatalf("retried before backoff elapsed: %d", n)
+	}
+	deliveries, _ := db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{})
+	if len(deliveries) != 1 || deliveries[0].Status != webhook_model.DeliveryPending || deliveries[0].Attempts != 1 ||
+		deliveries[0].LastStatusCode != 500 || !strings.Contains(deliveries[0].LastError, "HTTP 500") {
+		t.Fatalf("unexpected delivery after first failure %+v", deliveries)
+	}
+	for i := 0; i < 2; i++ {
+		time.Sleep(40 * time.Millisecond)
+		dispatcher.DeliverDue(ctx, logger)
+	}
+	deliveries, _ = db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{})
+	if deliveries[0].Status != webhook_model.DeliveryDelivered || deliveries[0].Attempts != 3 ||
+		deliveries[0].LastError != "" || deliveries[0].DeliveredTime == nil {
+		t.Fatalf("unexpected delivery after retries %+v", deliveries[0])
+	}
+
+	if len(rc.requests) != 3 || len(other.requests) != 0 {
+		t.Fatalf("expected 3 attempts to the subscriber only, got %d and %d", len(rc.requests), len(other.requests))
+	}
+	for i, request := range rc.requests {
+		timestamp := request.Header.Get(webhook_model.HeaderTimestamp)
+		expected := "sha256=" + webhook_service.Sign(secret, timestamp, rc.bodies[i])
+		if request.Header.Get(webhook_model.HeaderSignature) != expected {
+			t.Errorf("attempt %d: bad signature %q", i, request.Header.Get(webhook_model.HeaderSignature))
+		}
+		if request.Header.Get(webhook_model.HeaderEvent) != "LOW_BATTERY" || request.Header.Get(webhook_model.HeaderDelivery) != "1" {
+			t.Errorf("attempt %d: unexpected headers %v", i, request.Header)
+		}
+	}
+	var notification webhook_model.Notification
+	if err := json.Unmarshal(rc.bodies[2], &notification); err != nil || notification.ID != "event:1" ||
+		notification.Type != "LOW_BATTERY" || string(notification.Data) != `{"Battery":12}` {
+		t.Errorf("unexpected body %s", rc.bodies[2])
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	ctx, logger := context.Background(), utils.ComponentLogger("test")
+	db := repository_service.NewMemoryDatabase()
+	rc := &receiver{statuses: []int{500, 500, 500, 500}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{URL: server.URL, Secret: secret})
+	dispatcher := newDispatcher(db)
+	_ = dispatcher.Notify(ctx, "EMERGENCY", "event:2", nil)
+	for i := 0; i < 5; i++ {
+		dispatcher.DeliverDue(ctx, logger)
+		time.Sleep(40 * time.Millisecond)
+	}
+	deliveries, _ := db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{Status: webhook_model.DeliveryFailed})
+	if len(deliveries) != 1 || deliveries[0].Attempts != 3 || len(rc.requests) != 3 {
+		t.Fatalf("expected failure after 3 attempts, got %+v and %d requests", deliveries, len(rc.requests))
+	}
+}
+
+func TestEventsAreForwardedToWebhooks(t *testing.T) {
+	ctx := context.Background()
+	db := repository_service.NewMemoryDatabase()
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{URL: "http://127.0.0.1:1", Secret: secret, EventTypes: []string{"EMERGENCY"}})
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer("aircraft_event", "KafkaToWebhook")
+	toWebhook := data_transfer_service.NewKafkaToWebhookFromStores(consumer, newDispatcher(db))
+	toWebhook.Start()
+	producer := bus.Producer("aircraft_event")
+	for _, event := range []string{
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:01.000000","Event":"TAKEOFF","Severity":"info"}`,
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:02.000000","Event":"EMERGENCY","Severity":"critical"}`,
+		// 重投的同一事件只投递一次
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:02.000000","Event":"EMERGENCY","Severity":"critical"}`,
+	} {
+		if err := producer.SendKeyedMessage(ctx, "1", event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for deadline := time.Now().Add(5 * time.Second); consumer.Lag() > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for events to be consumed")
+		}
+	}
+	toWebhook.Stop()
+	deliveries, _ := db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{})
+	if len(deliveries) != 1 || deliveries[0].Type != "EMERGENCY" ||
+		deliveries[0].NotificationID != "event:1:2024-05-01 10:00:02.000000:EMERGENCY" {
+		t.Fatalf("unexpected deliveries %+v", deliveries)
+	}
+}
+
+func TestSubscriptionsAndTaskNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	db := repository_service.NewMemoryDatabase()
+	aircraft, _ := db.CreateAircraft(ctx, &aircraft_id_model.SetAircraftInfo{Company: "uam", Name: "a1", Type: "quad"})
+	dispatcher := newDispatcher(db)
+	webhookController := webhook_controller.NewWebhookControllerFromStores(db)
+	taskController := aircraft_task_controller.NewAircraftTaskModelFromStores(db, repository_service.NewMemoryStore(), dispatcher)
+	r := gin.New()
+	r.POST("/webhook/create", webhookController.CreateSubscription)
+	r.POST("/webhook/list", webhookController.ListSubscriptions)
+	r.POST("/webhook/delete", webhookController.DeleteSubscription)
+	r.POST("/webhook/deliveries", webhookController.ListDeliveries)
+	r.POST("/aircraftTask/create", taskController.CreateTask)
+	r.POST("/aircraftTask/end", taskController.EndTask)
+	post := func(path string, body interface{}) (int, json.RawMessage) {
+		payload, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload)))
+		var resp struct {
+			Data json.RawMessage `json:"data"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Data
+	}
+
+	for _, invalid := range []map[string]interface{}{
+		{"URL": "ftp://example.com/hook", "Secret": secret},
+		{"URL": "/relative", "Secret": secret},
+		{"URL": "https://example.com/hook", "Secret": "short"},
+		{"URL": "https://example.com/hook", "Secret": secret, "EventTypes": []string{"bad type"}},
+	} {
+		if code, _ := post("/webhook/create", invalid); code != 400 {
+			t.Errorf("invalid subscription %v: expected 400, got %d", invalid, code)
+		}
+	}
+	code, data := post("/webhook/create", map[string]interface{}{
+		"URL": "https://partner.example.com/hook", "Secret": secret, "EventTypes": []string{"TASK_STARTED", "TASK_ENDED"},
+	})
+	if code != 200 || strings.Contains(string(data), secret) {
+		t.Fatalf("create subscription: %d %s", code, data)
+	}
+	var subscription webhook_model.Subscription
+	_ = json.Unmarshal(data, &subscription)
+	if code, data := post("/webhook/list", map[string]string{}); code != 200 || strings.Contains(string(data), secret) {
+		t.Errorf("list subscriptions: %d %s", code, data)
+	}
+
+	if code, _ := post("/aircraftTask/create", map[string]int{"AircraftID": aircraft.AircraftID, "LaneID": 2}); code != 200 {
+		t.Fatalf("create task: %d", code)
+	}
+	if code, _ := post("/aircraftTask/end", map[string]int{"AircraftID": aircraft.AircraftID}); code != 200 {
+		t.Fatalf("end task: %d", code)
+	}
+	code, data = post("/webhook/deliveries", map[string]int{"SubscriptionID": subscription.SubscriptionID})
+	var deliveries []webhook_model.Delivery
+	_ = json.Unmarshal(data, &deliveries)
+	if code != 200 || len(deliveries) != 2 || deliveries[0].Type != webhook_model.TypeTaskEnded ||
+		deliveries[1].Type != webhook_model.TypeTaskStarted || deliveries[0].Status != webhook_model.DeliveryPending {
+		t.Fatalf("deliveries: %d %s", code, data)
+	}
+	var notification struct {
+		Data struct {
+			TaskID  int
+			EndTime *time.Time
+		}
+	}
+	_ = json.Unmarshal(deliveries[0].Body, &notification)
+	if notification.Data.TaskID == 0 || notification.Data.EndTime == nil {
+		t.Errorf("TASK_ENDED notification lacks the task: %s", deliveries[0].Body)
+	}
+	if code, _ := post("/webhook/deliveries", map[string]string{"Status": "lost"}); code != 400 {
+		t.Errorf("invalid status filter: expected 400, got %d", code)
+	}
+
+	if code, _ := post("/webhook/delete", map[string]int{"SubscriptionID": subscription.SubscriptionID}); code != 200 {
+		t.Fatalf("delete subscription: %d", code)
+	}
+	if code, _ := post("/webhook/delete", map[string]int{"SubscriptionID": subscription.SubscriptionID}); code != 404 {
+		t.Errorf("delete twice: expected 404, got %d", code)
+	}
+	if _, data := post("/webhook/deliveries", map[string]string{}); string(data) != "[]" {
+		t.Errorf("deliveries of a deleted subscription remain: %s", data)
+	}
+}
+
+// flakyWebhooks 前 failures 次写入投递记录返回错误的 webhook 存储，模拟 MySQL 暂时不可用
+type flakyWebhooks struct {
+	*repository_service.MemoryDatabase
+	failures int
+}
+
+func (f *flakyWebhooks) EnqueueDeliveries(ctx context.Context, deliveries []webhook_model.Delivery) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		return 0, errors.New("mysql unavailable")
+	}
+	return f.MemoryDatabase.EnqueueDeliveries(ctx, deliveries)
+}
+
+func TestFailedEnqueueIsNotCommitted(t *testing.T) {
+	ctx := context.Background()
+	db := repository_service.NewMemoryDatabase()
+	_ = db.CreateSubscription(ctx, &webhook_model.Subscription{URL: "http://127.0.0.1:1", Secret: secret, EventTypes: []string{"EMERGENCY"}})
+	bus := bus_service.NewMemoryBus(10)
+	consumer := bus.Consumer("aircraft_event", "KafkaToWebhook")
+	dispatcher := webhook_service.NewDispatcher(&flakyWebhooks{MemoryDatabase: db, failures: 2}, &db_config_model.WebhookConfigModel{
+		Enable: true, TimeoutMs: 1000, MaxAttempts: 3, InitialBackoffSec: 1, MaxBackoffSec: 1, PollIntervalSec: 1, BatchSize: 10,
+	})
+	toWebhook := data_transfer_service.NewKafkaToWebhookFromStores(consumer, dispatcher)
+	toWebhook.Start()
+	if err := bus.Producer("aircraft_event").SendKeyedMessage(ctx, "1",
+		`{"AircraftID":1,"TimeString":"2024-05-01 10:00:02.000000","Event":"EMERGENCY","Severity":"critical"}`); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); consumer.Lag() > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event to be committed")
+		}
+	}
+	toWebhook.Stop()
+	if deliveries, _ := db.ListDeliveries(ctx, &webhook_model.ListDeliveriesRequest{}); len(deliveries) != 1 {
+		t.Fatalf("expected the notification to be enqueued after retries, got %+v", deliveries)
+	}
+}
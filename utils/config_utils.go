@@ -1,22 +1,515 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 	"uam-power-backend/models/config_models/db_config_model"
 )
 
+const (
+	// ConfigEnvPrefix 环境变量覆盖配置时使用的前缀，如 UAM_MYSQLCFG_PSW
+	ConfigEnvPrefix = "UAM"
+	// ConfigPathEnv 指定配置文件路径的环境变量
+	ConfigPathEnv = "UAM_CONFIG"
+	// DefaultConfigPath 默认配置文件路径
+	DefaultConfigPath = "config/db_config.yaml"
+
+	redactedValue = "******"
+)
+
+// requiredAcks KafkaCfg.RequiredAcks 可选的值，与 dbservice.KafkaRequiredAcks 一致
+var requiredAcks = []string{"", "none", "one", "all", "0", "1", "-1"}
+
+// LoadDBConfig 按 默认值 -> 配置文件 -> 环境变量 -> 秘钥文件 的顺序分层加载配置。
+// 任一字段均可通过 UAM_<段名>_<字段名> 覆盖，或通过 UAM_<段名>_<字段名>_FILE 从文件读取（适用于密码等秘钥）
 func LoadDBConfig(filename string) (*db_config_model.DbConfigModel, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
+	config := DefaultDBConfig()
+	if filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("read config file %s: %w", filename, err)
+		}
+		if err = yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parse config file %s: %w", filename, err)
+		}
+	}
+	if err := applyEnvOverrides(reflect.ValueOf(config).Elem(), ConfigEnvPrefix); err != nil {
 		return nil, err
 	}
+	return config, nil
+}
+
+// DefaultDBConfig 返回未被配置文件覆盖时使用的默认配置
+func DefaultDBConfig() *db_config_model.DbConfigModel {
+	return &db_config_model.DbConfigModel{
+		ServerCfg: db_config_model.ServerConfigModel{Port: 26969, Mode: "debug", ShutdownTimeoutSec: 10},
+		LogCfg: db_config_model.LogConfigModel{
+			Level:      "info",
+			Format:     "console",
+			Dir:        "./logs",
+			MaxSizeMB:  100,
+			MaxBackups: 7,
+			MaxAgeDays: 30,
+		},
+		TraceCfg: db_config_model.TraceConfigModel{
+			Exporter:    "otlp",
+			ServiceName: "uam-power-backend",
+			Endpoint:    "127.0.0.1:4318",
+			FilePath:    "./logs/traces.jsonl",
+			SampleRatio: 1,
+		},
+		BusCfg: db_config_model.BusConfigModel{
+			Backend:      "kafka",
+			StreamPrefix: "stream:",
+			StreamMaxLen: 100000,
+			ClaimIdleMs:  30000,
+			MemoryBuffer: 10000,
+		},
+		KafkaCfg: db_config_model.KafkaConfigModel{
+			EnsureTopics:      true,
+			Partitions:        6,
+			ReplicationFactor: 1,
+			LingerMs:          10,
+			DataDelivery:      db_config_model.KafkaDeliveryConfigModel{Mode: "async"},
+			EventDelivery:     db_config_model.KafkaDeliveryConfigModel{Mode: "sync", RequiredAcks: "all", TimeoutMs: 5000},
+		},
+		RedisCfg: db_config_model.RedisConfigModel{
+			Mode:            "standalone",
+			StatusPrefix:    "status:",
+			EventPrefix:     "event:",
+			AircraftPrefix:  "aircraft:",
+			TaskInfoPrefix:  "task:",
+			DedupPrefix:     "dedup:",
+			RateLimitPrefix: "ratelimit:",
+		},
+		PipelineCfg: db_config_model.PipelineConfigModel{
+			DedupWindowSec:         600,
+			DedupMaxEntries:        1000,
+			EventHistorySize:       50,
+			EventGlobalHistorySize: 500,
+			EventHistoryTTLSec:     86400,
+			StatusTTLSec:           3600,
+			LinkTimeoutSec:         15,
+			LinkCheckIntervalSec:   2,
+			EventCatalogRefreshSec: 30,
+			LegacyTimeZone:         "UTC",
+		},
+		AlertCfg: db_config_model.AlertConfigModel{
+			Enable:             true,
+			EventTypes:         []string{"LINK_LOST"},
+			MinSeverity:        "critical",
+			EscalateAfterSec:   300,
+			MaxEscalationLevel: 3,
+			CheckIntervalSec:   30,
+		},
+		WebhookCfg: db_config_model.WebhookConfigModel{
+			Enable:            true,
+			TimeoutMs:         5000,
+			MaxAttempts:       8,
+			InitialBackoffSec: 10,
+			MaxBackoffSec:     3600,
+			PollIntervalSec:   2,
+			BatchSize:         50,
+		},
+		RateLimitCfg: db_config_model.RateLimitConfigModel{
+			Enable:             true,
+			CredentialHeader:   "X-API-Key",
+			FailOpen:           true,
+			UsageRetentionDays: 7,
+			Upload: db_config_model.RateLimitScopeModel{
+				Aircraft:   db_config_model.RateLimitRule{Rate: 20, Burst: 40},
+				Credential: db_config_model.RateLimitRule{Rate: 500, Burst: 1000},
+				IP:         db_config_model.RateLimitRule{Rate: 200, Burst: 400},
+			},
+			Query: db_config_model.RateLimitScopeModel{
+				Aircraft:   db_config_model.RateLimitRule{Rate: 10, Burst: 20},
+				Credential: db_config_model.RateLimitRule{Rate: 100, Burst: 200},
+				IP:         db_config_model.RateLimitRule{Rate: 20, Burst: 40},
+			},
+		},
+		ValidationCfg: db_config_model.ValidationConfigModel{
+			Enable:           true,
+			MinAltitude:      -500,
+			MaxAltitude:      10000,
+			RejectNullIsland: true,
+			MaxSpeedMps:      150,
+			SpeedWindowSec:   300,
+			MaxFutureSec:     30,
+			MaxPastSec:       86400,
+		},
+	}
+}
+
+// ValidateDBConfig 校验配置完整性，返回包含全部问题的错误
+func ValidateDBConfig(cfg *db_config_model.DbConfigModel) error {
+	var errs []error
+	required := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+	port := func(name string, value int) {
+		if value <= 0 || value > 65535 {
+			errs = append(errs, fmt.Errorf("%s must be in 1-65535, got %d", name, value))
+		}
+	}
+	redisDB := func(name string, value int) {
+		if value < 0 || value > 15 {
+			errs = append(errs, fmt.Errorf("%s must be in 0-15, got %d", name, value))
+		}
+	}
+	oneOf := func(name, value string, options ...string) {
+		for _, option := range options {
+			if strings.EqualFold(value, option) {
+				return
+			}
+		}
+		var names []string
+		for _, option := range options {
+			if option != "" {
+				names = append(names, option)
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(names, "/"), value))
+	}
+	nonNegative := func(name string, value int) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %d", name, value))
+		}
+	}
+	tlsFiles := func(name string, tlsCfg *db_config_model.TLSConfigModel) {
+		if tlsCfg.Enable && (tlsCfg.CertFile == "") != (tlsCfg.KeyFile == "") {
+			errs = append(errs, fmt.Errorf("%s.CertFile and %s.KeyFile must be set together", name, name))
+		}
+	}
+
+	port("ServerCfg.Port", cfg.ServerCfg.Port)
+	oneOf("ServerCfg.Mode", cfg.ServerCfg.Mode, "debug", "release", "test")
+	nonNegative("ServerCfg.ShutdownTimeoutSec", cfg.ServerCfg.ShutdownTimeoutSec)
+	oneOf("LogCfg.Level", cfg.LogCfg.Level, "debug", "info", "warn", "error")
+	oneOf("LogCfg.Format", cfg.LogCfg.Format, "console", "json")
+	nonNegative("LogCfg.MaxSizeMB", cfg.LogCfg.MaxSizeMB)
+	nonNegative("LogCfg.MaxBackups", cfg.LogCfg.MaxBackups)
+	nonNegative("LogCfg.MaxAgeDays", cfg.LogCfg.MaxAgeDays)
+	if cfg.TraceCfg.Enable {
+		oneOf("TraceCfg.Exporter", cfg.TraceCfg.Exporter, "otlp", "stdout", "file")
+		required("TraceCfg.ServiceName", cfg.TraceCfg.ServiceName)
+		switch strings.ToLower(cfg.TraceCfg.Exporter) {
+		case "otlp":
+			required("TraceCfg.Endpoint", cfg.TraceCfg.Endpoint)
+		case "file":
+			required("TraceCfg.FilePath", cfg.TraceCfg.FilePath)
+		}
+		if cfg.TraceCfg.SampleRatio < 0 || cfg.TraceCfg.SampleRatio > 1 {
+			errs = append(errs, fmt.Errorf("TraceCfg.SampleRatio must be in 0-1, got %v", cfg.TraceCfg.SampleRatio))
+		}
+	}
+
+	oneOf("BusCfg.Backend", cfg.BusCfg.Backend, "", "kafka", "redis", "memory")
+	if strings.EqualFold(cfg.BusCfg.Backend, "redis") {
+		nonNegative("BusCfg.StreamMaxLen", cfg.BusCfg.StreamMaxLen)
+		if cfg.BusCfg.ClaimIdleMs <= 0 {
+			errs = append(errs, fmt.Errorf("BusCfg.ClaimIdleMs must be positive, got %d", cfg.BusCfg.ClaimIdleMs))
+		}
+	}
+	if strings.EqualFold(cfg.BusCfg.Backend, "memory") && cfg.BusCfg.MemoryBuffer <= 0 {
+		errs = append(errs, fmt.Errorf("BusCfg.MemoryBuffer must be positive, got %d", cfg.BusCfg.MemoryBuffer))
+	}
+
+	// 仅 kafka 总线需要 broker 地址，topic 名称各总线通用
+	if (cfg.BusCfg.Backend == "" || strings.EqualFold(cfg.BusCfg.Backend, "kafka")) &&
+		strings.TrimSpace(cfg.KafkaCfg.Addr) == "" && len(cfg.KafkaCfg.Brokers) == 0 {
+		errs = append(errs, errors.New("KafkaCfg.Addr or KafkaCfg.Brokers is required"))
+	}
+	required("KafkaCfg.AircraftDataTopic", cfg.KafkaCfg.AircraftDataTopic)
+	required("KafkaCfg.AircraftEventTopic", cfg.KafkaCfg.AircraftEventTopic)
+	oneOf("KafkaCfg.SASL.Mechanism", cfg.KafkaCfg.SASL.Mechanism, "", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")
+	if cfg.KafkaCfg.SASL.Mechanism != "" {
+		required("KafkaCfg.SASL.Username", cfg.KafkaCfg.SASL.Username)
+		required("KafkaCfg.SASL.Password", cfg.KafkaCfg.SASL.Password)
+	}
+	tlsFiles("KafkaCfg.TLS", &cfg.KafkaCfg.TLS)
+	if cfg.KafkaCfg.EnsureTopics {
+		if cfg.KafkaCfg.Partitions <= 0 {
+			errs = append(errs, fmt.Errorf("KafkaCfg.Partitions must be positive, got %d", cfg.KafkaCfg.Partitions))
+		}
+		if cfg.KafkaCfg.ReplicationFactor <= 0 {
+			errs = append(errs, fmt.Errorf("KafkaCfg.ReplicationFactor must be positive, got %d", cfg.KafkaCfg.ReplicationFactor))
+		}
+	}
+	oneOf("KafkaCfg.Compression", cfg.KafkaCfg.Compression, "", "none", "gzip", "snappy", "lz4", "zstd")
+	oneOf("KafkaCfg.RequiredAcks", cfg.KafkaCfg.RequiredAcks, requiredAcks...)
+	delivery := func(name string, deliveryCfg *db_config_model.KafkaDeliveryConfigModel) {
+		oneOf(name+".Mode", deliveryCfg.Mode, "", "async", "sync")
+		oneOf(name+".RequiredAcks", deliveryCfg.RequiredAcks, requiredAcks...)
+		nonNegative(name+".TimeoutMs", deliveryCfg.TimeoutMs)
+	}
+	delivery("KafkaCfg.DataDelivery", &cfg.KafkaCfg.DataDelivery)
+	delivery("KafkaCfg.EventDelivery", &cfg.KafkaCfg.EventDelivery)
+	nonNegative("KafkaCfg.BatchSize", cfg.KafkaCfg.BatchSize)
+	nonNegative("KafkaCfg.LingerMs", cfg.KafkaCfg.LingerMs)
+	nonNegative("KafkaCfg.FetchMinBytes", cfg.KafkaCfg.FetchMinBytes)
+	nonNegative("KafkaCfg.FetchMaxBytes", cfg.KafkaCfg.FetchMaxBytes)
+	nonNegative("KafkaCfg.FetchMaxWaitMs", cfg.KafkaCfg.FetchMaxWaitMs)
+	if cfg.KafkaCfg.FetchMaxBytes > 0 && cfg.KafkaCfg.FetchMinBytes > cfg.KafkaCfg.FetchMaxBytes {
+		errs = append(errs, errors.New("KafkaCfg.FetchMinBytes must not exceed KafkaCfg.FetchMaxBytes"))
+	}
+
+	oneOf("RedisCfg.Mode", cfg.RedisCfg.Mode, "", "standalone", "sentinel", "cluster")
+	switch strings.ToLower(cfg.RedisCfg.Mode) {
+	case "", "standalone":
+		required("RedisCfg.Host", cfg.RedisCfg.Host)
+		port("RedisCfg.Port", cfg.RedisCfg.Port)
+		redisDB("RedisCfg.DB", cfg.RedisCfg.DB)
+	case "sentinel":
+		required("RedisCfg.MasterName", cfg.RedisCfg.MasterName)
+		if len(cfg.RedisCfg.Addrs) == 0 {
+			errs = append(errs, errors.New("RedisCfg.Addrs is required in sentinel mode"))
+		}
+		redisDB("RedisCfg.DB", cfg.RedisCfg.DB)
+	case "cluster":
+		if len(cfg.RedisCfg.Addrs) == 0 {
+			errs = append(errs, errors.New("RedisCfg.Addrs is required in cluster mode"))
+		}
+		if cfg.RedisCfg.DB != 0 {
+			errs = append(errs, errors.New("RedisCfg.DB must be 0 in cluster mode"))
+		}
+	}
+	tlsFiles("RedisCfg.TLS", &cfg.RedisCfg.TLS)
+	prefixes := map[string]string{}
+	prefixItems := [][2]string{
+		{"RedisCfg.StatusPrefix", cfg.RedisCfg.StatusPrefix}, {"RedisCfg.EventPrefix", cfg.RedisCfg.EventPrefix},
+		{"RedisCfg.AircraftPrefix", cfg.RedisCfg.AircraftPrefix}, {"RedisCfg.TaskInfoPrefix", cfg.RedisCfg.TaskInfoPrefix},
+		{"RedisCfg.DedupPrefix", cfg.RedisCfg.DedupPrefix}, {"RedisCfg.RateLimitPrefix", cfg.RedisCfg.RateLimitPrefix},
+	}
+	if strings.EqualFold(cfg.BusCfg.Backend, "redis") {
+		prefixItems = append(prefixItems, [2]string{"BusCfg.StreamPrefix", cfg.BusCfg.StreamPrefix})
+	}
+	for _, item := range prefixItems {
+		name, prefix := item[0], item[1]
+		required(name, prefix)
+		if other, ok := prefixes[prefix]; ok && prefix != "" {
+			errs = append(errs, fmt.Errorf("%s and %s must differ, both are %q", other, name, prefix))
+		}
+		prefixes[prefix] = name
+	}
+
+	required("MySqlCfg.Usr", cfg.MySqlCfg.Usr)
+	required("MySqlCfg.Psw", cfg.MySqlCfg.Psw)
+	required("MySqlCfg.Host", cfg.MySqlCfg.Host)
+	required("MySqlCfg.DB", cfg.MySqlCfg.DB)
+	required("MySqlCfg.FlightDB", cfg.MySqlCfg.FlightDB)
+	required("MySqlCfg.EventDB", cfg.MySqlCfg.EventDB)
+	port("MySqlCfg.Port", cfg.MySqlCfg.Port)
+
+	nonNegative("PipelineCfg.DedupWindowSec", cfg.PipelineCfg.DedupWindowSec)
+	if cfg.PipelineCfg.DedupWindowSec > 0 && cfg.PipelineCfg.DedupMaxEntries <= 0 {
+		errs = append(errs, errors.New("PipelineCfg.DedupMaxEntries must be positive when dedup is enabled"))
+	}
+	nonNegative("PipelineCfg.EventHistorySize", cfg.PipelineCfg.EventHistorySize)
+	nonNegative("PipelineCfg.EventGlobalHistorySize", cfg.PipelineCfg.EventGlobalHistorySize)
+	nonNegative("PipelineCfg.EventHistoryTTLSec", cfg.PipelineCfg.EventHistoryTTLSec)
+	nonNegative("PipelineCfg.StatusTTLSec", cfg.PipelineCfg.StatusTTLSec)
+	nonNegative("PipelineCfg.LinkTimeoutSec", cfg.PipelineCfg.LinkTimeoutSec)
+	nonNegative("PipelineCfg.EventCatalogRefreshSec", cfg.PipelineCfg.EventCatalogRefreshSec)
+	if _, err := time.LoadLocation(cfg.PipelineCfg.LegacyTimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("PipelineCfg.LegacyTimeZone: %w", err))
+	}
+	if cfg.PipelineCfg.LinkTimeoutSec > 0 && cfg.PipelineCfg.LinkCheckIntervalSec <= 0 {
+		errs = append(errs, errors.New("PipelineCfg.LinkCheckIntervalSec must be positive when link watchdog is enabled"))
+	}
+	if cfg.PipelineCfg.StatusTTLSec > 0 && cfg.PipelineCfg.StatusTTLSec <= cfg.PipelineCfg.LinkTimeoutSec {
+		errs = append(errs, errors.New("PipelineCfg.StatusTTLSec must exceed PipelineCfg.LinkTimeoutSec"))
+	}
+
+	oneOf("AlertCfg.MinSeverity", cfg.AlertCfg.MinSeverity, "", "info", "warning", "critical")
+	nonNegative("AlertCfg.EscalateAfterSec", cfg.AlertCfg.EscalateAfterSec)
+	if cfg.AlertCfg.Enable && cfg.AlertCfg.EscalateAfterSec > 0 &&
+		(cfg.AlertCfg.CheckIntervalSec <= 0 || cfg.AlertCfg.MaxEscalationLevel <= 0) {
+		errs = append(errs, errors.New("AlertCfg.CheckIntervalSec and MaxEscalationLevel must be positive when escalation is enabled"))
+	}
+	if webhookCfg := &cfg.WebhookCfg; webhookCfg.Enable {
+		if webhookCfg.TimeoutMs <= 0 || webhookCfg.MaxAttempts <= 0 || webhookCfg.InitialBackoffSec <= 0 ||
+			webhookCfg.PollIntervalSec <= 0 || webhookCfg.BatchSize <= 0 {
+			errs = append(errs, errors.New("WebhookCfg.TimeoutMs, MaxAttempts, InitialBackoffSec, PollIntervalSec and BatchSize must be positive"))
+		}
+		if webhookCfg.MaxBackoffSec < webhookCfg.InitialBackoffSec {
+			errs = append(errs, errors.New("WebhookCfg.MaxBackoffSec must not be less than InitialBackoffSec"))
+		}
+	}
+	if rateLimitCfg := &cfg.RateLimitCfg; rateLimitCfg.Enable {
+		if rateLimitCfg.UsageRetentionDays <= 0 {
+			errs = append(errs, errors.New("RateLimitCfg.UsageRetentionDays must be positive"))
+		}
+		for _, scope := range []struct {
+			name  string
+			rules *db_config_model.RateLimitScopeModel
+		}{{"Upload", &rateLimitCfg.Upload}, {"Query", &rateLimitCfg.Query}} {
+			for _, rule := range []struct {
+				name string
+				rule *db_config_model.RateLimitRule
+			}{{"Aircraft", &scope.rules.Aircraft}, {"Credential", &scope.rules.Credential}, {"IP", &scope.rules.IP}} {
+				name := "RateLimitCfg." + scope.name + "." + rule.name
+				if rule.rule.Rate < 0 || rule.rule.DailyQuota < 0 {
+					errs = append(errs, fmt.Errorf("%s.Rate and DailyQuota must not be negative", name))
+				}
+				if rule.rule.Rate > 0 && rule.rule.Burst < 1 {
+					errs = append(errs, fmt.Errorf("%s.Burst must be at least 1 when Rate is set", name))
+				}
+			}
+		}
+	}
+	if validationCfg := &cfg.ValidationCfg; validationCfg.Enable {
+		if validationCfg.MinAltitude >= validationCfg.MaxAltitude {
+			errs = append(errs, errors.New("ValidationCfg.MinAltitude must be less than MaxAltitude"))
+		}
+		if validationCfg.MaxSpeedMps < 0 {
+			errs = append(errs, errors.New("ValidationCfg.MaxSpeedMps must not be negative"))
+		}
+		if validationCfg.MaxSpeedMps > 0 && validationCfg.SpeedWindowSec <= 0 {
+			errs = append(errs, errors.New("ValidationCfg.SpeedWindowSec must be positive when MaxSpeedMps is set"))
+		}
+		nonNegative("ValidationCfg.MaxFutureSec", validationCfg.MaxFutureSec)
+		nonNegative("ValidationCfg.MaxPastSec", validationCfg.MaxPastSec)
+	}
 
-	var config db_config_model.DbConfigModel
-	err = yaml.Unmarshal(data, &config)
+	return errors.Join(errs...)
+}
+
+// DumpDBConfig 以 yaml 输出生效配置，带 secret 标签的字段会被脱敏
+func DumpDBConfig(cfg *db_config_model.DbConfigModel) string {
+	redacted := *cfg
+	redactSecrets(reflect.ValueOf(&redacted).Elem())
+	data, err := yaml.Marshal(&redacted)
 	if err != nil {
-		return nil, err
+		return err.Error()
+	}
+	return string(data)
+}
+
+func configFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" || name == "-" {
+		name = field.Name
+	}
+	return name
+}
+
+func applyEnvOverrides(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		envName := prefix + "_" + strings.ToUpper(configFieldName(field))
+		fieldValue := v.Field(i)
+		if fieldValue.Kind() == reflect.Struct {
+			if err := applyEnvOverrides(fieldValue, envName); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok, err := lookupEnvOrFile(envName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err = setConfigField(fieldValue, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", envName, err)
+		}
+	}
+	return nil
+}
+
+// lookupEnvOrFile 优先读取 NAME_FILE 指向的文件内容，其次读取 NAME 环境变量
+func lookupEnvOrFile(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("read secret file for %s: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
 	}
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+func setConfigField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func redactSecrets(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+		if fieldValue.Kind() == reflect.Struct {
+			redactSecrets(fieldValue)
+			continue
+		}
+		if field.Tag.Get("secret") == "true" && fieldValue.Kind() == reflect.String && fieldValue.String() != "" {
+			fieldValue.SetString(redactedValue)
+		}
+	}
+}
 
-	return &config, nil
+// MySqlDSN 构建 MySQL 连接串，db 为空时不指定默认库；连接按 UTC 读写时间，
+// 会话时区同为 UTC，列默认值 CURRENT_TIMESTAMP 与写入的时间一致
+func MySqlDSN(cfg *db_config_model.MySqlConfigModel, db string) string {
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27",
+		cfg.Usr, cfg.Psw, cfg.Host, cfg.Port, db,
+	)
 }
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// JSON Schema 子集：type（字符串或数组）、properties、required、additionalProperties（布尔值）、
+// items、enum、minimum、maximum、minLength、maxLength。其余关键字视为 schema 错误，避免被静默忽略
+
+// jsonSchema 解析后的 schema 节点
+type jsonSchema struct {
+	Type                 interface{}            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Schema               string                 `json:"$schema"`
+	Title                string                 `json:"title"`
+	Description          string                 `json:"description"`
+	types                []string
+}
+
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// CompileJSONSchema 检查 schema 是否为支持的 JSON Schema 子集
+func CompileJSONSchema(schema json.RawMessage) error {
+	_, err := parseJSONSchema(schema)
+	return err
+}
+
+// ValidateJSONSchema 按 schema 校验 document，返回第一处不符合的位置与原因
+func ValidateJSONSchema(schema, document json.RawMessage) error {
+	root, err := parseJSONSchema(schema)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err = decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return root.validate("$", value)
+}
+
+func parseJSONSchema(raw json.RawMessage) (*jsonSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var schema jsonSchema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, err
+	}
+	if err := schema.compile("$"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// compile 检查关键字取值并展开 type
+func (s *jsonSchema) compile(path string) error {
+	switch t := s.Type.(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%s: type must be a string or an array of strings", path)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return fmt.Errorf("%s: type must be a string or an array of strings", path)
+	}
+	for _, name := range s.types {
+		if !jsonSchemaTypes[name] {
+			return fmt.Errorf("%s: unsupported type %q", path, name)
+		}
+	}
+	if (s.MinLength != nil && *s.MinLength < 0) || (s.MaxLength != nil && *s.MaxLength < 0) {
+		return fmt.Errorf("%s: minLength/maxLength must be non-negative", path)
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: schema must be an object", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+func (s *jsonSchema) validate(path string, value interface{}) error {
+	if len(s.types) > 0 && !s.matchesType(value) {
+		return fmt.Errorf("%s: expected %v, got %s", path, s.types, jsonTypeOf(value))
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		return fmt.Errorf("%s: value is not one of the allowed values", path)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.validateObject(path, v)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: shorter than %d", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: longer than %d", path, *s.MaxLength)
+		}
+	case json.Number:
+		number, _ := v.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			return fmt.Errorf("%s: less than minimum %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return fmt.Errorf("%s: greater than maximum %v", path, *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateObject(path string, object map[string]interface{}) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+	// 按属性名排序，保证同一文档总是报告同一处错误
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s: unexpected property %q", path, name)
+			}
+			continue
+		}
+		if err := property.validate(path+"."+name, object[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) matchesType(value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, expected := range s.types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *jsonSchema) inEnum(value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.Enum {
+		if candidate, err := json.Marshal(allowed); err == nil && jsonEqual(encoded, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual 比较两段 JSON 的值，数字按数值比较
+func jsonEqual(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	ax, _ := json.Marshal(x)
+	by, _ := json.Marshal(y)
+	return bytes.Equal(ax, by)
+}
+
+// jsonTypeOf 返回解码值对应的 JSON Schema 类型名，整数值记为 integer
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if number, err := v.Float64(); err == nil && number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
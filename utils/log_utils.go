@@ -1,100 +1,171 @@
 package utils
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"uam-power-backend/models/config_models/db_config_model"
 )
 
 var (
-	mutex     sync.Mutex
-	logger    *log.Logger
-	logDir    = "./logs" // 日志文件存放的目录
-	logPrefix = "log-"   // 日志文件名前缀
+	mutex      sync.RWMutex
+	logger     = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logFile    io.Closer
+	logDir     = "./logs" // 日志文件存放的目录
+	logPrefix  = "log-"   // 日志文件名前缀
+	logLevels  = map[string]slog.Level{"debug": slog.LevelDebug, "info": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError}
+	requestKey = contextKey("RequestID")
+	loggerKey  = contextKey("Logger")
 )
 
-// Log 结构体
-type Log struct{}
+type contextKey string
 
-// NewLog 创建一个新的 Log 实例
-func NewLog() *Log {
-	return &Log{}
-}
+// RequestIDHeader 请求 ID 在 HTTP 头与 Kafka 消息头中使用的名称
+const RequestIDHeader = "X-Request-ID"
 
-// Init 初始化日志系统，设置日志文件
-func (l *Log) Init() error {
-	// 获取当前时间，并格式化为日志文件名
-	//now := time.Now()
-	//logFileName := fmt.Sprintf("%s/%s%s.log", logDir, logPrefix, now.Format("20060102150405"))
+// InitLog 以默认配置（console 格式、info 级别、仅输出到 stdout）初始化日志
+func InitLog() {
+	_ = InitLogWithConfig(&db_config_model.LogConfigModel{})
+}
 
-	// 确保日志目录存在
-	if _, err := os.Stat(logDir); os.IsNotExist(err) {
-		err := os.MkdirAll(logDir, 0755)
-		if err != nil {
+// InitLogWithConfig 按配置初始化结构化日志，可选写入 Dir 下按大小滚动的日志文件
+func InitLogWithConfig(cfg *db_config_model.LogConfigModel) error {
+	level, ok := logLevels[strings.ToLower(cfg.Level)]
+	if !ok && cfg.Level != "" {
+		return fmt.Errorf("unknown log level %q", cfg.Level)
+	}
+	var writer io.Writer = os.Stdout
+	var closer io.Closer
+	if cfg.ToFile {
+		dir := cfg.Dir
+		if dir == "" {
+			dir = logDir
+		}
+		// 确保日志目录存在
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			return fmt.Errorf("failed to create log directory: %v", err)
 		}
+		rotating := &lumberjack.Logger{
+			Filename:   filepath.Join(dir, logPrefix+"uam-power-backend.log"),
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			LocalTime:  true,
+		}
+		writer = io.MultiWriter(os.Stdout, rotating)
+		closer = rotating
 	}
 
-	// 打开日志文件，文件不存在则创建
-	//file, err := os.OpenFile(logFileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-	//if err != nil {
-	//	return fmt.Errorf("failed to open log file: %v", err)
-	//}
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "console":
+		handler = slog.NewTextHandler(writer, options)
+	case "json":
+		handler = slog.NewJSONHandler(writer, options)
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
 
-	// 使用 log.New 创建一个 Logger
-	//logger = log.New(file, "", log.Ldate|log.Ltime|log.Lmicroseconds)
-	logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmicroseconds)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+	logger, logFile = slog.New(handler), closer
 	return nil
 }
 
-// Write 写入日志
-func (l *Log) Write(message string) {
+// CloseLog 关闭日志文件
+func CloseLog() {
 	mutex.Lock()
 	defer mutex.Unlock()
+	if logFile != nil {
+		_ = logFile.Close()
+		logFile = nil
+	}
+}
 
-	// 输出日志，带时间戳
-	logger.Println(message)
+// Logger 返回全局结构化日志器
+func Logger() *slog.Logger {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return logger
 }
 
-func (l *Log) Info(message string) {
-	l.Write(fmt.Sprintf("%s☐", message))
+// ComponentLogger 返回带 component 字段的日志器
+func ComponentLogger(component string) *slog.Logger {
+	return Logger().With("component", component)
 }
 
-func (l *Log) Error(message string) {
-	l.Write(fmt.Sprintf("%s☒", message))
+// ContextWithRequestID 将请求 ID 写入 context
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestKey, requestID)
 }
 
-func (l *Log) Success(message string) {
-	l.Write(fmt.Sprintf("%s☑", message))
+// RequestIDFromContext 读取 context 中的请求 ID，不存在时返回空串
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestKey).(string)
+	return requestID
 }
 
-// Close 关闭日志文件
-func (l *Log) Close() {
-	if logger != nil && logger.Writer() != nil {
-		if file, ok := logger.Writer().(*os.File); ok {
-			_ = file.Close()
-		}
+// ContextWithLogger 将日志器写入 context，供下游沿用其上下文字段
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// LoggerFromContext 返回 context 中的日志器，并附带请求 ID 字段
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
 	}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return Logger().With("request_id", requestID)
+	}
+	return Logger()
 }
 
-var LogInfo Log
+// splitComponent 将 "    [Component]message" 形式的旧日志拆分为组件名与消息
+func splitComponent(msg string) (string, string) {
+	msg = strings.TrimSpace(msg)
+	if strings.HasPrefix(msg, "[") {
+		if end := strings.Index(msg, "]"); end > 0 {
+			return msg[1:end], strings.TrimSpace(msg[end+1:])
+		}
+	}
+	return "", msg
+}
 
-func InitLog() {
-	err := LogInfo.Init()
-	if err != nil {
-		return
+func logMsg(level slog.Level, msg string, args ...any) {
+	component, text := splitComponent(msg)
+	if component != "" {
+		args = append([]any{"component", component}, args...)
 	}
+	Logger().Log(context.Background(), level, text, args...)
+}
+
+func MsgDebug(msg string) {
+	logMsg(slog.LevelDebug, msg)
+}
+
+func MsgWarn(msg string) {
+	logMsg(slog.LevelWarn, msg)
 }
 
 func MsgError(msg string) {
-	LogInfo.Error(msg)
+	logMsg(slog.LevelError, msg)
 }
 
 func MsgInfo(msg string) {
-	LogInfo.Info(msg)
+	logMsg(slog.LevelInfo, msg)
 }
 
 func MsgSuccess(msg string) {
-	LogInfo.Success(msg)
+	logMsg(slog.LevelInfo, msg, "status", "success")
 }
@@ -1,29 +1,79 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 )
 
+// SqlTimeLayout MySQL DATETIME(6) 的时间格式；上传的时间归一化为该格式的 UTC 时间后写入 Redis 与 MySQL
+const SqlTimeLayout = "2006-01-02 15:04:05.000000"
+
+// GetTimeStr 返回当前 UTC 时间精确到微秒的 20 位数字串，用作批次标识与表名前缀
 func GetTimeStr() string {
-	currentTime := time.Now()
-	// 定义格式化样式
-	formattedTime := currentTime.Format("20060102150405012345")
-	return formattedTime
+	currentTime := time.Now().UTC()
+	return currentTime.Format("20060102150405") + fmt.Sprintf("%06d", currentTime.Nanosecond()/int(time.Microsecond))
 }
 
+// GetMySqlTimeStr 返回当前 UTC 时间的 SqlTimeLayout 格式
 func GetMySqlTimeStr() string {
-	now := time.Now()
-	return now.Format("2006-01-02 15:04:05.000000")
+	return FormatSqlTime(time.Now())
 }
 
 func TransferTimeStrToSqlTimeStr(str string) string {
-	parsedTime, _ := time.Parse("2006-01-02 15:04:05.000000", str)
+	parsedTime, _ := time.Parse(SqlTimeLayout, str)
 	// 格式化为目标格式
-	return parsedTime.Format("2006-01-02 15:04:05.000000")
+	return parsedTime.Format(SqlTimeLayout)
 }
 
 func IsValidSqlTimeFormat(str string) bool {
 	// Parse the string using the desired format
-	_, err := time.Parse("2006-01-02 15:04:05.000000", str)
+	_, err := time.Parse(SqlTimeLayout, str)
 	return err == nil // If err is nil, the string matches the format
 }
+
+// ParseSqlTimeStr 按 SqlTimeLayout 解析 UTC 时间，与数据库连接（loc=UTC）保持一致
+func ParseSqlTimeStr(str string) (time.Time, error) {
+	return time.ParseInLocation(SqlTimeLayout, str, time.UTC)
+}
+
+// FormatSqlTime 将 t 转换为 UTC 并按 SqlTimeLayout 格式化
+func FormatSqlTime(t time.Time) string {
+	return t.UTC().Format(SqlTimeLayout)
+}
+
+// ParseFlexibleTime 解析 RFC3339（须带时区）、毫秒时间戳或 SqlTimeLayout 格式的时间，
+// 不带时区的 SqlTimeLayout 按 legacy 时区解释（为 nil 时按 UTC），返回 UTC 时间
+func ParseFlexibleTime(str string, legacy *time.Location) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, errors.New("empty time")
+	}
+	if isDigits(str) {
+		millis, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(millis).UTC(), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return parsed.UTC(), nil
+	}
+	if legacy == nil {
+		legacy = time.UTC
+	}
+	parsed, err := time.ParseInLocation(SqlTimeLayout, str, legacy)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time %q is not RFC3339, epoch milliseconds or %q", str, SqlTimeLayout)
+	}
+	return parsed.UTC(), nil
+}
+
+func isDigits(str string) bool {
+	for _, r := range str {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}